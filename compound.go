@@ -0,0 +1,368 @@
+package imgHelper
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+)
+
+// CompoundOp 复合范围的布尔运算
+type CompoundOp int
+
+const (
+	CompoundUnion      CompoundOp = iota // 并集，保留所有范围覆盖的区域
+	CompoundIntersect                    // 交集，只保留所有范围重叠的区域
+	CompoundDifference                   // 差集，从第一个范围中减去其余范围覆盖的区域
+	CompoundXor                          // 异或，保留不重叠的区域
+)
+
+// RangeCompound 复合范围：多个范围通过布尔运算组合，范围可以是任意 RangeValue，包括复合范围本身
+// 运算在多边形上进行(圆形、曲边多边形先展开为折线)，结果是由若干闭合环组成的区域，环按奇偶规则填充，可以带孔洞，
+// 通过 Path 取得结果的轮廓；可用于 OpsCrop、OpsMosaic、OpsRegion，也可以通过 NewSolidRange、NewOutlineRange 绘制到几何图层
+// 包含掩码范围(RangeMask)时没有几何轮廓，改为在抗锯齿的覆盖掩码上逐像素运算(并集取最大值、交集取最小值、差集为 a-b、异或为 |a-b|)，
+// 此时 Path 返回错误，也不能用 NewOutlineRange 描边
+// 例如带孔洞的多边形: RangeDifference(RangePolygon{...}, RangePolygon{...}, RangeCircle{...})
+type RangeCompound struct {
+	Op     CompoundOp
+	Ranges []RangeValue
+}
+
+func (RangeCompound) Type() RangeType {
+	return RangeCompoundType
+}
+
+// RangeUnion 范围的并集
+func RangeUnion(ranges ...RangeValue) RangeCompound {
+	return RangeCompound{Op: CompoundUnion, Ranges: ranges}
+}
+
+// RangeIntersect 范围的交集
+func RangeIntersect(ranges ...RangeValue) RangeCompound {
+	return RangeCompound{Op: CompoundIntersect, Ranges: ranges}
+}
+
+// RangeDifference 从 base 中减去 others 覆盖的区域
+func RangeDifference(base RangeValue, others ...RangeValue) RangeCompound {
+	return RangeCompound{Op: CompoundDifference, Ranges: append([]RangeValue{base}, others...)}
+}
+
+// RangeXor 范围的异或，依次对每个范围做异或，即保留被奇数个范围覆盖的区域
+func RangeXor(ranges ...RangeValue) RangeCompound {
+	return RangeCompound{Op: CompoundXor, Ranges: ranges}
+}
+
+// Path 布尔运算结果的轮廓，每个闭合环为一个子路径，可用于 NewSolidPath、NewOutlinePath
+func (rg RangeCompound) Path() (*RangePath, error) {
+	rings, err := compoundRings(rg)
+	if err != nil {
+		return nil, err
+	}
+	p := &RangePath{rings: rings, closed: make([]bool, len(rings))}
+	for i := range p.closed {
+		p.closed[i] = true
+	}
+	return p, nil
+}
+
+// hasMask 是否包含掩码范围
+func (rg RangeCompound) hasMask() bool {
+	for _, r := range rg.Ranges {
+		if r.Type() == RangeMaskType || (r.Type() == RangeCompoundType && r.(RangeCompound).hasMask()) {
+			return true
+		}
+	}
+	return false
+}
+
+// compoundRings 依次对各个范围的多边形做布尔运算，返回结果的闭合环
+func compoundRings(rg RangeCompound) ([][][2]float64, error) {
+	if len(rg.Ranges) == 0 {
+		return nil, fmt.Errorf("复合范围至少需要一个范围")
+	}
+	if rg.Op < CompoundUnion || rg.Op > CompoundXor {
+		return nil, fmt.Errorf("不支持的布尔运算 %d", rg.Op)
+	}
+	if rg.hasMask() {
+		return nil, fmt.Errorf("包含掩码范围的复合范围没有几何轮廓")
+	}
+	rings, err := rangeRings(rg.Ranges[0])
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rg.Ranges[1:] {
+		other, err := rangeRings(r)
+		if err != nil {
+			return nil, err
+		}
+		rings = clipRings(rings, other, rg.Op)
+	}
+	return rings, nil
+}
+
+// compoundMask 包含掩码范围的复合范围在 bounds 内的覆盖掩码，逐像素运算
+func compoundMask(rg RangeCompound, bounds image.Rectangle) (*image.Gray, error) {
+	if len(rg.Ranges) == 0 {
+		return nil, fmt.Errorf("复合范围至少需要一个范围")
+	}
+	dst, err := rangeMask(rg.Ranges[0], bounds)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rg.Ranges[1:] {
+		mask, err := rangeMask(r, bounds)
+		if err != nil {
+			return nil, err
+		}
+		for i, b := range mask.Pix {
+			a := dst.Pix[i]
+			switch rg.Op {
+			case CompoundUnion:
+				dst.Pix[i] = maxValue(a, b)
+			case CompoundIntersect:
+				dst.Pix[i] = minValue(a, b)
+			case CompoundDifference:
+				dst.Pix[i] = a - minValue(a, b)
+			case CompoundXor:
+				dst.Pix[i] = maxValue(a, b) - minValue(a, b)
+			default:
+				return nil, fmt.Errorf("不支持的布尔运算 %d", rg.Op)
+			}
+		}
+	}
+	return dst, nil
+}
+
+// clipEdge 参与布尔运算的边，splits 为边上的分割点(参数 t 和坐标)
+type clipEdge struct {
+	a, b   [2]float64
+	splits []clipSplit
+}
+
+type clipSplit struct {
+	t float64
+	p [2]float64
+}
+
+// clipEpsilon 判断共线、端点重合的容差(像素)
+const clipEpsilon = 1e-9
+
+// clipRings 多边形布尔运算，a、b 均按奇偶规则填充，可以自相交、带孔洞
+// 在所有交点处切分两组边，对每段边检查两侧的点是否属于运算结果，只保留两侧结果不同的边，再连接成闭合环
+func clipRings(a, b [][][2]float64, op CompoundOp) [][][2]float64 {
+	edges := append(ringEdges(a), ringEdges(b)...)
+	splitEdges(edges)
+
+	type segment struct{ a, b [2]float64 }
+	var kept []segment
+	seen := make(map[[4]int64]bool)
+	for _, e := range edges {
+		sort.Slice(e.splits, func(i, j int) bool { return e.splits[i].t < e.splits[j].t })
+		prev := e.a
+		points := make([][2]float64, 0, len(e.splits)+1)
+		for _, s := range e.splits {
+			points = append(points, s.p)
+		}
+		points = append(points, e.b)
+		for _, p := range points {
+			dx, dy := p[0]-prev[0], p[1]-prev[1]
+			length := math.Hypot(dx, dy)
+			if length < clipEpsilon {
+				continue
+			}
+			// 段中点沿法线方向两侧各取一点，两侧的运算结果不同时该段是结果的边界
+			mx, my := (prev[0]+p[0])/2, (prev[1]+p[1])/2
+			off := math.Min(1e-4, length/4)
+			nx, ny := -dy/length*off, dx/length*off
+			left := clipOp(op, pointInRings(mx+nx, my+ny, a), pointInRings(mx+nx, my+ny, b))
+			right := clipOp(op, pointInRings(mx-nx, my-ny, a), pointInRings(mx-nx, my-ny, b))
+			if left != right {
+				// 两组多边形重合的边只保留一条
+				ka, kb := clipKey(prev), clipKey(p)
+				if ka[0] > kb[0] || (ka[0] == kb[0] && ka[1] > kb[1]) {
+					ka, kb = kb, ka
+				}
+				if key := [4]int64{ka[0], ka[1], kb[0], kb[1]}; !seen[key] {
+					seen[key] = true
+					kept = append(kept, segment{prev, p})
+				}
+			}
+			prev = p
+		}
+	}
+
+	// 按端点连接边界段，结果按奇偶规则填充，所以不需要区分环的方向
+	adjacent := make(map[[2]int64][]int)
+	for i, s := range kept {
+		adjacent[clipKey(s.a)] = append(adjacent[clipKey(s.a)], i)
+		adjacent[clipKey(s.b)] = append(adjacent[clipKey(s.b)], i)
+	}
+	used := make([]bool, len(kept))
+	var rings [][][2]float64
+	for i := range kept {
+		if used[i] {
+			continue
+		}
+		used[i] = true
+		start := clipKey(kept[i].a)
+		ring := [][2]float64{kept[i].a}
+		cur := kept[i].b
+		for clipKey(cur) != start {
+			next := -1
+			for _, j := range adjacent[clipKey(cur)] {
+				if !used[j] {
+					next = j
+					break
+				}
+			}
+			if next < 0 {
+				break
+			}
+			used[next] = true
+			ring = append(ring, cur)
+			if clipKey(kept[next].a) == clipKey(cur) {
+				cur = kept[next].b
+			} else {
+				cur = kept[next].a
+			}
+		}
+		if len(ring) >= 3 {
+			rings = append(rings, ring)
+		}
+	}
+	return rings
+}
+
+func clipOp(op CompoundOp, inA, inB bool) bool {
+	switch op {
+	case CompoundIntersect:
+		return inA && inB
+	case CompoundDifference:
+		return inA && !inB
+	case CompoundXor:
+		return inA != inB
+	default:
+		return inA || inB
+	}
+}
+
+// clipKey 连接边界段时用于判断端点重合的键
+func clipKey(p [2]float64) [2]int64 {
+	return [2]int64{int64(math.Round(p[0] * 1e6)), int64(math.Round(p[1] * 1e6))}
+}
+
+// ringEdges 环的所有边，去掉长度为0的边
+func ringEdges(rings [][][2]float64) []*clipEdge {
+	var edges []*clipEdge
+	for _, ring := range rings {
+		if len(ring) < 3 {
+			continue
+		}
+		for i := range ring {
+			a, b := ring[i], ring[(i+1)%len(ring)]
+			if a != b {
+				edges = append(edges, &clipEdge{a: a, b: b})
+			}
+		}
+	}
+	return edges
+}
+
+// splitEdges 求所有边两两之间的交点(包括共线重叠部分的端点)，记录为各条边的分割点
+// 同一个交点在两条边上使用相同的坐标，连接时端点能精确重合
+func splitEdges(edges []*clipEdge) {
+	// 按 x 的最小值排序，只比较 x 范围重叠的边
+	order := make([]int, len(edges))
+	for i := range order {
+		order[i] = i
+	}
+	minX := func(e *clipEdge) float64 { return math.Min(e.a[0], e.b[0]) }
+	maxX := func(e *clipEdge) float64 { return math.Max(e.a[0], e.b[0]) }
+	sort.Slice(order, func(i, j int) bool { return minX(edges[order[i]]) < minX(edges[order[j]]) })
+	for oi, i := range order {
+		ei := edges[i]
+		for _, j := range order[oi+1:] {
+			ej := edges[j]
+			if minX(ej) > maxX(ei)+clipEpsilon {
+				break
+			}
+			if math.Min(ei.a[1], ei.b[1]) > math.Max(ej.a[1], ej.b[1])+clipEpsilon ||
+				math.Min(ej.a[1], ej.b[1]) > math.Max(ei.a[1], ei.b[1])+clipEpsilon {
+				continue
+			}
+			intersectEdges(ei, ej)
+		}
+	}
+}
+
+// intersectEdges 求两条边的交点并记录分割点，交点在边的端点上时不分割该边
+func intersectEdges(e, f *clipEdge) {
+	rx, ry := e.b[0]-e.a[0], e.b[1]-e.a[1]
+	sx, sy := f.b[0]-f.a[0], f.b[1]-f.a[1]
+	qx, qy := f.a[0]-e.a[0], f.a[1]-e.a[1]
+	lr, ls := math.Hypot(rx, ry), math.Hypot(sx, sy)
+	denom := rx*sy - ry*sx
+	// 端点 p 在边 g 的内部时在 p 处分割 g
+	splitAt := func(g *clipEdge, p [2]float64) {
+		gx, gy := g.b[0]-g.a[0], g.b[1]-g.a[1]
+		l2 := gx*gx + gy*gy
+		t := ((p[0]-g.a[0])*gx + (p[1]-g.a[1])*gy) / l2
+		if t*math.Sqrt(l2) <= clipEpsilon || (1-t)*math.Sqrt(l2) <= clipEpsilon {
+			return
+		}
+		if math.Abs((p[0]-g.a[0])*gy-(p[1]-g.a[1])*gx)/math.Sqrt(l2) > 1e-7 {
+			return
+		}
+		g.splits = append(g.splits, clipSplit{t, p})
+	}
+	if math.Abs(denom) <= 1e-12*lr*ls {
+		// 平行，共线时在对方端点处分割
+		if math.Abs(qx*ry-qy*rx)/lr > 1e-7 {
+			return
+		}
+		splitAt(e, f.a)
+		splitAt(e, f.b)
+		splitAt(f, e.a)
+		splitAt(f, e.b)
+		return
+	}
+	t := (qx*sy - qy*sx) / denom
+	u := (qx*ry - qy*rx) / denom
+	tol := 1e-9
+	if t < -tol || t > 1+tol || u < -tol || u > 1+tol {
+		return
+	}
+	// 交点在某条边的端点上时使用端点的精确坐标
+	switch {
+	case t*lr <= clipEpsilon:
+		splitAt(f, e.a)
+	case (1-t)*lr <= clipEpsilon:
+		splitAt(f, e.b)
+	case u*ls <= clipEpsilon:
+		splitAt(e, f.a)
+	case (1-u)*ls <= clipEpsilon:
+		splitAt(e, f.b)
+	default:
+		p := [2]float64{e.a[0] + t*rx, e.a[1] + t*ry}
+		e.splits = append(e.splits, clipSplit{t, p})
+		f.splits = append(f.splits, clipSplit{u, p})
+	}
+}
+
+// pointInRings 奇偶规则判断点是否在环组成的区域内
+func pointInRings(x, y float64, rings [][][2]float64) bool {
+	inside := false
+	for _, ring := range rings {
+		n := len(ring)
+		if n < 3 {
+			continue
+		}
+		for i, j := 0, n-1; i < n; j, i = i, i+1 {
+			a, b := ring[i], ring[j]
+			if (a[1] > y) != (b[1] > y) && x < a[0]+(y-a[1])*(b[0]-a[0])/(b[1]-a[1]) {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
@@ -0,0 +1,67 @@
+package imgHelper
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestCompoundRingsArea(t *testing.T) {
+	a := Range{X0: 0, Y0: 0, X1: 100, Y1: 100}
+	b := Range{X0: 50, Y0: 50, X1: 150, Y1: 150}
+	adjacent := Range{X0: 100, Y0: 0, X1: 200, Y1: 100}
+	hole := RangeCircle{Cx: 50, Cy: 50, R: 20}
+	cases := []struct {
+		name string
+		rg   RangeCompound
+		want float64
+		tol  float64
+	}{
+		{"相邻矩形的并集", RangeUnion(a, adjacent), 20000, 0},
+		{"重叠矩形的并集", RangeUnion(a, b), 17500, 0},
+		{"交集", RangeIntersect(a, b), 2500, 0},
+		{"差集", RangeDifference(a, b), 7500, 0},
+		{"异或", RangeXor(a, b), 15000, 0},
+		{"减去自身", RangeDifference(a, a), 0, 0},
+		{"没有重叠的交集", RangeIntersect(a, Range{X0: 120, Y0: 0, X1: 150, Y1: 30}), 0, 0},
+		{"圆形孔洞", RangeDifference(a, hole), 10000 - math.Pi*400, 4},
+		{"嵌套", RangeDifference(RangeUnion(a, b), RangeIntersect(a, b)), 15000, 0},
+	}
+	bounds := image.Rect(-10, -10, 210, 210)
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rings, err := compoundRings(c.rg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := maskArea(coverageMask(rings, bounds))
+			if math.Abs(got-c.want) > c.tol+1e-9 {
+				t.Errorf("面积 %g, want %g±%g", got, c.want, c.tol)
+			}
+		})
+	}
+}
+
+func TestCompoundMaskMatchesRings(t *testing.T) {
+	// 含掩码的复合范围走逐像素运算，结果应与多边形运算一致
+	bounds := image.Rect(0, 0, 160, 160)
+	a := Range{X0: 0, Y0: 0, X1: 100, Y1: 100}
+	b := Range{X0: 50, Y0: 50, X1: 150, Y1: 150}
+	maskB, err := rangeMask(b, bounds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, op := range []CompoundOp{CompoundUnion, CompoundIntersect, CompoundDifference, CompoundXor} {
+		want, err := rangeMask(RangeCompound{Op: op, Ranges: []RangeValue{a, b}}, bounds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := rangeMask(RangeCompound{Op: op, Ranges: []RangeValue{a, RangeMask{Mask: maskB}}}, bounds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if maskArea(got) != maskArea(want) {
+			t.Errorf("op %d: 掩码运算面积 %g, 多边形运算面积 %g", op, maskArea(got), maskArea(want))
+		}
+	}
+}
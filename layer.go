@@ -1,7 +1,9 @@
 package imgHelper
 
 import (
+	"fmt"
 	"image"
+	"image/draw"
 )
 
 // Layer 图层
@@ -19,14 +21,9 @@ const (
 	RangeCircleType   RangeType = "circle"
 	RangeTriangleType RangeType = "triangle"
 	RangePolygonType  RangeType = "polygon"
-	// todo 曲边多边形（Curvilinear Polygon）
-	// 核心特征
-	//边界组成：由至少 3 个曲线段首尾相连形成的闭合回路，区别于普通多边形的直边
-	//曲线类型：边可以是任意曲线，包括：
-	//圆弧（如鲁洛三角形）
-	//椭圆弧
-	//贝塞尔曲线段
-	//自由曲线（通过拟合离散点形成）
+	RangeMaskType     RangeType = "mask"
+	RangePathType     RangeType = "path"     // 曲边多边形（路径），见 RangePath
+	RangeCompoundType RangeType = "compound" // 复合范围（布尔运算），见 RangeCompound
 )
 
 type RangeValue interface {
@@ -83,3 +80,85 @@ type RangePolygon struct {
 func (RangePolygon) Type() RangeType {
 	return RangePolygonType
 }
+
+// Vertices 返回顶点数组，可直接用于 NewSolidPolygon、NewOutlinePolygon
+func (rg RangePolygon) Vertices() [][2]int {
+	vertices := make([][2]int, len(rg.Points))
+	for i, p := range rg.Points {
+		vertices[i] = [2]int{p.X, p.Y}
+	}
+	return vertices
+}
+
+// RangeMask 掩码范围，掩码值为0的像素不在范围内，255为完全在范围内，中间值表示部分覆盖
+type RangeMask struct {
+	Mask *image.Gray // 掩码，坐标与源图像一致
+}
+
+func (RangeMask) Type() RangeType {
+	return RangeMaskType
+}
+
+// rangeMask 将范围栅格化为 bounds 大小的掩码，掩码值为像素被范围覆盖的面积比例，边缘抗锯齿；掩码范围直接使用其掩码值
+func rangeMask(rg RangeValue, bounds image.Rectangle) (*image.Gray, error) {
+	if rg.Type() == RangeCompoundType && rg.(RangeCompound).hasMask() {
+		return compoundMask(rg.(RangeCompound), bounds)
+	}
+	if rg.Type() == RangeMaskType {
+		rgObj := rg.(RangeMask)
+		if rgObj.Mask == nil {
+			return nil, fmt.Errorf("掩码不能为空")
+		}
+		mask := image.NewGray(bounds)
+		draw.Draw(mask, bounds, rgObj.Mask, bounds.Min, draw.Src)
+		return mask, nil
+	}
+	rings, err := rangeRings(rg)
+	if err != nil {
+		return nil, err
+	}
+	return coverageMask(rings, bounds), nil
+}
+
+// rangeRings 将范围转换为多边形的环，坐标以像素中心为整数，圆形用足够多的边逼近
+func rangeRings(rg RangeValue) ([][][2]float64, error) {
+	switch rg.Type() {
+	case RangeRectType:
+		// 矩形范围包含 X0..X1-1 列、Y0..Y1-1 行的像素
+		x0, y0, x1, y1 := rg.(Range).Value()
+		fx0, fy0, fx1, fy1 := float64(x0)-0.5, float64(y0)-0.5, float64(x1)-0.5, float64(y1)-0.5
+		return [][][2]float64{{{fx0, fy0}, {fx1, fy0}, {fx1, fy1}, {fx0, fy1}}}, nil
+
+	case RangeCircleType:
+		rgObj := rg.(RangeCircle)
+		return [][][2]float64{circleRing(float64(rgObj.Cx), float64(rgObj.Cy), float64(rgObj.R))}, nil
+
+	case RangeTriangleType:
+		rgObj := rg.(RangeTriangle)
+		return [][][2]float64{{
+			{float64(rgObj.X0), float64(rgObj.Y0)}, {float64(rgObj.X1), float64(rgObj.Y1)}, {float64(rgObj.X2), float64(rgObj.Y2)},
+		}}, nil
+
+	case RangePolygonType:
+		rgObj := rg.(RangePolygon)
+		if len(rgObj.Points) < 3 {
+			return nil, fmt.Errorf("多边形至少需要3个顶点")
+		}
+		ring := make([][2]float64, len(rgObj.Points))
+		for i, p := range rgObj.Points {
+			ring[i] = [2]float64{float64(p.X), float64(p.Y)}
+		}
+		return [][][2]float64{ring}, nil
+
+	case RangePathType:
+		rings := rg.(*RangePath).fillRings()
+		if len(rings) == 0 {
+			return nil, fmt.Errorf("路径至少需要一个3个顶点以上的子路径")
+		}
+		return rings, nil
+
+	case RangeCompoundType:
+		return compoundRings(rg.(RangeCompound))
+	}
+	return nil, fmt.Errorf("不支持的范围类型 %s", rg.Type())
+}
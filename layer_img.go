@@ -107,6 +107,22 @@ func (imgLayer *ImgLayer) Scale(targetWidth, targetHeight int) error {
 	return nil
 }
 
+// Transform 对当前图像图层进行几何变换，坐标为图层图像的坐标
+// 扩展输出范围时图层在画布上的位置随之调整，使变换前后不动的点保持在画布的同一位置
+func (imgLayer *ImgLayer) Transform(t Transform, opts ...TransformOptions) error {
+	dst, offset, err := TransformImage(imgLayer.Resource, t, opts...)
+	if err != nil {
+		return err
+	}
+	offset = offset.Sub(imgLayer.Resource.Bounds().Min)
+	imgLayer.Resource = dst
+	imgLayer.X0 += offset.X
+	imgLayer.Y0 += offset.Y
+	imgLayer.X1 = imgLayer.X0 + dst.Bounds().Dx()
+	imgLayer.Y1 = imgLayer.Y0 + dst.Bounds().Dy()
+	return nil
+}
+
 // Save 将当前图像图层保存到文件
 func (imgLayer *ImgLayer) Save(filePath string) error {
 	outputFile, err := os.Create(filePath)
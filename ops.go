@@ -32,25 +32,14 @@ func OpsGray() func(ctx *CanvasContext) error {
 
 // BinaryImg 二值图
 // 参数: thresholdVal阈值，通过这个阈值来划分二值，默认为128
+// 等同于 BinaryImgWithOptions(src, BinaryOptions{Threshold: &thresholdVal})，需要自动或局部阈值时使用 BinaryImgWithOptions
 func BinaryImg(src image.Image, thresholdVal ...int) image.Image {
-	threshold := 128
+	var opts BinaryOptions
 	if len(thresholdVal) > 0 {
-		threshold = thresholdVal[0]
-	}
-	bounds := src.Bounds()
-	binaryImg := image.NewGray(bounds)
-	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			gray := color.GrayModel.Convert(src.At(x, y)).(color.Gray)
-			if gray.Y > uint8(threshold) {
-				// 大于阈值的像素设为白色
-				binaryImg.SetGray(x, y, color.Gray{Y: 255})
-			} else {
-				// 小于阈值的像素设为黑色
-				binaryImg.SetGray(x, y, color.Gray{Y: 0})
-			}
-		}
+		opts.Threshold = &thresholdVal[0]
 	}
+	// 固定阈值不会返回错误
+	binaryImg, _ := BinaryImgWithOptions(src, opts)
 	return binaryImg
 }
 
@@ -67,18 +56,9 @@ func OpsBinaryImg(thresholdVal ...int) func(ctx *CanvasContext) error {
 	}
 }
 
-// Transposition 图像转置
+// Transposition 图像转置，沿左上到右下的对角线翻转
 func Transposition(src image.Image) image.Image {
-	bounds := src.Bounds()
-	width := bounds.Dx()
-	height := bounds.Dy()
-	dst := image.NewRGBA(image.Rect(0, 0, height, width))
-	for y := 0; y < height; y++ {
-		for x := 0; x < width; x++ {
-			dst.Set(y, x, src.At(x, y))
-		}
-	}
-	return dst
+	return permutePixels(src, true, false, false)
 }
 
 // OpsTransposition 图像转置操作
@@ -89,32 +69,27 @@ func OpsTransposition() func(ctx *CanvasContext) error {
 	}
 }
 
+// Transverse 图像反转置，沿右上到左下的对角线翻转，相当于转置后再旋转180度
+func Transverse(src image.Image) image.Image {
+	return permutePixels(src, true, true, true)
+}
+
+// OpsTransverse 图像反转置操作
+func OpsTransverse() func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		ctx.Dst = Transverse(ctx.Dst).(*image.RGBA)
+		return nil
+	}
+}
+
 // MirrorHorizontal 图像水平镜像
 func MirrorHorizontal(src image.Image) image.Image {
-	bounds := src.Bounds()
-	width := bounds.Dx()
-	height := bounds.Dy()
-	dst := image.NewRGBA(bounds)
-	for y := 0; y < height; y++ {
-		for x := 0; x < width; x++ {
-			dst.Set(width-1-x, y, src.At(x, y))
-		}
-	}
-	return dst
+	return permutePixels(src, false, true, false)
 }
 
 // MirrorVertical 图像垂直镜像
 func MirrorVertical(src image.Image) image.Image {
-	bounds := src.Bounds()
-	width := bounds.Dx()
-	height := bounds.Dy()
-	dst := image.NewRGBA(bounds)
-	for y := 0; y < height; y++ {
-		for x := 0; x < width; x++ {
-			dst.Set(x, height-1-y, src.At(x, y))
-		}
-	}
-	return dst
+	return permutePixels(src, false, false, true)
 }
 
 // OpsMirrorHorizontal 图像水平镜像操作
@@ -404,10 +379,17 @@ func OpsGaussianBlur1D(sigma float64) func(ctx *CanvasContext) error {
 }
 
 // Thinning 图像细化
-// 针对文本图像进行细化处理
-func Thinning(src image.Image) image.Image {
-	bin := binaryImgForText(src)
-	height, width := len(bin), len(bin[0])
+// 针对文本图像进行细化处理，先二值化得到深色的文字笔画，再细化为单像素宽的骨架
+// 参数: opts 可选，文字二值化的参数，默认为固定阈值50(灰度小于等于50的像素为文字)，光照不均时可使用 ThresholdSauvola 等局部阈值
+func Thinning(src image.Image, opts ...BinaryOptions) image.Image {
+	bin := binaryImgForText(src, opts...)
+	height, width := len(bin), 0
+	if height > 0 {
+		width = len(bin[0])
+	}
+	if width == 0 {
+		return image.NewRGBA(image.Rect(0, 0, width, height))
+	}
 	// 复制原图避免修改输入
 	thinned := make([][]uint8, height)
 	for y := range bin {
@@ -505,9 +487,10 @@ func Thinning(src image.Image) image.Image {
 }
 
 // OpsThinning 图像细化
-func OpsThinning() func(ctx *CanvasContext) error {
+// 参数: opts 可选，文字二值化的参数
+func OpsThinning(opts ...BinaryOptions) func(ctx *CanvasContext) error {
 	return func(ctx *CanvasContext) error {
-		ctx.Dst = Thinning(ctx.Dst).(*image.RGBA)
+		ctx.Dst = Thinning(ctx.Dst, opts...).(*image.RGBA)
 		return nil
 	}
 }
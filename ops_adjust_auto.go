@@ -0,0 +1,266 @@
+package imgHelper
+
+import (
+	"image"
+	"math"
+)
+
+// WhiteBalanceParams 白平衡参数，记录各通道的增益，可用于审计或复用到同批次的其他图像
+type WhiteBalanceParams struct {
+	RGain float64
+	GGain float64
+	BGain float64
+}
+
+// LevelsParams 色阶参数，记录各通道的黑点、白点以及伽马值，可用于审计或复用到同批次的其他图像
+// 下标 0,1,2 分别对应 R,G,B 通道
+type LevelsParams struct {
+	Black [3]float64 // 黑点，小于等于该值映射为0
+	White [3]float64 // 白点，大于等于该值映射为255
+	Gamma float64    // 伽马校正，1为不校正
+}
+
+// ApplyWhiteBalance 按给定的白平衡参数调整图像
+func ApplyWhiteBalance(src image.Image, params WhiteBalanceParams) image.Image {
+	bounds := src.Bounds()
+	nrgba := imageToNRGBA(src)
+	gains := [3]float64{params.RGain, params.GGain, params.BGain}
+	var luts [3][256]uint8
+	for c := 0; c < 3; c++ {
+		for v := 0; v < 256; v++ {
+			luts[c][v] = uint8(clamp(math.Round(float64(v)*gains[c]), 0, 255))
+		}
+	}
+	for i := 0; i+3 < len(nrgba.Pix); i += 4 {
+		nrgba.Pix[i] = luts[0][nrgba.Pix[i]]
+		nrgba.Pix[i+1] = luts[1][nrgba.Pix[i+1]]
+		nrgba.Pix[i+2] = luts[2][nrgba.Pix[i+2]]
+	}
+	return nrgbaToRGBA(nrgba, bounds)
+}
+
+// OpsApplyWhiteBalance 按给定的白平衡参数调整图像操作
+func OpsApplyWhiteBalance(params WhiteBalanceParams) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		ctx.Dst = ApplyWhiteBalance(ctx.Dst, params).(*image.RGBA)
+		return nil
+	}
+}
+
+// AutoWhiteBalanceGrayWorld 灰色世界法自动白平衡
+// 假设整幅图像的平均颜色为灰色，以绿色通道为基准计算红蓝通道的增益
+// 返回校正后的图像和使用的白平衡参数
+func AutoWhiteBalanceGrayWorld(src image.Image) (image.Image, WhiteBalanceParams) {
+	hist := opaqueChannelHistogram(imageToNRGBA(src))
+	params := WhiteBalanceParams{RGain: 1, GGain: 1, BGain: 1}
+	var mean [3]float64
+	for c := 0; c < 3; c++ {
+		mean[c] = histMean(hist[c])
+	}
+	if mean[0] > 0 && mean[1] > 0 && mean[2] > 0 {
+		params.RGain = mean[1] / mean[0]
+		params.BGain = mean[1] / mean[2]
+	}
+	return ApplyWhiteBalance(src, params), params
+}
+
+// OpsAutoWhiteBalanceGrayWorld 灰色世界法自动白平衡操作
+func OpsAutoWhiteBalanceGrayWorld() func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		dst, _ := AutoWhiteBalanceGrayWorld(ctx.Dst)
+		ctx.Dst = dst.(*image.RGBA)
+		return nil
+	}
+}
+
+// AutoWhiteBalanceWhitePatch 白点法(完美反射法)自动白平衡
+// 假设图像中最亮的区域为白色，将各通道的高亮分位值拉伸到255
+// 参数: percentile 取高亮部分的百分比，默认为1，即取各通道最亮的1%像素作为白点，用于规避噪点和高光溢出
+// 返回校正后的图像和使用的白平衡参数
+func AutoWhiteBalanceWhitePatch(src image.Image, percentile ...float64) (image.Image, WhiteBalanceParams) {
+	p := 1.0
+	if len(percentile) > 0 {
+		p = clamp(percentile[0], 0, 50)
+	}
+	hist := opaqueChannelHistogram(imageToNRGBA(src))
+	params := WhiteBalanceParams{RGain: 1, GGain: 1, BGain: 1}
+	gains := [3]*float64{&params.RGain, &params.GGain, &params.BGain}
+	for c := 0; c < 3; c++ {
+		white := histPercentileHigh(hist[c], p)
+		if white > 0 {
+			*gains[c] = 255 / float64(white)
+		}
+	}
+	return ApplyWhiteBalance(src, params), params
+}
+
+// OpsAutoWhiteBalanceWhitePatch 白点法自动白平衡操作
+// 参数: percentile 取高亮部分的百分比，默认为1
+func OpsAutoWhiteBalanceWhitePatch(percentile ...float64) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		dst, _ := AutoWhiteBalanceWhitePatch(ctx.Dst, percentile...)
+		ctx.Dst = dst.(*image.RGBA)
+		return nil
+	}
+}
+
+// ApplyLevels 按给定的色阶参数调整图像
+func ApplyLevels(src image.Image, params LevelsParams) image.Image {
+	bounds := src.Bounds()
+	nrgba := imageToNRGBA(src)
+	gamma := params.Gamma
+	if gamma <= 0 {
+		gamma = 1
+	}
+	var luts [3][256]uint8
+	for c := 0; c < 3; c++ {
+		black, white := params.Black[c], params.White[c]
+		for v := 0; v < 256; v++ {
+			var val float64
+			switch {
+			case white <= black:
+				val = float64(v)
+			case float64(v) <= black:
+				val = 0
+			case float64(v) >= white:
+				val = 255
+			default:
+				val = (float64(v) - black) / (white - black) * 255
+			}
+			if gamma != 1 {
+				val = 255 * math.Pow(val/255, 1/gamma)
+			}
+			luts[c][v] = uint8(clamp(math.Round(val), 0, 255))
+		}
+	}
+	for i := 0; i+3 < len(nrgba.Pix); i += 4 {
+		nrgba.Pix[i] = luts[0][nrgba.Pix[i]]
+		nrgba.Pix[i+1] = luts[1][nrgba.Pix[i+1]]
+		nrgba.Pix[i+2] = luts[2][nrgba.Pix[i+2]]
+	}
+	return nrgbaToRGBA(nrgba, bounds)
+}
+
+// OpsApplyLevels 按给定的色阶参数调整图像操作
+func OpsApplyLevels(params LevelsParams) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		ctx.Dst = ApplyLevels(ctx.Dst, params).(*image.RGBA)
+		return nil
+	}
+}
+
+// AutoLevels 自动色阶
+// 每个通道单独裁剪暗部和高光各 clipPercent% 的像素，再将剩余范围拉伸到 0~255，可同时校正偏色
+// 参数: clipPercent 暗部和高光各裁剪的百分比，常用 0.1~1
+// 返回校正后的图像和使用的色阶参数
+func AutoLevels(src image.Image, clipPercent float64) (image.Image, LevelsParams) {
+	clipPercent = clamp(clipPercent, 0, 50)
+	hist := opaqueChannelHistogram(imageToNRGBA(src))
+	params := LevelsParams{Gamma: 1}
+	for c := 0; c < 3; c++ {
+		params.Black[c] = float64(histPercentileLow(hist[c], clipPercent))
+		params.White[c] = float64(histPercentileHigh(hist[c], clipPercent))
+	}
+	return ApplyLevels(src, params), params
+}
+
+// OpsAutoLevels 自动色阶操作
+// 参数: clipPercent 暗部和高光各裁剪的百分比
+func OpsAutoLevels(clipPercent float64) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		dst, _ := AutoLevels(ctx.Dst, clipPercent)
+		ctx.Dst = dst.(*image.RGBA)
+		return nil
+	}
+}
+
+// AutoContrast 自动对比度
+// 与自动色阶不同，三个通道使用同一组由亮度直方图得出的黑点和白点，只拉伸对比度不改变色调
+// 参数: clipPercent 暗部和高光各裁剪的百分比，常用 0.1~1
+// 返回校正后的图像和使用的色阶参数
+func AutoContrast(src image.Image, clipPercent float64) (image.Image, LevelsParams) {
+	clipPercent = clamp(clipPercent, 0, 50)
+	hist := opaqueChannelHistogram(imageToNRGBA(src))
+	black := float64(histPercentileLow(hist[3], clipPercent))
+	white := float64(histPercentileHigh(hist[3], clipPercent))
+	params := LevelsParams{
+		Black: [3]float64{black, black, black},
+		White: [3]float64{white, white, white},
+		Gamma: 1,
+	}
+	return ApplyLevels(src, params), params
+}
+
+// OpsAutoContrast 自动对比度操作
+// 参数: clipPercent 暗部和高光各裁剪的百分比
+func OpsAutoContrast(clipPercent float64) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		dst, _ := AutoContrast(ctx.Dst, clipPercent)
+		ctx.Dst = dst.(*image.RGBA)
+		return nil
+	}
+}
+
+// opaqueChannelHistogram 统计非透明像素的 R,G,B 以及亮度直方图（下标 0,1,2,3）
+// 完全透明的像素不参与统计，避免抠图后的透明背景影响自动校正
+func opaqueChannelHistogram(src *image.NRGBA) [4][256]int {
+	var hist [4][256]int
+	for i := 0; i+3 < len(src.Pix); i += 4 {
+		if src.Pix[i+3] == 0 {
+			continue
+		}
+		r, g, b := src.Pix[i], src.Pix[i+1], src.Pix[i+2]
+		hist[0][r]++
+		hist[1][g]++
+		hist[2][b]++
+		hist[3][luminance(r, g, b)]++
+	}
+	return hist
+}
+
+// histMean 直方图的均值
+func histMean(hist [256]int) float64 {
+	sum, total := 0, 0
+	for v, n := range hist {
+		sum += v * n
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(sum) / float64(total)
+}
+
+// histPercentileLow 从暗部开始累计，返回累计数量超过 percent% 时的值
+func histPercentileLow(hist [256]int, percent float64) int {
+	total := 0
+	for _, n := range hist {
+		total += n
+	}
+	limit := float64(total) * percent / 100
+	sum := 0
+	for v := 0; v < 256; v++ {
+		sum += hist[v]
+		if float64(sum) > limit {
+			return v
+		}
+	}
+	return 0
+}
+
+// histPercentileHigh 从高光开始累计，返回累计数量超过 percent% 时的值
+func histPercentileHigh(hist [256]int, percent float64) int {
+	total := 0
+	for _, n := range hist {
+		total += n
+	}
+	limit := float64(total) * percent / 100
+	sum := 0
+	for v := 255; v >= 0; v-- {
+		sum += hist[v]
+		if float64(sum) > limit {
+			return v
+		}
+	}
+	return 255
+}
@@ -0,0 +1,35 @@
+package imgHelper
+
+import "testing"
+
+func TestHistPercentile(t *testing.T) {
+	// 10,20,...,100 各100个像素
+	var hist [256]int
+	for v := 10; v <= 100; v += 10 {
+		hist[v] = 100
+	}
+	cases := []struct {
+		name    string
+		hist    [256]int
+		percent float64
+		low     int
+		high    int
+	}{
+		{"0%", hist, 0, 10, 100},
+		{"恰好等于一个 bin", hist, 10, 20, 90},
+		{"5%", hist, 5, 10, 100},
+		{"50%", hist, 50, 60, 50},
+		{"100%", hist, 100, 0, 255},
+		{"空直方图", [256]int{}, 1, 0, 255},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := histPercentileLow(c.hist, c.percent); got != c.low {
+				t.Errorf("histPercentileLow = %d, want %d", got, c.low)
+			}
+			if got := histPercentileHigh(c.hist, c.percent); got != c.high {
+				t.Errorf("histPercentileHigh = %d, want %d", got, c.high)
+			}
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package imgHelper
+
+import (
+	"image"
+	"math"
+)
+
+// EdgeMode 边缘处理方式，卷积或采样的坐标超出图像范围时如何取值
+type EdgeMode int
+
+const (
+	EdgeClamp       EdgeMode = iota // 取最近的边缘像素（默认）
+	EdgeMirror                      // 以边缘像素为轴镜像取值
+	EdgeWrap                        // 从图像另一侧循环取值
+	EdgeTransparent                 // 超出部分视为透明像素
+)
+
+// edgeIndex 按边缘处理方式将下标映射到 [0,n) 范围内，ok 为 false 表示该位置视为透明
+func edgeIndex(i, n int, mode EdgeMode) (int, bool) {
+	if i >= 0 && i < n {
+		return i, true
+	}
+	switch mode {
+	case EdgeMirror:
+		if n == 1 {
+			return 0, true
+		}
+		period := 2 * (n - 1)
+		i %= period
+		if i < 0 {
+			i += period
+		}
+		if i >= n {
+			i = period - i
+		}
+		return i, true
+	case EdgeWrap:
+		i %= n
+		if i < 0 {
+			i += n
+		}
+		return i, true
+	case EdgeTransparent:
+		return 0, false
+	default:
+		return clamp(i, 0, n-1), true
+	}
+}
+
+// getEdgeMode 取可选参数中的边缘处理方式，默认为 EdgeClamp
+func getEdgeMode(mode []EdgeMode) EdgeMode {
+	if len(mode) > 0 {
+		return mode[0]
+	}
+	return EdgeClamp
+}
+
+// GaussianBlur 二维高斯模糊
+// 使用可分离的高斯核先水平后垂直卷积，在预乘 Alpha 空间中计算，透明图像边缘不会出现暗色光晕
+// 参数:
+// - sigma 高斯核的标准差，越大越模糊
+// - mode 可选，边缘处理方式，默认为 EdgeClamp
+func GaussianBlur(src image.Image, sigma float64, mode ...EdgeMode) image.Image {
+	rgba := imageToRGBA(src)
+	if sigma <= 0 {
+		return rgba
+	}
+	edge := getEdgeMode(mode)
+	kernel := generateGaussianKernel(sigma)
+	w, h := rgba.Bounds().Dx(), rgba.Bounds().Dy()
+	plane := rgbaToPlane(rgba)
+	tmp := make([]float32, len(plane))
+	convolvePass(plane, tmp, w, h, kernel, true, edge)
+	convolvePass(tmp, plane, w, h, kernel, false, edge)
+	planeToRGBA(plane, rgba)
+	return rgba
+}
+
+// OpsGaussianBlur 二维高斯模糊操作
+func OpsGaussianBlur(sigma float64, mode ...EdgeMode) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		ctx.Dst = GaussianBlur(ctx.Dst, sigma, mode...).(*image.RGBA)
+		return nil
+	}
+}
+
+// BoxBlur 方框模糊（均值模糊）
+// 使用滑动窗口累加，耗时与半径无关
+// 参数:
+// - radius 模糊半径，窗口大小为 2*radius+1
+// - mode 可选，边缘处理方式，默认为 EdgeClamp
+func BoxBlur(src image.Image, radius int, mode ...EdgeMode) image.Image {
+	rgba := imageToRGBA(src)
+	if radius <= 0 {
+		return rgba
+	}
+	edge := getEdgeMode(mode)
+	w, h := rgba.Bounds().Dx(), rgba.Bounds().Dy()
+	plane := rgbaToPlane(rgba)
+	tmp := make([]float32, len(plane))
+	boxBlurPass(plane, tmp, w, h, radius, true, edge)
+	boxBlurPass(tmp, plane, w, h, radius, false, edge)
+	planeToRGBA(plane, rgba)
+	return rgba
+}
+
+// OpsBoxBlur 方框模糊操作
+func OpsBoxBlur(radius int, mode ...EdgeMode) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		ctx.Dst = BoxBlur(ctx.Dst, radius, mode...).(*image.RGBA)
+		return nil
+	}
+}
+
+// FastGaussianBlur 快速近似高斯模糊
+// 用三次方框模糊叠加近似高斯模糊，耗时与 sigma 无关，适合大半径模糊
+// 参数:
+// - sigma 近似高斯核的标准差
+// - mode 可选，边缘处理方式，默认为 EdgeClamp
+func FastGaussianBlur(src image.Image, sigma float64, mode ...EdgeMode) image.Image {
+	rgba := imageToRGBA(src)
+	if sigma <= 0 {
+		return rgba
+	}
+	edge := getEdgeMode(mode)
+	w, h := rgba.Bounds().Dx(), rgba.Bounds().Dy()
+	plane := rgbaToPlane(rgba)
+	tmp := make([]float32, len(plane))
+	for _, size := range boxSizesForGauss(sigma, 3) {
+		radius := (size - 1) / 2
+		if radius <= 0 {
+			continue
+		}
+		boxBlurPass(plane, tmp, w, h, radius, true, edge)
+		boxBlurPass(tmp, plane, w, h, radius, false, edge)
+	}
+	planeToRGBA(plane, rgba)
+	return rgba
+}
+
+// OpsFastGaussianBlur 快速近似高斯模糊操作
+func OpsFastGaussianBlur(sigma float64, mode ...EdgeMode) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		ctx.Dst = FastGaussianBlur(ctx.Dst, sigma, mode...).(*image.RGBA)
+		return nil
+	}
+}
+
+// boxSizesForGauss 计算 n 次方框模糊近似标准差为 sigma 的高斯模糊时，每次方框的大小（奇数）
+func boxSizesForGauss(sigma float64, n int) []int {
+	wIdeal := math.Sqrt(12*sigma*sigma/float64(n) + 1)
+	wl := int(math.Floor(wIdeal))
+	if wl%2 == 0 {
+		wl--
+	}
+	wu := wl + 2
+	mIdeal := (12*sigma*sigma - float64(n*wl*wl) - float64(4*n*wl) - float64(3*n)) / float64(-4*wl-4)
+	m := int(math.Round(mIdeal))
+	sizes := make([]int, n)
+	for i := 0; i < n; i++ {
+		if i < m {
+			sizes[i] = wl
+		} else {
+			sizes[i] = wu
+		}
+	}
+	return sizes
+}
+
+// rgbaToPlane 将 RGBA 图像的像素（预乘 Alpha）转换为连续的浮点数组，每个像素4个通道
+func rgbaToPlane(src *image.RGBA) []float32 {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	plane := make([]float32, w*h*4)
+	for y := 0; y < h; y++ {
+		row := src.Pix[y*src.Stride : y*src.Stride+w*4]
+		for i, v := range row {
+			plane[y*w*4+i] = float32(v)
+		}
+	}
+	return plane
+}
+
+// planeToRGBA 将浮点数组写回 RGBA 图像，颜色通道不超过 Alpha 以保证预乘 Alpha 合法
+func planeToRGBA(plane []float32, dst *image.RGBA) {
+	w, h := dst.Bounds().Dx(), dst.Bounds().Dy()
+	for y := 0; y < h; y++ {
+		row := dst.Pix[y*dst.Stride : y*dst.Stride+w*4]
+		for x := 0; x < w; x++ {
+			i := (y*w + x) * 4
+			a := uint8(clamp(math.Round(float64(plane[i+3])), 0, 255))
+			row[x*4+3] = a
+			for c := 0; c < 3; c++ {
+				v := uint8(clamp(math.Round(float64(plane[i+c])), 0, 255))
+				row[x*4+c] = min(v, a)
+			}
+		}
+	}
+}
+
+// loadLine 将一行（或一列）像素按边缘处理方式复制到带 pad 填充的缓冲区中
+func loadLine(in []float32, buf []float32, w, line, length, pad int, horizontal bool, edge EdgeMode) {
+	for p := -pad; p < length+pad; p++ {
+		o := (p + pad) * 4
+		idx, ok := edgeIndex(p, length, edge)
+		if !ok {
+			buf[o], buf[o+1], buf[o+2], buf[o+3] = 0, 0, 0, 0
+			continue
+		}
+		var src int
+		if horizontal {
+			src = (line*w + idx) * 4
+		} else {
+			src = (idx*w + line) * 4
+		}
+		copy(buf[o:o+4], in[src:src+4])
+	}
+}
+
+// lineGeometry 返回按行或按列处理时的行数、每行长度以及输出偏移量计算函数
+func lineGeometry(w, h int, horizontal bool) (int, int, func(line, pos int) int) {
+	if horizontal {
+		return h, w, func(line, pos int) int { return (line*w + pos) * 4 }
+	}
+	return w, h, func(line, pos int) int { return (pos*w + line) * 4 }
+}
+
+// convolvePass 一维卷积，horizontal 为 true 时按行卷积，否则按列卷积
+func convolvePass(in, out []float32, w, h int, kernel []float64, horizontal bool, edge EdgeMode) {
+	half := len(kernel) / 2
+	lines, length, offset := lineGeometry(w, h, horizontal)
+	buf := make([]float32, (length+2*half)*4)
+	for line := 0; line < lines; line++ {
+		loadLine(in, buf, w, line, length, half, horizontal, edge)
+		for pos := 0; pos < length; pos++ {
+			var s0, s1, s2, s3 float64
+			window := buf[pos*4 : (pos+len(kernel))*4]
+			for k, kv := range kernel {
+				s0 += float64(window[k*4]) * kv
+				s1 += float64(window[k*4+1]) * kv
+				s2 += float64(window[k*4+2]) * kv
+				s3 += float64(window[k*4+3]) * kv
+			}
+			o := offset(line, pos)
+			out[o], out[o+1], out[o+2], out[o+3] = float32(s0), float32(s1), float32(s2), float32(s3)
+		}
+	}
+}
+
+// boxBlurPass 一维方框模糊，使用滑动窗口累加，horizontal 为 true 时按行处理，否则按列处理
+func boxBlurPass(in, out []float32, w, h, radius int, horizontal bool, edge EdgeMode) {
+	lines, length, offset := lineGeometry(w, h, horizontal)
+	buf := make([]float32, (length+2*radius+1)*4)
+	scale := 1 / float64(2*radius+1)
+	for line := 0; line < lines; line++ {
+		loadLine(in, buf[:(length+2*radius)*4], w, line, length, radius, horizontal, edge)
+		var sum [4]float64
+		for k := 0; k < 2*radius+1; k++ {
+			for c := 0; c < 4; c++ {
+				sum[c] += float64(buf[k*4+c])
+			}
+		}
+		for pos := 0; pos < length; pos++ {
+			o := offset(line, pos)
+			for c := 0; c < 4; c++ {
+				out[o+c] = float32(sum[c] * scale)
+				// 窗口右移：加入右侧新像素，移除左侧旧像素
+				sum[c] += float64(buf[(pos+2*radius+1)*4+c]) - float64(buf[pos*4+c])
+			}
+		}
+	}
+}
+
+// blurPlane 对单通道浮点平面做可分离高斯模糊，边缘取最近像素
+func blurPlane(plane []float64, w, h int, sigma float64) []float64 {
+	out := make([]float64, len(plane))
+	if sigma <= 0 {
+		copy(out, plane)
+		return out
+	}
+	kernel := generateGaussianKernel(sigma)
+	tmp := make([]float64, len(plane))
+	convolvePlanePass(plane, tmp, w, h, kernel, true)
+	convolvePlanePass(tmp, out, w, h, kernel, false)
+	return out
+}
+
+// convolvePlanePass 单通道平面沿一个方向的一维卷积，边缘取最近像素
+func convolvePlanePass(in, out []float64, w, h int, kernel []float64, horizontal bool) {
+	half := len(kernel) / 2
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			sum := 0.0
+			for k := -half; k <= half; k++ {
+				if horizontal {
+					sum += in[y*w+clamp(x+k, 0, w-1)] * kernel[k+half]
+				} else {
+					sum += in[clamp(y+k, 0, h-1)*w+x] * kernel[k+half]
+				}
+			}
+			out[y*w+x] = sum
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package imgHelper
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// uniformRGBA 纯色图像
+func uniformRGBA(w, h int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+	return img
+}
+
+func TestEdgeIndex(t *testing.T) {
+	cases := []struct {
+		name   string
+		i, n   int
+		mode   EdgeMode
+		want   int
+		wantOk bool
+	}{
+		{"范围内", 3, 5, EdgeMirror, 3, true},
+		{"取边缘左", -2, 5, EdgeClamp, 0, true},
+		{"取边缘右", 7, 5, EdgeClamp, 4, true},
+		{"镜像左", -1, 5, EdgeMirror, 1, true},
+		{"镜像右", 5, 5, EdgeMirror, 3, true},
+		{"镜像超过一个周期", 9, 5, EdgeMirror, 1, true},
+		{"镜像单像素", -3, 1, EdgeMirror, 0, true},
+		{"循环左", -1, 5, EdgeWrap, 4, true},
+		{"循环右", 5, 5, EdgeWrap, 0, true},
+		{"透明", -1, 5, EdgeTransparent, 0, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, ok := edgeIndex(c.i, c.n, c.mode)
+			if got != c.want || ok != c.wantOk {
+				t.Errorf("edgeIndex(%d, %d) = (%d, %v), want (%d, %v)", c.i, c.n, got, ok, c.want, c.wantOk)
+			}
+		})
+	}
+}
+
+func TestBoxSizesForGauss(t *testing.T) {
+	for _, sigma := range []float64{2, 3.5, 5, 10, 25} {
+		sizes := boxSizesForGauss(sigma, 3)
+		if len(sizes) != 3 {
+			t.Fatalf("sigma %g: %d 个方框, want 3", sigma, len(sizes))
+		}
+		// 方框模糊的方差为 (w*w-1)/12，三次叠加的方差应接近 sigma^2，误差不超过一次方框尺寸取整的量
+		variance := 0.0
+		for _, w := range sizes {
+			if w%2 != 1 {
+				t.Errorf("sigma %g: 方框大小 %d 不是奇数", sigma, w)
+			}
+			variance += float64(w*w-1) / 12
+		}
+		step := float64(sizes[2]*sizes[2]-sizes[0]*sizes[0]) / 12
+		if math.Abs(variance-sigma*sigma) > math.Max(step, 1) {
+			t.Errorf("sigma %g: 方差 %g, want %g", sigma, variance, sigma*sigma)
+		}
+	}
+}
+
+func TestBlurKeepsUniformImage(t *testing.T) {
+	c := color.RGBA{R: 200, G: 100, B: 50, A: 255}
+	blurs := []struct {
+		name string
+		blur func(img image.Image, mode EdgeMode) image.Image
+	}{
+		{"GaussianBlur", func(img image.Image, mode EdgeMode) image.Image { return GaussianBlur(img, 2, mode) }},
+		{"BoxBlur", func(img image.Image, mode EdgeMode) image.Image { return BoxBlur(img, 3, mode) }},
+		{"FastGaussianBlur", func(img image.Image, mode EdgeMode) image.Image { return FastGaussianBlur(img, 4, mode) }},
+	}
+	for _, b := range blurs {
+		for _, mode := range []EdgeMode{EdgeClamp, EdgeMirror, EdgeWrap} {
+			dst := b.blur(uniformRGBA(9, 7, c), mode).(*image.RGBA)
+			if dst.Bounds() != image.Rect(0, 0, 9, 7) {
+				t.Fatalf("%s: Bounds = %v", b.name, dst.Bounds())
+			}
+			for i := 0; i < len(dst.Pix); i += 4 {
+				got := color.RGBA{R: dst.Pix[i], G: dst.Pix[i+1], B: dst.Pix[i+2], A: dst.Pix[i+3]}
+				if absDiff(got.R, c.R) > 1 || absDiff(got.G, c.G) > 1 || absDiff(got.B, c.B) > 1 || got.A != 255 {
+					t.Fatalf("%s mode %d: 像素 %d = %v, want %v", b.name, mode, i/4, got, c)
+				}
+			}
+		}
+		// 透明边缘：边角像素混入透明像素，Alpha 降低
+		dst := b.blur(uniformRGBA(9, 7, c), EdgeTransparent).(*image.RGBA)
+		if a := dst.RGBAAt(0, 0).A; a >= 255 {
+			t.Errorf("%s EdgeTransparent: 角点 Alpha = %d, want < 255", b.name, a)
+		}
+	}
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func TestBoxBlurImpulse(t *testing.T) {
+	src := uniformRGBA(7, 7, color.RGBA{A: 255})
+	src.SetRGBA(3, 3, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	dst := BoxBlur(src, 1).(*image.RGBA)
+	for y := 0; y < 7; y++ {
+		for x := 0; x < 7; x++ {
+			want := uint8(0)
+			if x >= 2 && x <= 4 && y >= 2 && y <= 4 {
+				want = 28 // 255/9
+			}
+			if got := dst.RGBAAt(x, y); absDiff(got.R, want) > 1 || got.A != 255 {
+				t.Errorf("(%d, %d) = %v, want R=%d", x, y, got, want)
+			}
+		}
+	}
+}
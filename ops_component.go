@@ -0,0 +1,287 @@
+package imgHelper
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math/rand"
+)
+
+// Component 连通区域的统计信息
+type Component struct {
+	Label     int        // 标签，从1开始
+	Area      int        // 面积(像素数)
+	Bounds    Range      // 外接矩形，X1,Y1 不包含在内
+	CentroidX float64    // 质心
+	CentroidY float64    // 质心
+	Perimeter int        // 周长，区域像素与区域外像素或图像边界相邻的边的数量
+	MeanColor color.RGBA // 区域内像素的平均颜色
+}
+
+// AspectRatio 外接矩形的宽高比
+func (c Component) AspectRatio() float64 {
+	return float64(c.Bounds.X1-c.Bounds.X0) / float64(c.Bounds.Y1-c.Bounds.Y0)
+}
+
+// ComponentLabels 连通区域标记结果
+type ComponentLabels struct {
+	Width      int
+	Height     int
+	Labels     []int       // 每个像素的标签，按行存储，0 为背景，i 对应 Components[i-1]
+	Components []Component // 各连通区域的统计信息
+	bounds     image.Rectangle
+}
+
+// Label 返回 (x,y) 处的标签，坐标与原图一致，超出范围返回0
+func (l *ComponentLabels) Label(x, y int) int {
+	x, y = x-l.bounds.Min.X, y-l.bounds.Min.Y
+	if x < 0 || x >= l.Width || y < 0 || y >= l.Height {
+		return 0
+	}
+	return l.Labels[y*l.Width+x]
+}
+
+// Mask 返回指定标签的掩码图，属于这些连通区域的像素为255，未指定标签时返回全部连通区域
+func (l *ComponentLabels) Mask(labels ...int) *image.Gray {
+	keep := make(map[int]bool, len(labels))
+	for _, label := range labels {
+		keep[label] = true
+	}
+	dst := image.NewGray(image.Rect(l.bounds.Min.X, l.bounds.Min.Y, l.bounds.Min.X+l.Width, l.bounds.Min.Y+l.Height))
+	for y := 0; y < l.Height; y++ {
+		for x := 0; x < l.Width; x++ {
+			label := l.Labels[y*l.Width+x]
+			if label > 0 && (len(labels) == 0 || keep[label]) {
+				dst.Pix[y*dst.Stride+x] = 255
+			}
+		}
+	}
+	return dst
+}
+
+// Range 返回指定标签的掩码范围，可直接用于 OpsCrop、OpsMosaic
+func (l *ComponentLabels) Range(labels ...int) RangeMask {
+	return RangeMask{Mask: l.Mask(labels...)}
+}
+
+// ConnectedComponents 连通区域标记
+// 输入图像以阈值128二值化，亮色为前景(与 BinaryImg 的输出一致)，对前景像素标记连通区域并统计信息
+// 参数:
+// - src 二值图
+// - connectivity 连通方式，4 或 8
+// - colorSrc 可选，用于计算平均颜色的原图，尺寸需与 src 一致，默认使用 src
+func ConnectedComponents(src image.Image, connectivity int, colorSrc ...image.Image) (*ComponentLabels, error) {
+	if connectivity != 4 && connectivity != 8 {
+		return nil, fmt.Errorf("connectivity 必须为4或8, 当前为 %d", connectivity)
+	}
+	nrgba := imageToNRGBA(src)
+	colorImg := nrgba
+	if len(colorSrc) > 0 && colorSrc[0] != nil {
+		if colorSrc[0].Bounds().Size() != src.Bounds().Size() {
+			return nil, fmt.Errorf("colorSrc 尺寸 %v 与 src 尺寸 %v 不一致", colorSrc[0].Bounds().Size(), src.Bounds().Size())
+		}
+		colorImg = imageToNRGBA(colorSrc[0])
+	}
+	w, h := nrgba.Bounds().Dx(), nrgba.Bounds().Dy()
+	foreground := make([]bool, w*h)
+	for i := range foreground {
+		o := i * 4
+		foreground[i] = nrgba.Pix[o+3] > 0 && luminance(nrgba.Pix[o], nrgba.Pix[o+1], nrgba.Pix[o+2]) >= 128
+	}
+
+	offsets := neighbors
+	if connectivity == 4 {
+		offsets = neighbors4
+	}
+	labels := make([]int, w*h)
+	count := 0
+	stack := make([]int, 0, 1024)
+	for start := range foreground {
+		if !foreground[start] || labels[start] != 0 {
+			continue
+		}
+		count++
+		labels[start] = count
+		stack = append(stack[:0], start)
+		for len(stack) > 0 {
+			cur := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			cx, cy := cur%w, cur/w
+			for _, n := range offsets {
+				nx, ny := cx+n.X, cy+n.Y
+				if nx < 0 || nx >= w || ny < 0 || ny >= h {
+					continue
+				}
+				ni := ny*w + nx
+				if foreground[ni] && labels[ni] == 0 {
+					labels[ni] = count
+					stack = append(stack, ni)
+				}
+			}
+		}
+	}
+	return newComponentLabels(labels, count, w, h, src.Bounds(), colorImg), nil
+}
+
+// newComponentLabels 根据标签图统计各区域的信息，labels 中 0 为背景，1~count 为区域标签
+func newComponentLabels(labels []int, count, w, h int, bounds image.Rectangle, colorImg *image.NRGBA) *ComponentLabels {
+	result := &ComponentLabels{Width: w, Height: h, Labels: labels, Components: make([]Component, count), bounds: bounds}
+	sumX := make([]float64, count)
+	sumY := make([]float64, count)
+	sumColor := make([][4]int, count)
+	for i := range result.Components {
+		result.Components[i] = Component{Label: i + 1, Bounds: Range{X0: w, Y0: h, X1: -1, Y1: -1}}
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			label := labels[y*w+x]
+			if label == 0 {
+				continue
+			}
+			comp := &result.Components[label-1]
+			comp.Area++
+			sumX[label-1] += float64(x)
+			sumY[label-1] += float64(y)
+			comp.Bounds.X0, comp.Bounds.Y0 = minValue(comp.Bounds.X0, x), minValue(comp.Bounds.Y0, y)
+			comp.Bounds.X1, comp.Bounds.Y1 = maxValue(comp.Bounds.X1, x), maxValue(comp.Bounds.Y1, y)
+			o := y*colorImg.Stride + x*4
+			for c := 0; c < 4; c++ {
+				sumColor[label-1][c] += int(colorImg.Pix[o+c])
+			}
+			// 周长按4邻域统计与区域外像素相邻的边
+			for _, n := range neighbors4 {
+				nx, ny := x+n.X, y+n.Y
+				if nx < 0 || nx >= w || ny < 0 || ny >= h || labels[ny*w+nx] != label {
+					comp.Perimeter++
+				}
+			}
+		}
+	}
+	minX, minY := bounds.Min.X, bounds.Min.Y
+	for i := range result.Components {
+		comp := &result.Components[i]
+		if comp.Area == 0 {
+			comp.Bounds = Range{X0: minX, Y0: minY, X1: minX, Y1: minY}
+			continue
+		}
+		comp.CentroidX = sumX[i]/float64(comp.Area) + float64(minX)
+		comp.CentroidY = sumY[i]/float64(comp.Area) + float64(minY)
+		comp.Bounds = Range{X0: comp.Bounds.X0 + minX, Y0: comp.Bounds.Y0 + minY, X1: comp.Bounds.X1 + minX + 1, Y1: comp.Bounds.Y1 + minY + 1}
+		comp.MeanColor = color.RGBA{
+			R: uint8(sumColor[i][0] / comp.Area),
+			G: uint8(sumColor[i][1] / comp.Area),
+			B: uint8(sumColor[i][2] / comp.Area),
+			A: uint8(sumColor[i][3] / comp.Area),
+		}
+	}
+	return result
+}
+
+// ComponentFilter 连通区域过滤条件，零值字段表示不限制
+type ComponentFilter struct {
+	MinArea   int     // 最小面积
+	MaxArea   int     // 最大面积
+	MinAspect float64 // 外接矩形的最小宽高比
+	MaxAspect float64 // 外接矩形的最大宽高比
+}
+
+// match 判断连通区域是否满足过滤条件
+func (f ComponentFilter) match(c Component) bool {
+	if f.MinArea > 0 && c.Area < f.MinArea {
+		return false
+	}
+	if f.MaxArea > 0 && c.Area > f.MaxArea {
+		return false
+	}
+	if f.MinAspect > 0 && c.AspectRatio() < f.MinAspect {
+		return false
+	}
+	if f.MaxAspect > 0 && c.AspectRatio() > f.MaxAspect {
+		return false
+	}
+	return true
+}
+
+// FilterComponents 按面积或宽高比过滤连通区域，返回新的标记结果，保留的区域按原顺序重新编号
+func FilterComponents(labels *ComponentLabels, filter ComponentFilter) *ComponentLabels {
+	result := &ComponentLabels{Width: labels.Width, Height: labels.Height, Labels: make([]int, len(labels.Labels)), bounds: labels.bounds}
+	// 旧标签到新标签的映射，0 表示被过滤掉
+	mapping := make([]int, len(labels.Components)+1)
+	for _, c := range labels.Components {
+		if !filter.match(c) {
+			continue
+		}
+		old := c.Label
+		c.Label = len(result.Components) + 1
+		mapping[old] = c.Label
+		result.Components = append(result.Components, c)
+	}
+	for i, label := range labels.Labels {
+		result.Labels[i] = mapping[label]
+	}
+	return result
+}
+
+// RemoveSmallComponents 去除二值图中面积小于 minArea 的前景斑点，被去除的像素置为黑色
+// 参数:
+// - minArea 最小面积，小于该面积的连通区域会被去除
+// - connectivity 连通方式，4 或 8
+func RemoveSmallComponents(src image.Image, minArea, connectivity int) (image.Image, error) {
+	labels, err := ConnectedComponents(src, connectivity)
+	if err != nil {
+		return nil, err
+	}
+	dst := imageToRGBA(src)
+	minX, minY := dst.Bounds().Min.X, dst.Bounds().Min.Y
+	for i, label := range labels.Labels {
+		if label == 0 || labels.Components[label-1].Area >= minArea {
+			continue
+		}
+		dst.SetRGBA(minX+i%labels.Width, minY+i/labels.Width, color.RGBA{A: 255})
+	}
+	return dst, nil
+}
+
+// OpsRemoveSmallComponents 去除小面积前景斑点操作
+func OpsRemoveSmallComponents(minArea, connectivity int) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		dst, err := RemoveSmallComponents(ctx.Dst, minArea, connectivity)
+		if err != nil {
+			return err
+		}
+		ctx.Dst = dst.(*image.RGBA)
+		return nil
+	}
+}
+
+// RenderComponents 将每个连通区域渲染为不同的随机颜色，背景为黑色，用于调试
+// 颜色由固定的随机种子生成，同一标记结果每次渲染的颜色相同
+func RenderComponents(labels *ComponentLabels) image.Image {
+	r := rand.New(rand.NewSource(1))
+	palette := make([]color.RGBA, len(labels.Components)+1)
+	palette[0] = color.RGBA{A: 255}
+	for i := 1; i < len(palette); i++ {
+		cr, cg, cb := HSVToRGB(r.Float64()*360, 0.5+r.Float64()*0.5, 0.6+r.Float64()*0.4)
+		palette[i] = color.RGBA{R: cr, G: cg, B: cb, A: 255}
+	}
+	dst := image.NewRGBA(image.Rect(labels.bounds.Min.X, labels.bounds.Min.Y, labels.bounds.Min.X+labels.Width, labels.bounds.Min.Y+labels.Height))
+	for i, label := range labels.Labels {
+		c := palette[label]
+		o := (i/labels.Width)*dst.Stride + (i%labels.Width)*4
+		dst.Pix[o], dst.Pix[o+1], dst.Pix[o+2], dst.Pix[o+3] = c.R, c.G, c.B, c.A
+	}
+	return dst
+}
+
+// OpsRenderComponents 标记连通区域并以随机颜色渲染的操作
+// 参数: connectivity 连通方式，4 或 8
+func OpsRenderComponents(connectivity int) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		labels, err := ConnectedComponents(ctx.Dst, connectivity)
+		if err != nil {
+			return err
+		}
+		ctx.Dst = RenderComponents(labels).(*image.RGBA)
+		return nil
+	}
+}
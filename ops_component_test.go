@@ -0,0 +1,150 @@
+package imgHelper
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestConnectedComponents(t *testing.T) {
+	src := patternImage(
+		"##....#",
+		"##...#.",
+		"....#..",
+		".......",
+		"###....",
+	)
+	cases := []struct {
+		name         string
+		connectivity int
+		areas        []int
+	}{
+		// 对角相连的3个像素在4连通时为3个区域
+		{"4连通", 4, []int{4, 1, 1, 1, 3}},
+		{"8连通", 8, []int{4, 3, 3}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			labels, err := ConnectedComponents(src, c.connectivity)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(labels.Components) != len(c.areas) {
+				t.Fatalf("区域数 %d, want %d", len(labels.Components), len(c.areas))
+			}
+			for i, comp := range labels.Components {
+				if comp.Label != i+1 || comp.Area != c.areas[i] {
+					t.Errorf("区域 %d: 标签 %d 面积 %d, want 标签 %d 面积 %d", i, comp.Label, comp.Area, i+1, c.areas[i])
+				}
+			}
+		})
+	}
+	if _, err := ConnectedComponents(src, 6); err == nil {
+		t.Error("connectivity 为6时 want error")
+	}
+}
+
+func TestComponentStats(t *testing.T) {
+	// 非零原点的图像，统计结果使用原图坐标
+	src := patternImage(
+		"......",
+		".###..",
+		".###..",
+		"......",
+		"....##",
+	)
+	src.Rect = src.Rect.Add(image.Pt(10, 20))
+	tint := uniformRGBA(6, 5, color.RGBA{R: 10, G: 20, B: 30, A: 255})
+	labels, err := ConnectedComponents(src, 8, tint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Component{
+		{Label: 1, Area: 6, Bounds: Range{X0: 11, Y0: 21, X1: 14, Y1: 23}, CentroidX: 12, CentroidY: 21.5, Perimeter: 10, MeanColor: color.RGBA{R: 10, G: 20, B: 30, A: 255}},
+		{Label: 2, Area: 2, Bounds: Range{X0: 14, Y0: 24, X1: 16, Y1: 25}, CentroidX: 14.5, CentroidY: 24, Perimeter: 6, MeanColor: color.RGBA{R: 10, G: 20, B: 30, A: 255}},
+	}
+	if len(labels.Components) != len(want) {
+		t.Fatalf("区域数 %d, want %d", len(labels.Components), len(want))
+	}
+	for i, c := range labels.Components {
+		if c != want[i] {
+			t.Errorf("区域 %d = %+v, want %+v", i, c, want[i])
+		}
+	}
+	if got := labels.Components[0].AspectRatio(); math.Abs(got-1.5) > 1e-9 {
+		t.Errorf("AspectRatio = %g, want 1.5", got)
+	}
+	cases := []struct {
+		x, y, want int
+	}{{12, 21, 1}, {15, 24, 2}, {10, 20, 0}, {0, 0, 0}, {16, 24, 0}}
+	for _, c := range cases {
+		if got := labels.Label(c.x, c.y); got != c.want {
+			t.Errorf("Label(%d, %d) = %d, want %d", c.x, c.y, got, c.want)
+		}
+	}
+	mask := labels.Mask(2)
+	if mask.Bounds() != src.Bounds() || mask.GrayAt(14, 24).Y != 255 || mask.GrayAt(12, 21).Y != 0 {
+		t.Errorf("Mask(2) 不正确")
+	}
+}
+
+func TestFilterComponents(t *testing.T) {
+	src := patternImage(
+		"#.###.#",
+		"..###.#",
+		"......#",
+		"##.....",
+	)
+	labels, err := ConnectedComponents(src, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name   string
+		filter ComponentFilter
+		areas  []int
+	}{
+		{"不限制", ComponentFilter{}, []int{1, 6, 3, 2}},
+		{"最小面积", ComponentFilter{MinArea: 2}, []int{6, 3, 2}},
+		{"最大面积", ComponentFilter{MaxArea: 3}, []int{1, 3, 2}},
+		{"竖长条", ComponentFilter{MaxAspect: 0.5}, []int{3}},
+		{"横长条", ComponentFilter{MinAspect: 1.5}, []int{6, 2}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := FilterComponents(labels, c.filter)
+			if len(got.Components) != len(c.areas) {
+				t.Fatalf("区域数 %d, want %d", len(got.Components), len(c.areas))
+			}
+			for i, comp := range got.Components {
+				if comp.Label != i+1 || comp.Area != c.areas[i] {
+					t.Errorf("区域 %d: 标签 %d 面积 %d, want 标签 %d 面积 %d", i, comp.Label, comp.Area, i+1, c.areas[i])
+				}
+			}
+			// 标签图与重新编号后的区域一致
+			for i, label := range got.Labels {
+				if label > 0 && got.Components[label-1].Bounds.X0 > i%got.Width {
+					t.Fatalf("像素 %d 的标签 %d 与区域不一致", i, label)
+				}
+			}
+		})
+	}
+}
+
+func TestRemoveSmallComponents(t *testing.T) {
+	src := patternImage(
+		"#.###.#",
+		"..###.#",
+		"......#",
+		"##.....",
+	)
+	dst, err := RemoveSmallComponents(src, 3, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "..###.#\n..###.#\n......#\n......."
+	if got := imagePattern(dst); got != want {
+		t.Errorf("结果\n%s\nwant\n%s", got, want)
+	}
+}
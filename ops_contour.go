@@ -0,0 +1,378 @@
+package imgHelper
+
+import (
+	"image"
+	"math"
+	"sort"
+)
+
+// Contour 轮廓，由边界像素按顺序组成的闭合折线
+type Contour struct {
+	Points []Point // 边界像素坐标，与原图坐标一致
+	Hole   bool    // true 表示孔洞的内边界，false 表示外边界
+	Parent int     // 父轮廓在结果中的下标，-1 表示没有父轮廓；外边界的父轮廓是包围它的孔洞，孔洞的父轮廓是它所在区域的外边界
+}
+
+// Polygon 将轮廓转换为多边形范围，可直接用于 OpsCrop、OpsMosaic
+func (c Contour) Polygon() RangePolygon {
+	return RangePolygon{Points: c.Points}
+}
+
+// Area 轮廓围成的面积(按像素中心计算)
+func (c Contour) Area() float64 {
+	return ContourArea(c.Points)
+}
+
+// contourDirs 8邻域方向，按逆时针顺序排列(从右侧开始，图像坐标系y轴向下)
+var contourDirs = [8]image.Point{{1, 0}, {1, -1}, {0, -1}, {-1, -1}, {-1, 0}, {-1, 1}, {0, 1}, {1, 1}}
+
+// FindContours 提取二值图中前景区域的轮廓及层级关系 (Suzuki-Abe 边界跟踪算法)
+// 输入图像以阈值128二值化，亮色为前景，前景按8连通处理
+// 返回的轮廓包含外边界和孔洞边界，通过 Hole 和 Parent 表示层级关系
+func FindContours(src image.Image) []Contour {
+	nrgba := imageToNRGBA(src)
+	w, h := nrgba.Bounds().Dx(), nrgba.Bounds().Dy()
+	minX, minY := src.Bounds().Min.X, src.Bounds().Min.Y
+
+	// 四周各填充一个像素的背景，简化边界判断
+	pw, ph := w+2, h+2
+	f := make([]int, pw*ph)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			o := y*nrgba.Stride + x*4
+			if nrgba.Pix[o+3] > 0 && luminance(nrgba.Pix[o], nrgba.Pix[o+1], nrgba.Pix[o+2]) >= 128 {
+				f[(y+1)*pw+x+1] = 1
+			}
+		}
+	}
+	dirIndex := func(from, to int) int {
+		dx, dy := to%pw-from%pw, to/pw-from/pw
+		for i, d := range contourDirs {
+			if d.X == dx && d.Y == dy {
+				return i
+			}
+		}
+		return 0
+	}
+	step := func(p, dir int) int {
+		return p + contourDirs[dir].Y*pw + contourDirs[dir].X
+	}
+
+	contours := make([]Contour, 0)
+	// 边界编号 NBD 从2开始，编号1表示图像边框(视为孔洞边界)，contours[nbd-2] 为对应轮廓
+	isHole := func(nbd int) bool { return nbd == 1 || contours[nbd-2].Hole }
+	parentOf := func(nbd int) int {
+		if nbd == 1 {
+			return -1
+		}
+		return contours[nbd-2].Parent
+	}
+
+	nbd := 1
+	for y := 1; y < ph-1; y++ {
+		lnbd := 1
+		for x := 1; x < pw-1; x++ {
+			p := y*pw + x
+			if f[p] == 0 {
+				continue
+			}
+			var from int
+			var hole bool
+			switch {
+			case f[p] == 1 && f[p-1] == 0:
+				from = p - 1
+			case f[p] >= 1 && f[p+1] == 0:
+				from = p + 1
+				hole = true
+				if f[p] > 1 {
+					lnbd = f[p]
+				}
+			default:
+				if f[p] != 1 {
+					lnbd = abs(f[p])
+				}
+				continue
+			}
+
+			nbd++
+			contour := Contour{Hole: hole, Parent: -1}
+			// 根据上一个遇到的边界 LNBD 确定父轮廓
+			if hole == isHole(lnbd) {
+				contour.Parent = parentOf(lnbd)
+			} else if lnbd != 1 {
+				contour.Parent = lnbd - 2
+			}
+
+			// 从 from 开始顺时针寻找第一个非零像素
+			start := dirIndex(p, from)
+			first := -1
+			for i := 0; i < 8; i++ {
+				q := step(p, (start-i+8)%8)
+				if f[q] != 0 {
+					first = q
+					break
+				}
+			}
+			if first < 0 {
+				// 孤立像素
+				f[p] = -nbd
+				contour.Points = []Point{{X: x - 1 + minX, Y: y - 1 + minY}}
+			} else {
+				prev, cur := first, p
+				for {
+					contour.Points = append(contour.Points, Point{X: cur%pw - 1 + minX, Y: cur/pw - 1 + minY})
+					// 从 prev 的下一个方向开始逆时针寻找下一个非零像素
+					d := dirIndex(cur, prev)
+					eastChecked := false
+					var next int
+					for i := 1; i <= 8; i++ {
+						nd := (d + i) % 8
+						q := step(cur, nd)
+						if f[q] != 0 {
+							next = q
+							break
+						}
+						if nd == 0 {
+							eastChecked = true
+						}
+					}
+					if eastChecked {
+						f[cur] = -nbd
+					} else if f[cur] == 1 {
+						f[cur] = nbd
+					}
+					if next == p && cur == first {
+						break
+					}
+					prev, cur = cur, next
+				}
+			}
+			contours = append(contours, contour)
+			if f[p] != 1 {
+				lnbd = abs(f[p])
+			}
+		}
+	}
+	return contours
+}
+
+// ContourArea 计算多边形的面积(鞋带公式)，points 按顺序首尾相连
+func ContourArea(points []Point) float64 {
+	if len(points) < 3 {
+		return 0
+	}
+	sum := 0
+	for i := range points {
+		j := (i + 1) % len(points)
+		sum += points[i].X*points[j].Y - points[j].X*points[i].Y
+	}
+	return math.Abs(float64(sum)) / 2
+}
+
+// ArcLength 计算折线的长度
+// 参数: closed 为 true 时包含最后一个点到第一个点的线段
+func ArcLength(points []Point, closed bool) float64 {
+	length := 0.0
+	for i := 1; i < len(points); i++ {
+		length += math.Hypot(float64(points[i].X-points[i-1].X), float64(points[i].Y-points[i-1].Y))
+	}
+	if closed && len(points) > 1 {
+		last := points[len(points)-1]
+		length += math.Hypot(float64(points[0].X-last.X), float64(points[0].Y-last.Y))
+	}
+	return length
+}
+
+// ApproxPolyDP 使用 Douglas-Peucker 算法简化折线，去除偏离不超过 epsilon 的中间点
+// 参数:
+// - epsilon 允许的最大偏离距离(像素)，常取轮廓周长的 1%~5%
+// - closed 是否为闭合轮廓
+func ApproxPolyDP(points []Point, epsilon float64, closed bool) []Point {
+	if len(points) < 3 {
+		return append([]Point(nil), points...)
+	}
+	if !closed {
+		return douglasPeucker(points, epsilon)
+	}
+	// 闭合轮廓：以第一个点和距其最远的点将轮廓分为两段分别简化
+	far, farDist := 0, -1.0
+	for i, p := range points {
+		d := math.Hypot(float64(p.X-points[0].X), float64(p.Y-points[0].Y))
+		if d > farDist {
+			far, farDist = i, d
+		}
+	}
+	if far == 0 {
+		return []Point{points[0]}
+	}
+	first := douglasPeucker(points[:far+1], epsilon)
+	second := douglasPeucker(append(append([]Point(nil), points[far:]...), points[0]), epsilon)
+	result := append(first, second[1:len(second)-1]...)
+	return result
+}
+
+// douglasPeucker 简化首尾点固定的折线
+func douglasPeucker(points []Point, epsilon float64) []Point {
+	keep := make([]bool, len(points))
+	keep[0], keep[len(points)-1] = true, true
+	type segment struct{ start, end int }
+	stack := []segment{{0, len(points) - 1}}
+	for len(stack) > 0 {
+		seg := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		a, b := points[seg.start], points[seg.end]
+		maxDist, index := 0.0, -1
+		for i := seg.start + 1; i < seg.end; i++ {
+			d := pointSegmentDistance(points[i], a, b)
+			if d > maxDist {
+				maxDist, index = d, i
+			}
+		}
+		if index >= 0 && maxDist > epsilon {
+			keep[index] = true
+			stack = append(stack, segment{seg.start, index}, segment{index, seg.end})
+		}
+	}
+	result := make([]Point, 0)
+	for i, p := range points {
+		if keep[i] {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// pointSegmentDistance 点 p 到线段 ab 的距离
+func pointSegmentDistance(p, a, b Point) float64 {
+	dx, dy := float64(b.X-a.X), float64(b.Y-a.Y)
+	px, py := float64(p.X-a.X), float64(p.Y-a.Y)
+	lenSq := dx*dx + dy*dy
+	if lenSq == 0 {
+		return math.Hypot(px, py)
+	}
+	t := clamp((px*dx+py*dy)/lenSq, 0, 1)
+	return math.Hypot(px-t*dx, py-t*dy)
+}
+
+// ConvexHull 计算点集的凸包 (Andrew 单调链算法)，返回的顶点按顺序排列，不包含共线点
+func ConvexHull(points []Point) []Point {
+	pts := append([]Point(nil), points...)
+	sort.Slice(pts, func(i, j int) bool {
+		if pts[i].X != pts[j].X {
+			return pts[i].X < pts[j].X
+		}
+		return pts[i].Y < pts[j].Y
+	})
+	// 去重
+	unique := pts[:0]
+	for i, p := range pts {
+		if i == 0 || p != pts[i-1] {
+			unique = append(unique, p)
+		}
+	}
+	pts = unique
+	if len(pts) < 3 {
+		return pts
+	}
+	cross := func(o, a, b Point) int {
+		return (a.X-o.X)*(b.Y-o.Y) - (a.Y-o.Y)*(b.X-o.X)
+	}
+	hull := make([]Point, 0, 2*len(pts))
+	for _, p := range pts {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	lower := len(hull) + 1
+	for i := len(pts) - 2; i >= 0; i-- {
+		p := pts[i]
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	return hull[:len(hull)-1]
+}
+
+// RotatedRect 旋转矩形
+type RotatedRect struct {
+	Cx, Cy float64 // 中心点
+	Width  float64 // 沿 Angle 方向的边长
+	Height float64 // 垂直于 Angle 方向的边长
+	Angle  float64 // Width 边相对于x轴的角度，单位为度，范围 [0,180)
+}
+
+// Corners 返回矩形的四个顶点坐标(四舍五入取整)，按顺序首尾相连
+func (r RotatedRect) Corners() [4]Point {
+	rad := r.Angle * math.Pi / 180
+	ux, uy := math.Cos(rad)*r.Width/2, math.Sin(rad)*r.Width/2
+	vx, vy := -math.Sin(rad)*r.Height/2, math.Cos(rad)*r.Height/2
+	round := func(x, y float64) Point {
+		return Point{X: int(math.Round(x)), Y: int(math.Round(y))}
+	}
+	return [4]Point{
+		round(r.Cx-ux-vx, r.Cy-uy-vy),
+		round(r.Cx+ux-vx, r.Cy+uy-vy),
+		round(r.Cx+ux+vx, r.Cy+uy+vy),
+		round(r.Cx-ux+vx, r.Cy-uy+vy),
+	}
+}
+
+// Polygon 将旋转矩形转换为多边形范围
+func (r RotatedRect) Polygon() RangePolygon {
+	corners := r.Corners()
+	return RangePolygon{Points: corners[:]}
+}
+
+// MinAreaRect 计算包围点集的最小面积旋转矩形 (旋转卡壳)，坐标按像素中心计算
+func MinAreaRect(points []Point) RotatedRect {
+	hull := ConvexHull(points)
+	switch len(hull) {
+	case 0:
+		return RotatedRect{}
+	case 1:
+		return RotatedRect{Cx: float64(hull[0].X), Cy: float64(hull[0].Y)}
+	}
+	best := RotatedRect{}
+	bestArea := math.Inf(1)
+	for i := range hull {
+		a, b := hull[i], hull[(i+1)%len(hull)]
+		ex, ey := float64(b.X-a.X), float64(b.Y-a.Y)
+		length := math.Hypot(ex, ey)
+		if length == 0 {
+			continue
+		}
+		ux, uy := ex/length, ey/length
+		// 将所有顶点投影到以该边为方向的坐标系中
+		minU, maxU := math.Inf(1), math.Inf(-1)
+		minV, maxV := math.Inf(1), math.Inf(-1)
+		for _, p := range hull {
+			px, py := float64(p.X-a.X), float64(p.Y-a.Y)
+			u := px*ux + py*uy
+			v := -px*uy + py*ux
+			minU, maxU = math.Min(minU, u), math.Max(maxU, u)
+			minV, maxV = math.Min(minV, v), math.Max(maxV, v)
+		}
+		area := (maxU - minU) * (maxV - minV)
+		if area < bestArea {
+			bestArea = area
+			cu, cv := (minU+maxU)/2, (minV+maxV)/2
+			angle := math.Atan2(uy, ux) * 180 / math.Pi
+			if angle < 0 {
+				angle += 180
+			}
+			if angle >= 180 {
+				angle -= 180
+			}
+			best = RotatedRect{
+				Cx:     float64(a.X) + cu*ux - cv*uy,
+				Cy:     float64(a.Y) + cu*uy + cv*ux,
+				Width:  maxU - minU,
+				Height: maxV - minV,
+				Angle:  angle,
+			}
+		}
+	}
+	return best
+}
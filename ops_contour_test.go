@@ -0,0 +1,120 @@
+package imgHelper
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestContourArea(t *testing.T) {
+	cases := []struct {
+		name   string
+		points []Point
+		want   float64
+	}{
+		{"不足3点", []Point{{0, 0}, {10, 0}}, 0},
+		{"正方形", []Point{{0, 0}, {10, 0}, {10, 10}, {0, 10}}, 100},
+		{"逆时针", []Point{{0, 0}, {0, 10}, {10, 10}, {10, 0}}, 100},
+		{"三角形", []Point{{0, 0}, {10, 0}, {0, 5}}, 25},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := ContourArea(c.points); got != c.want {
+				t.Errorf("ContourArea = %g, want %g", got, c.want)
+			}
+		})
+	}
+}
+
+func TestApproxPolyDP(t *testing.T) {
+	cases := []struct {
+		name    string
+		points  []Point
+		epsilon float64
+		closed  bool
+		want    []Point
+	}{
+		{"偏离小于阈值", []Point{{0, 0}, {5, 1}, {10, 0}}, 2, false, []Point{{0, 0}, {10, 0}}},
+		{"偏离大于阈值", []Point{{0, 0}, {5, 1}, {10, 0}}, 0.5, false, []Point{{0, 0}, {5, 1}, {10, 0}}},
+		{"折线", []Point{{0, 0}, {3, 0}, {6, 0}, {6, 3}, {6, 6}}, 1, false, []Point{{0, 0}, {6, 0}, {6, 6}}},
+		{"闭合正方形去掉边中点", []Point{{0, 0}, {5, 0}, {10, 0}, {10, 10}, {0, 10}}, 1, true, []Point{{0, 0}, {10, 0}, {10, 10}, {0, 10}}},
+		{"不足3点", []Point{{0, 0}, {1, 1}}, 1, true, []Point{{0, 0}, {1, 1}}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := ApproxPolyDP(c.points, c.epsilon, c.closed); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("ApproxPolyDP = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestConvexHull(t *testing.T) {
+	cases := []struct {
+		name   string
+		points []Point
+		want   []Point
+	}{
+		{"内部点和共线点", []Point{{0, 0}, {5, 0}, {10, 0}, {10, 10}, {0, 10}, {5, 5}, {3, 7}, {0, 5}}, []Point{{0, 0}, {10, 0}, {10, 10}, {0, 10}}},
+		{"重复点", []Point{{1, 1}, {1, 1}, {4, 1}, {1, 4}, {4, 1}}, []Point{{1, 1}, {4, 1}, {1, 4}}},
+		{"两个点", []Point{{3, 3}, {0, 0}}, []Point{{0, 0}, {3, 3}}},
+		{"全部共线", []Point{{0, 0}, {1, 1}, {2, 2}, {3, 3}}, []Point{{0, 0}, {3, 3}}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := ConvexHull(c.points)
+			if len(got) >= 3 && ContourArea(got) != ContourArea(c.want) {
+				t.Errorf("凸包面积 %g, want %g", ContourArea(got), ContourArea(c.want))
+			}
+			sortPoints(got)
+			want := append([]Point(nil), c.want...)
+			sortPoints(want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("ConvexHull = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func sortPoints(points []Point) {
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].X != points[j].X {
+			return points[i].X < points[j].X
+		}
+		return points[i].Y < points[j].Y
+	})
+}
+
+func TestMinAreaRect(t *testing.T) {
+	cases := []struct {
+		name        string
+		points      []Point
+		cx, cy      float64
+		area        float64
+		side, side2 float64
+	}{
+		{"轴对齐矩形", []Point{{0, 0}, {10, 0}, {10, 4}, {0, 4}, {5, 2}}, 5, 2, 40, 10, 4},
+		{"菱形", []Point{{5, 0}, {10, 5}, {5, 10}, {0, 5}}, 5, 5, 50, math.Sqrt(50), math.Sqrt(50)},
+		{"旋转的矩形", []Point{{0, 3}, {4, 0}, {10, 8}, {6, 11}}, 5, 5.5, 50, 10, 5},
+		{"单点", []Point{{3, 4}}, 3, 4, 0, 0, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := MinAreaRect(c.points)
+			if math.Abs(r.Cx-c.cx) > 1e-9 || math.Abs(r.Cy-c.cy) > 1e-9 {
+				t.Errorf("中心 (%g, %g), want (%g, %g)", r.Cx, r.Cy, c.cx, c.cy)
+			}
+			if math.Abs(r.Width*r.Height-c.area) > 1e-9 {
+				t.Errorf("面积 %g, want %g", r.Width*r.Height, c.area)
+			}
+			long, short := math.Max(r.Width, r.Height), math.Min(r.Width, r.Height)
+			if math.Abs(long-math.Max(c.side, c.side2)) > 1e-9 || math.Abs(short-math.Min(c.side, c.side2)) > 1e-9 {
+				t.Errorf("边长 %g x %g, want %g x %g", r.Width, r.Height, c.side, c.side2)
+			}
+			if r.Angle < 0 || r.Angle >= 180 {
+				t.Errorf("角度 %g 超出 [0,180)", r.Angle)
+			}
+		})
+	}
+}
@@ -1,10 +1,8 @@
 package imgHelper
 
 import (
-	"errors"
 	"fmt"
 	"image"
-	"image/color"
 	"image/draw"
 )
 
@@ -16,173 +14,97 @@ func Crop(src image.Image, x0, y0, x1, y1 int) image.Image {
 	return dst
 }
 
+// ShapeOptions 形状裁剪和形状马赛克的参数，零值字段使用默认值
+// 形状的边缘按像素被覆盖的面积做抗锯齿
+type ShapeOptions struct {
+	Feather  float64 // 羽化半径(像素)，边缘在该宽度内平滑过渡，默认为0即只做抗锯齿
+	KeepSize bool    // 裁剪时保持源图像的尺寸和位置，形状以外透明；默认裁剪为形状的外接矩形。对马赛克无效
+	Invert   bool    // 反选，作用于形状以外的部分
+}
+
+func getShapeOptions(opts []ShapeOptions) ShapeOptions {
+	if len(opts) > 0 {
+		return opts[0]
+	}
+	return ShapeOptions{}
+}
+
+// shapeMask 范围在 bounds 内的抗锯齿掩码，按参数羽化和反选
+func shapeMask(rg RangeValue, bounds image.Rectangle, opt ShapeOptions) (*image.Gray, error) {
+	mask, err := rangeMask(rg, bounds)
+	if err != nil {
+		return nil, err
+	}
+	mask = featherMask(mask, opt.Feather)
+	if opt.Invert {
+		for i, v := range mask.Pix {
+			mask.Pix[i] = 255 - v
+		}
+	}
+	return mask, nil
+}
+
 // OpsCrop 裁剪操作
-// todo [优化]三角形，多边形的锯齿太严重了
-// todo 需要增加曲边多边形裁剪
-func OpsCrop(rg RangeValue) func(ctx *CanvasContext) error {
+// 参数:
+// - rg 裁剪范围（矩形，圆，三角形，多边形，曲边多边形，掩码）
+// - opts 羽化半径和是否保持原尺寸，可选，矩形不传时为普通的矩形裁剪
+func OpsCrop(rg RangeValue, opts ...ShapeOptions) func(ctx *CanvasContext) error {
 	return func(ctx *CanvasContext) error {
-
-		switch rg.Type() {
-		case RangeRectType:
+		if rg.Type() == RangeRectType && getShapeOptions(opts) == (ShapeOptions{}) {
 			rgObj := rg.(Range)
 			ctx.Dst = Crop(ctx.Dst, rgObj.X0, rgObj.Y0, rgObj.X1, rgObj.Y1).(*image.RGBA)
-
-		case RangeCircleType:
-			rgObj := rg.(RangeCircle)
-			ctx.Dst = CropCircle(ctx.Dst, rgObj.Cx, rgObj.Cy, rgObj.R).(*image.RGBA)
-
-		case RangeTriangleType:
-			rgObj := rg.(RangeTriangle)
-			ctx.Dst = CropTriangle(ctx.Dst, rgObj.X0, rgObj.Y0, rgObj.X1, rgObj.Y1, rgObj.X2, rgObj.Y2).(*image.RGBA)
-
-		case RangePolygonType:
-			rgObj := rg.(RangePolygon)
-			points := make([]int, 0)
-			for _, v := range rgObj.Points {
-				points = append(points, v.X)
-				points = append(points, v.Y)
-			}
-			dst, err := CropPolygon(ctx.Dst, points...)
-			ctx.Dst = dst.(*image.RGBA)
-			ctx.Err = errors.Join(ctx.Err, err)
-
+			return nil
 		}
-
+		dst, err := CropShape(ctx.Dst, rg, opts...)
+		if err != nil {
+			return err
+		}
+		ctx.Dst = dst.(*image.RGBA)
 		return nil
 	}
 }
 
-// CropCircle 圆形裁剪：保留源图像中以(cx, cy)为圆心、r为半径的圆形区域，圆形外像素设为透明
+// CropShape 形状裁剪：保留源图像中范围内的区域，范围外透明，边缘抗锯齿，可选羽化
+// 默认裁剪为形状的外接矩形(羽化时包含羽化的部分)，形状与源图像没有交集时返回空图像
+// 参数:
+// - rg 裁剪范围（矩形，圆，三角形，多边形，曲边多边形，掩码）
+// - opts 羽化半径和是否保持原尺寸，可选
+func CropShape(src image.Image, rg RangeValue, opts ...ShapeOptions) (image.Image, error) {
+	opt := getShapeOptions(opts)
+	mask, err := shapeMask(rg, src.Bounds(), opt)
+	if err != nil {
+		return nil, err
+	}
+	if opt.KeepSize {
+		return maskedCopy(src, mask, src.Bounds()), nil
+	}
+	return maskedCopy(src, mask, maskBounds(mask)), nil
+}
+
+// CropCircle 圆形裁剪：保留源图像中以(cx, cy)为圆心、r为半径的圆形区域，圆形外像素设为透明，边缘抗锯齿
 // 参数：
 //
 //	cx, cy：圆心在源图像中的坐标
 //	r：圆的半径
-func CropCircle(src image.Image, cx, cy, r int) image.Image {
-	// 边界矩形左上角：(cx - r, cy - r)，右下角：(cx + r, cy + r)
-	boundMinX := cx - r
-	boundMinY := cy - r
-	boundMaxX := cx + r
-	boundMaxY := cy + r
-
-	// 越界处理
-	srcBounds := src.Bounds()
-	if boundMinX < srcBounds.Min.X {
-		boundMinX = srcBounds.Min.X
-	}
-	if boundMinY < srcBounds.Min.Y {
-		boundMinY = srcBounds.Min.Y
-	}
-	if boundMaxX > srcBounds.Max.X {
-		boundMaxX = srcBounds.Max.X
-	}
-	if boundMaxY > srcBounds.Max.Y {
-		boundMaxY = srcBounds.Max.Y
-	}
-
-	cropRect := image.Rect(boundMinX, boundMinY, boundMaxX, boundMaxY)
-	dst := image.NewRGBA(cropRect)
-
-	rSquared := r * r // 半径的平方（用于距离判断，避免开方运算）
-	for y := boundMinY; y < boundMaxY; y++ {
-		for x := boundMinX; x < boundMaxX; x++ {
-			// 计算当前像素到圆心的距离的平方
-			dx := x - cx
-			dy := y - cy
-			distanceSquared := dx*dx + dy*dy
-
-			// 若距离 <= 半径，属于圆形内，保留源图像像素；否则设为透明
-			if distanceSquared <= rSquared {
-
-				r, g, b, a := src.At(x, y).RGBA()
-				dst.SetRGBA(
-					x, y,
-					color.RGBA{
-						R: uint8(r >> 8),
-						G: uint8(g >> 8),
-						B: uint8(b >> 8),
-						A: uint8(a >> 8),
-					},
-				)
-			} else {
-				// 圆形外像素设为透明（Alpha=0）
-				dst.SetRGBA(x, y, color.RGBA{R: 0, G: 0, B: 0, A: 0})
-			}
-		}
-	}
-
+//	opts：羽化半径和是否保持原尺寸，可选
+func CropCircle(src image.Image, cx, cy, r int, opts ...ShapeOptions) image.Image {
+	dst, _ := CropShape(src, RangeCircle{Cx: cx, Cy: cy, R: r}, opts...)
 	return dst
 }
 
-// CropTriangle 三角形裁剪：保留源图像中由三个顶点(x1,y1)、(x2,y2)、(x3,y3)围成的三角形区域，外部像素设为透明
+// CropTriangle 三角形裁剪：保留源图像中由三个顶点(x1,y1)、(x2,y2)、(x3,y3)围成的三角形区域，外部像素设为透明，边缘抗锯齿
 // 参数：
 //
 //	src：源图像
 //	x1,y1, x2,y2, x3,y3：三角形三个顶点在源图像中的坐标
-func CropTriangle(src image.Image, x1, y1, x2, y2, x3, y3 int) image.Image {
-	minX := minValue(x1, x2, x3)
-	maxX := maxValue(x1, x2, x3)
-	minY := minValue(y1, y2, y3)
-	maxY := maxValue(y1, y2, y3)
-
-	srcBounds := src.Bounds()
-	srcMinX, srcMinY := srcBounds.Min.X, srcBounds.Min.Y
-	srcMaxX, srcMaxY := srcBounds.Max.X, srcBounds.Max.Y
-
-	if minX < srcMinX {
-		minX = srcMinX
-	}
-	if maxX > srcMaxX {
-		maxX = srcMaxX
-	}
-	if minY < srcMinY {
-		minY = srcMinY
-	}
-	if maxY > srcMaxY {
-		maxY = srcMaxY
-	}
-
-	// 目标图像的Bounds为 [minX, minY] 到 [maxX, maxY]，内部坐标以 (0,0) 对应 (minX, minY)
-	cropRect := image.Rect(minX, minY, maxX, maxY)
-	dst := image.NewRGBA(cropRect)
-	dstWidth := maxX - minX  // 目标图像宽度
-	dstHeight := maxY - minY // 目标图像高度
-
-	// 遍历边界矩形内的每个像素，判断是否在三角形内
-	for y := minY; y < maxY; y++ {
-		for x := minX; x < maxX; x++ {
-			// 计算当前像素在目标图像中的相对坐标（关键修复：转换为dst内部坐标）
-			dx := x - minX
-			dy := y - minY
-
-			// 双重校验：确保相对坐标在目标图像范围内（避免极端情况越界）
-			if dx < 0 || dx >= dstWidth || dy < 0 || dy >= dstHeight {
-				continue
-			}
-
-			// 判断点是否在三角形内
-			if isPointInTriangle(x, y, x1, y1, x2, y2, x3, y3) {
-				// 三角形内：保留源图像像素（转换为8位通道）
-				r, g, b, a := src.At(x, y).RGBA()
-				dst.SetRGBA(
-					dx, dy, // 使用相对坐标写入目标图像
-					color.RGBA{
-						R: uint8(r >> 8),
-						G: uint8(g >> 8),
-						B: uint8(b >> 8),
-						A: uint8(a >> 8),
-					},
-				)
-			} else {
-				// 三角形外：设为透明（Alpha=0）
-				dst.SetRGBA(dx, dy, color.RGBA{R: 0, G: 0, B: 0, A: 0})
-			}
-		}
-	}
-
+//	opts：羽化半径和是否保持原尺寸，可选
+func CropTriangle(src image.Image, x1, y1, x2, y2, x3, y3 int, opts ...ShapeOptions) image.Image {
+	dst, _ := CropShape(src, RangeTriangle{X0: x1, Y0: y1, X1: x2, Y1: y2, X2: x3, Y2: y3}, opts...)
 	return dst
 }
 
-// CropPolygon 多边形裁剪：保留源图像中由多个顶点围成的多边形区域，外部像素设为透明
+// CropPolygon 多边形裁剪：保留源图像中由多个顶点围成的多边形区域，外部像素设为透明，边缘抗锯齿
+// 需要羽化或保持原尺寸时使用 CropShape(src, RangePolygon{...}, ShapeOptions{...})
 // 参数：
 //
 //	src：源图像
@@ -193,83 +115,69 @@ func CropPolygon(src image.Image, points ...int) (image.Image, error) {
 	if len(points) < 6 || len(points)%2 != 0 {
 		return nil, fmt.Errorf("至少需要三个顶点")
 	}
-	vertexCount := len(points) / 2
-
-	minX, maxX := points[0], points[0]
-	minY, maxY := points[1], points[1]
-	vertices := make([][2]int, vertexCount) // 存储顶点坐标 (x,y)
-
-	for i := 0; i < vertexCount; i++ {
-		x := points[2*i]
-		y := points[2*i+1]
-		vertices[i] = [2]int{x, y}
+	return CropShape(src, pointsToPolygon(points))
+}
 
-		if x < minX {
-			minX = x
-		}
-		if x > maxX {
-			maxX = x
-		}
-		if y < minY {
-			minY = y
-		}
-		if y > maxY {
-			maxY = y
-		}
+// pointsToPolygon 将 [x0,y0, x1,y1, ...] 格式的坐标转换为多边形范围
+func pointsToPolygon(points []int) RangePolygon {
+	rg := RangePolygon{Points: make([]Point, len(points)/2)}
+	for i := range rg.Points {
+		rg.Points[i] = Point{X: points[2*i], Y: points[2*i+1]}
 	}
+	return rg
+}
 
-	// 边界矩形越界处理（限制在源图像范围内）
-	srcBounds := src.Bounds()
-	srcMinX, srcMinY := srcBounds.Min.X, srcBounds.Min.Y
-	srcMaxX, srcMaxY := srcBounds.Max.X, srcBounds.Max.Y
-
-	if minX < srcMinX {
-		minX = srcMinX
-	}
-	if maxX > srcMaxX {
-		maxX = srcMaxX
-	}
-	if minY < srcMinY {
-		minY = srcMinY
+// CropMask 掩码裁剪：保留源图像中掩码覆盖的区域，裁剪为掩码非零区域的外接矩形
+// 掩码值作为不透明度，0 为完全透明，255 保留原像素，中间值得到半透明的柔和边缘
+// 参数：
+//
+//	src：源图像
+//	mask：掩码，坐标与源图像一致
+func CropMask(src image.Image, mask *image.Gray) (image.Image, error) {
+	if mask == nil {
+		return nil, fmt.Errorf("掩码不能为空")
 	}
-	if maxY > srcMaxY {
-		maxY = srcMaxY
+	rect := maskBounds(mask).Intersect(src.Bounds())
+	if rect.Empty() {
+		return nil, fmt.Errorf("掩码与源图像没有交集")
 	}
+	return maskedCopy(src, mask, rect), nil
+}
 
-	// 创建目标图像（大小为边界矩形的宽高）
-	cropRect := image.Rect(minX, minY, maxX, maxY)
-	dst := image.NewRGBA(cropRect)
-	dstWidth := maxX - minX
-	dstHeight := maxY - minY
-
-	for y := minY; y < maxY; y++ {
-		for x := minX; x < maxX; x++ {
-			dx := x - minX
-			dy := y - minY
-
-			// 校验相对坐标有效性
-			if dx < 0 || dx >= dstWidth || dy < 0 || dy >= dstHeight {
+// maskedCopy 复制源图像 rect 范围内的像素，按掩码值设置不透明度，掩码以外的部分透明
+func maskedCopy(src image.Image, mask *image.Gray, rect image.Rectangle) *image.RGBA {
+	dst := image.NewRGBA(rect)
+	draw.Draw(dst, rect, src, rect.Min, draw.Src)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			m := uint32(mask.GrayAt(x, y).Y)
+			if m == 255 {
 				continue
 			}
-
-			if isPointInPolygon(x, y, vertices) {
-				// 多边形内：保留源图像像素
-				r, g, b, a := src.At(x, y).RGBA()
-				dst.SetRGBA(
-					dx, dy,
-					color.RGBA{
-						R: uint8(r >> 8),
-						G: uint8(g >> 8),
-						B: uint8(b >> 8),
-						A: uint8(a >> 8),
-					},
-				)
-			} else {
-				// 多边形外：设为透明
-				dst.SetRGBA(dx, dy, color.RGBA{R: 0, G: 0, B: 0, A: 0})
+			o := dst.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				dst.Pix[o+c] = uint8((uint32(dst.Pix[o+c])*m + 127) / 255)
 			}
 		}
 	}
+	return dst
+}
 
-	return dst, nil
+// maskBounds 掩码中非零像素的外接矩形
+func maskBounds(mask *image.Gray) image.Rectangle {
+	b := mask.Bounds()
+	minX, minY, maxX, maxY := b.Max.X, b.Max.Y, b.Min.X, b.Min.Y
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if mask.GrayAt(x, y).Y == 0 {
+				continue
+			}
+			minX, minY = minValue(minX, x), minValue(minY, y)
+			maxX, maxY = maxValue(maxX, x+1), maxValue(maxY, y+1)
+		}
+	}
+	if minX >= maxX || minY >= maxY {
+		return image.Rectangle{}
+	}
+	return image.Rect(minX, minY, maxX, maxY)
 }
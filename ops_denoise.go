@@ -0,0 +1,283 @@
+package imgHelper
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+)
+
+// MedianFilter 中值滤波
+// 取邻域内每个通道的中值，能有效去除椒盐噪点并保持边缘清晰
+// 半径较大时使用基于直方图的滑动窗口算法(Huang算法)，耗时与半径近似线性
+// 参数: radius 邻域半径，窗口大小为 2*radius+1
+func MedianFilter(src image.Image, radius int) (image.Image, error) {
+	if radius < 1 {
+		return nil, fmt.Errorf("radius 必须大于等于1, 当前为 %d", radius)
+	}
+	bounds := src.Bounds()
+	nrgba := imageToNRGBA(src)
+	w, h := nrgba.Bounds().Dx(), nrgba.Bounds().Dy()
+	var dst *image.NRGBA
+	if radius <= 2 {
+		dst = medianFilterSort(nrgba, w, h, radius)
+	} else {
+		dst = medianFilterHistogram(nrgba, w, h, radius)
+	}
+	return nrgbaToRGBA(dst, bounds), nil
+}
+
+// OpsMedianFilter 中值滤波操作
+// 参数: radius 邻域半径
+func OpsMedianFilter(radius int) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		dst, err := MedianFilter(ctx.Dst, radius)
+		if err != nil {
+			return err
+		}
+		ctx.Dst = dst.(*image.RGBA)
+		return nil
+	}
+}
+
+// medianFilterSort 小半径的中值滤波，直接对邻域排序取中值
+func medianFilterSort(src *image.NRGBA, w, h, radius int) *image.NRGBA {
+	dst := image.NewNRGBA(src.Bounds())
+	size := (2*radius + 1) * (2*radius + 1)
+	values := make([]int, size)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			o := y*src.Stride + x*4
+			for c := 0; c < 4; c++ {
+				n := 0
+				for ky := -radius; ky <= radius; ky++ {
+					yy := clamp(y+ky, 0, h-1)
+					for kx := -radius; kx <= radius; kx++ {
+						xx := clamp(x+kx, 0, w-1)
+						values[n] = int(src.Pix[yy*src.Stride+xx*4+c])
+						n++
+					}
+				}
+				sort.Ints(values)
+				dst.Pix[o+c] = uint8(values[size/2])
+			}
+		}
+	}
+	return dst
+}
+
+// medianFilterHistogram 基于直方图的中值滤波，每行维护一个滑动窗口直方图
+func medianFilterHistogram(src *image.NRGBA, w, h, radius int) *image.NRGBA {
+	dst := image.NewNRGBA(src.Bounds())
+	half := (2*radius+1)*(2*radius+1)/2 + 1
+	// 向直方图中加入或移除一列像素
+	updateColumn := func(hist *[4][256]int, x, y, delta int) {
+		xx := clamp(x, 0, w-1)
+		for ky := -radius; ky <= radius; ky++ {
+			yy := clamp(y+ky, 0, h-1)
+			o := yy*src.Stride + xx*4
+			hist[0][src.Pix[o]] += delta
+			hist[1][src.Pix[o+1]] += delta
+			hist[2][src.Pix[o+2]] += delta
+			hist[3][src.Pix[o+3]] += delta
+		}
+	}
+	for y := 0; y < h; y++ {
+		var hist [4][256]int
+		for kx := -radius; kx <= radius; kx++ {
+			updateColumn(&hist, kx, y, 1)
+		}
+		for x := 0; x < w; x++ {
+			o := y*dst.Stride + x*4
+			for c := 0; c < 4; c++ {
+				sum := 0
+				for v := 0; v < 256; v++ {
+					sum += hist[c][v]
+					if sum >= half {
+						dst.Pix[o+c] = uint8(v)
+						break
+					}
+				}
+			}
+			updateColumn(&hist, x-radius, y, -1)
+			updateColumn(&hist, x+radius+1, y, 1)
+		}
+	}
+	return dst
+}
+
+// BilateralFilter 双边滤波
+// 权重同时考虑空间距离和颜色差异，颜色差异大的邻域像素(边缘另一侧)权重很小，因此在平滑的同时保持边缘
+// 参数:
+// - sigmaSpace 空间域标准差，决定邻域大小，窗口半径为 ceil(2*sigmaSpace)
+// - sigmaColor 颜色域标准差，越大越接近普通高斯模糊，常用 10~50
+func BilateralFilter(src image.Image, sigmaSpace, sigmaColor float64) (image.Image, error) {
+	if sigmaSpace <= 0 || sigmaColor <= 0 {
+		return nil, fmt.Errorf("sigmaSpace 和 sigmaColor 必须大于0, 当前为 %v, %v", sigmaSpace, sigmaColor)
+	}
+	bounds := src.Bounds()
+	nrgba := imageToNRGBA(src)
+	w, h := nrgba.Bounds().Dx(), nrgba.Bounds().Dy()
+	dst := image.NewNRGBA(nrgba.Bounds())
+
+	radius := int(math.Ceil(2 * sigmaSpace))
+	size := 2*radius + 1
+	spaceWeight := make([]float64, size*size)
+	for ky := -radius; ky <= radius; ky++ {
+		for kx := -radius; kx <= radius; kx++ {
+			spaceWeight[(ky+radius)*size+kx+radius] = math.Exp(-float64(kx*kx+ky*ky) / (2 * sigmaSpace * sigmaSpace))
+		}
+	}
+	// 颜色距离的平方最大为 3*255*255，预先计算颜色权重查找表
+	colorWeight := make([]float64, 3*255*255+1)
+	for i := range colorWeight {
+		colorWeight[i] = math.Exp(-float64(i) / (2 * sigmaColor * sigmaColor))
+	}
+
+	pix := nrgba.Pix
+	stride := nrgba.Stride
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			o := y*stride + x*4
+			r0, g0, b0 := int(pix[o]), int(pix[o+1]), int(pix[o+2])
+			var sumR, sumG, sumB, sumA, sumW float64
+			for ky := -radius; ky <= radius; ky++ {
+				yy := clamp(y+ky, 0, h-1)
+				for kx := -radius; kx <= radius; kx++ {
+					xx := clamp(x+kx, 0, w-1)
+					n := yy*stride + xx*4
+					dr, dg, db := int(pix[n])-r0, int(pix[n+1])-g0, int(pix[n+2])-b0
+					wt := spaceWeight[(ky+radius)*size+kx+radius] * colorWeight[dr*dr+dg*dg+db*db]
+					sumR += float64(pix[n]) * wt
+					sumG += float64(pix[n+1]) * wt
+					sumB += float64(pix[n+2]) * wt
+					sumA += float64(pix[n+3]) * wt
+					sumW += wt
+				}
+			}
+			dst.Pix[o] = uint8(clamp(math.Round(sumR/sumW), 0, 255))
+			dst.Pix[o+1] = uint8(clamp(math.Round(sumG/sumW), 0, 255))
+			dst.Pix[o+2] = uint8(clamp(math.Round(sumB/sumW), 0, 255))
+			dst.Pix[o+3] = uint8(clamp(math.Round(sumA/sumW), 0, 255))
+		}
+	}
+	return nrgbaToRGBA(dst, bounds), nil
+}
+
+// OpsBilateralFilter 双边滤波操作
+// 参数:
+// - sigmaSpace 空间域标准差
+// - sigmaColor 颜色域标准差
+func OpsBilateralFilter(sigmaSpace, sigmaColor float64) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		dst, err := BilateralFilter(ctx.Dst, sigmaSpace, sigmaColor)
+		if err != nil {
+			return err
+		}
+		ctx.Dst = dst.(*image.RGBA)
+		return nil
+	}
+}
+
+// NLMeansDenoise 非局部均值降噪 (Non-Local Means)
+// 在搜索窗口内寻找与当前像素周围图像块相似的图像块，按相似度加权平均，纹理和文字笔画能较好保留
+// 使用积分图计算图像块距离，耗时与图像块大小无关
+// 参数:
+// - strength 降噪强度(滤波参数h)，越大越平滑，常用 5~15
+// - patchRadius 图像块半径，常用 1~3
+// - searchRadius 搜索窗口半径，常用 5~10，越大效果越好但越慢
+func NLMeansDenoise(src image.Image, strength float64, patchRadius, searchRadius int) (image.Image, error) {
+	if strength <= 0 {
+		return nil, fmt.Errorf("strength 必须大于0, 当前为 %v", strength)
+	}
+	if patchRadius < 0 || searchRadius < 1 {
+		return nil, fmt.Errorf("patchRadius 必须大于等于0且 searchRadius 必须大于等于1, 当前为 %d, %d", patchRadius, searchRadius)
+	}
+	bounds := src.Bounds()
+	nrgba := imageToNRGBA(src)
+	w, h := nrgba.Bounds().Dx(), nrgba.Bounds().Dy()
+	if w == 0 || h == 0 {
+		return nrgbaToRGBA(nrgba, bounds), nil
+	}
+	pix := nrgba.Pix
+	stride := nrgba.Stride
+
+	sums := make([]float64, w*h*4)
+	weights := make([]float64, w*h)
+	diff := make([]float64, w*h)
+	integral := make([]float64, (w+1)*(h+1))
+	h2 := strength * strength
+
+	for dy := -searchRadius; dy <= searchRadius; dy++ {
+		for dx := -searchRadius; dx <= searchRadius; dx++ {
+			// 当前偏移下每个像素与偏移像素的颜色差平方
+			for y := 0; y < h; y++ {
+				yy := clamp(y+dy, 0, h-1)
+				for x := 0; x < w; x++ {
+					xx := clamp(x+dx, 0, w-1)
+					o := y*stride + x*4
+					n := yy*stride + xx*4
+					dr := float64(pix[o]) - float64(pix[n])
+					dg := float64(pix[o+1]) - float64(pix[n+1])
+					db := float64(pix[o+2]) - float64(pix[n+2])
+					diff[y*w+x] = (dr*dr + dg*dg + db*db) / 3
+				}
+			}
+			// 积分图
+			for y := 0; y < h; y++ {
+				rowSum := 0.0
+				for x := 0; x < w; x++ {
+					rowSum += diff[y*w+x]
+					integral[(y+1)*(w+1)+x+1] = integral[y*(w+1)+x+1] + rowSum
+				}
+			}
+			// 图像块距离与加权累加
+			for y := 0; y < h; y++ {
+				y0, y1 := maxValue(y-patchRadius, 0), minValue(y+patchRadius+1, h)
+				yy := clamp(y+dy, 0, h-1)
+				for x := 0; x < w; x++ {
+					x0, x1 := maxValue(x-patchRadius, 0), minValue(x+patchRadius+1, w)
+					area := float64((x1 - x0) * (y1 - y0))
+					dist := (integral[y1*(w+1)+x1] - integral[y0*(w+1)+x1] - integral[y1*(w+1)+x0] + integral[y0*(w+1)+x0]) / area
+					wt := math.Exp(-dist / h2)
+					xx := clamp(x+dx, 0, w-1)
+					n := yy*stride + xx*4
+					i := y*w + x
+					sums[i*4] += float64(pix[n]) * wt
+					sums[i*4+1] += float64(pix[n+1]) * wt
+					sums[i*4+2] += float64(pix[n+2]) * wt
+					sums[i*4+3] += float64(pix[n+3]) * wt
+					weights[i] += wt
+				}
+			}
+		}
+	}
+
+	dst := image.NewNRGBA(nrgba.Bounds())
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			o := y*dst.Stride + x*4
+			for c := 0; c < 4; c++ {
+				dst.Pix[o+c] = uint8(clamp(math.Round(sums[i*4+c]/weights[i]), 0, 255))
+			}
+		}
+	}
+	return nrgbaToRGBA(dst, bounds), nil
+}
+
+// OpsNLMeansDenoise 非局部均值降噪操作
+// 参数:
+// - strength 降噪强度
+// - patchRadius 图像块半径
+// - searchRadius 搜索窗口半径
+func OpsNLMeansDenoise(strength float64, patchRadius, searchRadius int) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		dst, err := NLMeansDenoise(ctx.Dst, strength, patchRadius, searchRadius)
+		if err != nil {
+			return err
+		}
+		ctx.Dst = dst.(*image.RGBA)
+		return nil
+	}
+}
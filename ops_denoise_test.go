@@ -0,0 +1,132 @@
+package imgHelper
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// noisyImage 带有确定性噪声的彩色图像
+func noisyImage(r image.Rectangle) *image.NRGBA {
+	img := image.NewNRGBA(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			v := uint8((x*37 + y*91 + x*y*13) % 256)
+			img.SetNRGBA(x, y, color.NRGBA{R: v, G: 255 - v, B: uint8(x * 16), A: 255})
+		}
+	}
+	return img
+}
+
+func TestDenoiseInvalidParams(t *testing.T) {
+	src := noisyImage(image.Rect(0, 0, 8, 8))
+	cases := []struct {
+		name string
+		run  func() (image.Image, error)
+	}{
+		{"中值半径为0", func() (image.Image, error) { return MedianFilter(src, 0) }},
+		{"双边 sigmaSpace 为0", func() (image.Image, error) { return BilateralFilter(src, 0, 10) }},
+		{"双边 sigmaColor 为负", func() (image.Image, error) { return BilateralFilter(src, 2, -1) }},
+		{"非局部均值强度为0", func() (image.Image, error) { return NLMeansDenoise(src, 0, 1, 3) }},
+		{"非局部均值搜索半径为0", func() (image.Image, error) { return NLMeansDenoise(src, 10, 1, 0) }},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := c.run(); err == nil {
+				t.Error("want error")
+			}
+		})
+	}
+}
+
+func TestDenoiseKeepsUniformImage(t *testing.T) {
+	c := color.RGBA{R: 120, G: 60, B: 200, A: 255}
+	filters := []struct {
+		name string
+		run  func(img image.Image) (image.Image, error)
+	}{
+		{"MedianFilter r1", func(img image.Image) (image.Image, error) { return MedianFilter(img, 1) }},
+		{"MedianFilter r4", func(img image.Image) (image.Image, error) { return MedianFilter(img, 4) }},
+		{"BilateralFilter", func(img image.Image) (image.Image, error) { return BilateralFilter(img, 2, 20) }},
+		{"NLMeansDenoise", func(img image.Image) (image.Image, error) { return NLMeansDenoise(img, 10, 1, 3) }},
+	}
+	for _, f := range filters {
+		t.Run(f.name, func(t *testing.T) {
+			src := uniformRGBA(12, 10, c)
+			src.Rect = src.Rect.Add(image.Pt(5, -3))
+			dst, err := f.run(src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dst.Bounds() != src.Bounds() {
+				t.Fatalf("Bounds = %v, want %v", dst.Bounds(), src.Bounds())
+			}
+			for y := dst.Bounds().Min.Y; y < dst.Bounds().Max.Y; y++ {
+				for x := dst.Bounds().Min.X; x < dst.Bounds().Max.X; x++ {
+					if got := color.RGBAModel.Convert(dst.At(x, y)); got != c {
+						t.Fatalf("(%d, %d) = %v, want %v", x, y, got, c)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestMedianFilterRemovesImpulse(t *testing.T) {
+	for _, radius := range []int{1, 2, 3, 5} {
+		src := uniformRGBA(15, 15, color.RGBA{R: 100, G: 100, B: 100, A: 255})
+		src.SetRGBA(7, 7, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+		src.SetRGBA(3, 10, color.RGBA{A: 255})
+		dst, err := MedianFilter(src, radius)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range []image.Point{{7, 7}, {3, 10}} {
+			if got := color.RGBAModel.Convert(dst.At(p.X, p.Y)).(color.RGBA); got.R != 100 {
+				t.Errorf("radius %d: 噪点 %v = %v, want 100", radius, p, got)
+			}
+		}
+	}
+}
+
+func TestMedianFilterSortMatchesHistogram(t *testing.T) {
+	src := noisyImage(image.Rect(0, 0, 17, 13))
+	for _, radius := range []int{1, 2, 3} {
+		a := medianFilterSort(src, 17, 13, radius)
+		b := medianFilterHistogram(src, 17, 13, radius)
+		for i := range a.Pix {
+			if a.Pix[i] != b.Pix[i] {
+				t.Fatalf("radius %d: 像素 %d 通道 %d 排序结果 %d, 直方图结果 %d", radius, i/4, i%4, a.Pix[i], b.Pix[i])
+			}
+		}
+	}
+}
+
+func TestBilateralFilterKeepsEdge(t *testing.T) {
+	// 左黑右白的阶跃边缘，颜色差异远大于 sigmaColor，边缘两侧不应相互渗透
+	src := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 20; x++ {
+			v := uint8(0)
+			if x >= 10 {
+				v = 255
+			}
+			src.SetRGBA(x, y, color.RGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+	dst, err := BilateralFilter(src, 3, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for y := 0; y < 10; y++ {
+		l := color.RGBAModel.Convert(dst.At(9, y)).(color.RGBA)
+		r := color.RGBAModel.Convert(dst.At(10, y)).(color.RGBA)
+		if l.R > 2 || r.R < 253 {
+			t.Fatalf("行 %d: 边缘两侧为 %d, %d, want 0, 255", y, l.R, r.R)
+		}
+	}
+	// 作为对照，高斯模糊会模糊边缘
+	if g := GaussianBlur(src, 3).(*image.RGBA).RGBAAt(9, 5).R; g < 50 {
+		t.Errorf("对照的高斯模糊边缘值 %d 过小", g)
+	}
+}
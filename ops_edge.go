@@ -0,0 +1,231 @@
+package imgHelper
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// Gradient 图像梯度，记录每个像素在水平和垂直方向的梯度值
+type Gradient struct {
+	Width  int
+	Height int
+	Gx     []float64 // 水平方向梯度，按行存储
+	Gy     []float64 // 垂直方向梯度，按行存储
+	bounds image.Rectangle
+}
+
+// Magnitude 梯度幅值图，超过255的值会被截断
+func (g *Gradient) Magnitude() *image.Gray {
+	return planeToGray(g.magnitudePlane(), g.Width, g.Height, g.bounds)
+}
+
+// Direction 梯度方向图，将 [0,360) 度的方向线性映射到 0~255
+func (g *Gradient) Direction() *image.Gray {
+	plane := make([]float64, len(g.Gx))
+	for i := range plane {
+		angle := math.Atan2(g.Gy[i], g.Gx[i]) * 180 / math.Pi
+		if angle < 0 {
+			angle += 360
+		}
+		plane[i] = angle / 360 * 255
+	}
+	return planeToGray(plane, g.Width, g.Height, g.bounds)
+}
+
+// Angle 返回 (x,y) 处的梯度方向，单位为弧度，范围 (-π,π]，坐标相对于图像左上角
+func (g *Gradient) Angle(x, y int) float64 {
+	i := y*g.Width + x
+	return math.Atan2(g.Gy[i], g.Gx[i])
+}
+
+// magnitudePlane 计算梯度幅值
+func (g *Gradient) magnitudePlane() []float64 {
+	plane := make([]float64, len(g.Gx))
+	for i := range plane {
+		plane[i] = math.Hypot(g.Gx[i], g.Gy[i])
+	}
+	return plane
+}
+
+// SobelGradient 使用 Sobel 算子计算图像梯度
+func SobelGradient(src image.Image) *Gradient {
+	plane, w, h := grayPlane(src)
+	return gradient3x3(plane, w, h, 1, 2, src.Bounds())
+}
+
+// ScharrGradient 使用 Scharr 算子计算图像梯度，对方向的响应比 Sobel 更加各向同性
+func ScharrGradient(src image.Image) *Gradient {
+	plane, w, h := grayPlane(src)
+	return gradient3x3(plane, w, h, 3, 10, src.Bounds())
+}
+
+// Sobel 边缘检测，返回 Sobel 梯度幅值灰度图
+func Sobel(src image.Image) *image.Gray {
+	return SobelGradient(src).Magnitude()
+}
+
+// OpsSobel Sobel 边缘检测操作
+func OpsSobel() func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		ctx.Dst = imageToRGBA(Sobel(ctx.Dst))
+		return nil
+	}
+}
+
+// Scharr 边缘检测，返回 Scharr 梯度幅值灰度图
+func Scharr(src image.Image) *image.Gray {
+	return ScharrGradient(src).Magnitude()
+}
+
+// OpsScharr Scharr 边缘检测操作
+func OpsScharr() func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		ctx.Dst = imageToRGBA(Scharr(ctx.Dst))
+		return nil
+	}
+}
+
+// gradient3x3 使用形如 [-a,0,a; -b,0,b; -a,0,a] 的 3x3 算子计算梯度，边缘取最近像素
+func gradient3x3(plane []float64, w, h int, a, b float64, bounds image.Rectangle) *Gradient {
+	g := &Gradient{
+		Width:  w,
+		Height: h,
+		Gx:     make([]float64, w*h),
+		Gy:     make([]float64, w*h),
+		bounds: bounds,
+	}
+	at := func(x, y int) float64 {
+		return plane[clamp(y, 0, h-1)*w+clamp(x, 0, w-1)]
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			tl, tc, tr := at(x-1, y-1), at(x, y-1), at(x+1, y-1)
+			ml, mr := at(x-1, y), at(x+1, y)
+			bl, bc, br := at(x-1, y+1), at(x, y+1), at(x+1, y+1)
+			g.Gx[y*w+x] = a*(tr-tl) + b*(mr-ml) + a*(br-bl)
+			g.Gy[y*w+x] = a*(bl-tl) + b*(bc-tc) + a*(br-tr)
+		}
+	}
+	return g
+}
+
+// LaplacianOfGaussian 高斯拉普拉斯(LoG)边缘检测
+// 先高斯平滑抑制噪声再做拉普拉斯运算，返回响应的绝对值灰度图
+// 参数: sigma 高斯平滑的标准差，常用 1~3
+func LaplacianOfGaussian(src image.Image, sigma float64) *image.Gray {
+	plane, w, h := grayPlane(src)
+	plane = blurPlane(plane, w, h, sigma)
+	out := make([]float64, w*h)
+	at := func(x, y int) float64 {
+		return plane[clamp(y, 0, h-1)*w+clamp(x, 0, w-1)]
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			lap := at(x-1, y) + at(x+1, y) + at(x, y-1) + at(x, y+1) - 4*at(x, y)
+			out[y*w+x] = math.Abs(lap)
+		}
+	}
+	return planeToGray(out, w, h, src.Bounds())
+}
+
+// OpsLaplacianOfGaussian 高斯拉普拉斯边缘检测操作
+func OpsLaplacianOfGaussian(sigma float64) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		ctx.Dst = imageToRGBA(LaplacianOfGaussian(ctx.Dst, sigma))
+		return nil
+	}
+}
+
+// Canny Canny 边缘检测
+// 高斯平滑 -> Sobel 梯度 -> 非极大值抑制 -> 双阈值滞后连接，返回单像素宽的边缘图(边缘为255，其余为0)
+// 参数:
+// - low, high 滞后阈值，梯度幅值大于 high 的为强边缘，介于 low 和 high 之间且与强边缘相连的为弱边缘；low 为0时连接所有与强边缘相连的边缘点
+// - sigma 可选，高斯平滑的标准差，默认为1.4
+func Canny(src image.Image, low, high float64, sigma ...float64) (*image.Gray, error) {
+	if low < 0 || high <= 0 || high < low {
+		return nil, fmt.Errorf("阈值需满足 0 <= low <= high 且 high > 0, 当前为 low=%v high=%v", low, high)
+	}
+	s := 1.4
+	if len(sigma) > 0 {
+		s = sigma[0]
+	}
+	plane, w, h := grayPlane(src)
+	plane = blurPlane(plane, w, h, s)
+	g := gradient3x3(plane, w, h, 1, 2, src.Bounds())
+	mag := g.magnitudePlane()
+
+	// 非极大值抑制：只保留梯度方向上的局部最大值
+	nms := make([]float64, w*h)
+	for y := 1; y < h-1; y++ {
+		for x := 1; x < w-1; x++ {
+			i := y*w + x
+			m := mag[i]
+			if m == 0 {
+				continue
+			}
+			angle := math.Atan2(g.Gy[i], g.Gx[i]) * 180 / math.Pi
+			if angle < 0 {
+				angle += 180
+			}
+			var n1, n2 float64
+			switch {
+			case angle < 22.5 || angle >= 157.5: // 水平梯度，比较左右
+				n1, n2 = mag[i-1], mag[i+1]
+			case angle < 67.5: // 45度
+				n1, n2 = mag[i-w-1], mag[i+w+1]
+			case angle < 112.5: // 垂直梯度，比较上下
+				n1, n2 = mag[i-w], mag[i+w]
+			default: // 135度
+				n1, n2 = mag[i-w+1], mag[i+w-1]
+			}
+			if m >= n1 && m >= n2 {
+				nms[i] = m
+			}
+		}
+	}
+
+	// 双阈值滞后连接：从强边缘出发，沿8邻域连接弱边缘
+	dst := image.NewGray(image.Rect(src.Bounds().Min.X, src.Bounds().Min.Y, src.Bounds().Min.X+w, src.Bounds().Min.Y+h))
+	stack := make([]int, 0, 1024)
+	for i, m := range nms {
+		if m < high || dst.Pix[(i/w)*dst.Stride+i%w] == 255 {
+			continue
+		}
+		dst.Pix[(i/w)*dst.Stride+i%w] = 255
+		stack = append(stack, i)
+		for len(stack) > 0 {
+			cur := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			cx, cy := cur%w, cur/w
+			for _, n := range neighbors {
+				nx, ny := cx+n.X, cy+n.Y
+				if nx < 0 || nx >= w || ny < 0 || ny >= h {
+					continue
+				}
+				ni := ny*w + nx
+				// 非极大值抑制后为0的像素不是边缘，low 为0时也不连接
+				if nms[ni] > 0 && nms[ni] >= low && dst.Pix[ny*dst.Stride+nx] == 0 {
+					dst.Pix[ny*dst.Stride+nx] = 255
+					stack = append(stack, ni)
+				}
+			}
+		}
+	}
+	return dst, nil
+}
+
+// OpsCanny Canny 边缘检测操作
+// 参数:
+// - low, high 滞后阈值
+// - sigma 可选，高斯平滑的标准差，默认为1.4
+func OpsCanny(low, high float64, sigma ...float64) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		dst, err := Canny(ctx.Dst, low, high, sigma...)
+		if err != nil {
+			return err
+		}
+		ctx.Dst = imageToRGBA(dst)
+		return nil
+	}
+}
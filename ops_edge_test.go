@@ -0,0 +1,163 @@
+package imgHelper
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// squareImage 黑色背景上的白色正方形
+func squareImage(size int, square image.Rectangle) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, size, size))
+	for y := square.Min.Y; y < square.Max.Y; y++ {
+		for x := square.Min.X; x < square.Max.X; x++ {
+			img.SetGray(x, y, color.Gray{Y: 255})
+		}
+	}
+	return img
+}
+
+func TestCannyInvalidThreshold(t *testing.T) {
+	src := squareImage(20, image.Rect(5, 5, 15, 15))
+	cases := []struct {
+		name      string
+		low, high float64
+	}{
+		{"low为负", -1, 50},
+		{"high为0", 0, 0},
+		{"high小于low", 80, 40},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := Canny(src, c.low, c.high); err == nil {
+				t.Error("want error")
+			}
+		})
+	}
+}
+
+func TestCanny(t *testing.T) {
+	src := squareImage(40, image.Rect(10, 10, 30, 30))
+	cases := []struct {
+		name      string
+		low, high float64
+	}{
+		{"low为0", 0, 50},
+		{"常规阈值", 20, 50},
+		{"low等于high", 50, 50},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dst, err := Canny(src, c.low, c.high)
+			if err != nil {
+				t.Fatal(err)
+			}
+			edges := 0
+			for y := 0; y < 40; y++ {
+				for x := 0; x < 40; x++ {
+					if dst.GrayAt(x, y).Y == 0 {
+						continue
+					}
+					edges++
+					// 边缘只出现在正方形边界附近
+					if x > 12 && x < 27 && y > 12 && y < 27 || x < 7 || x > 32 || y < 7 || y > 32 {
+						t.Fatalf("(%d, %d) 不应为边缘", x, y)
+					}
+				}
+			}
+			// 单像素宽的边缘，数量接近正方形周长
+			if edges < 60 || edges > 120 {
+				t.Errorf("边缘像素数 %d, want 约80", edges)
+			}
+		})
+	}
+	// 纯色图像没有边缘
+	flat := image.NewGray(image.Rect(0, 0, 20, 20))
+	dst, err := Canny(flat, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range dst.Pix {
+		if v != 0 {
+			t.Fatal("纯色图像不应有边缘")
+		}
+	}
+}
+
+func TestGradient(t *testing.T) {
+	// 左半黑右半白的垂直边缘
+	src := image.NewGray(image.Rect(0, 0, 10, 6))
+	for y := 0; y < 6; y++ {
+		for x := 5; x < 10; x++ {
+			src.SetGray(x, y, color.Gray{Y: 100})
+		}
+	}
+	cases := []struct {
+		name string
+		g    *Gradient
+		peak float64
+	}{
+		{"Sobel", SobelGradient(src), 400},
+		{"Scharr", ScharrGradient(src), 1600},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			for y := 0; y < 6; y++ {
+				for x := 0; x < 10; x++ {
+					i := y*10 + x
+					wantGx := 0.0
+					if x == 4 || x == 5 {
+						wantGx = c.peak
+					}
+					if math.Abs(c.g.Gx[i]-wantGx) > 1e-9 || math.Abs(c.g.Gy[i]) > 1e-9 {
+						t.Fatalf("(%d, %d): Gx=%g Gy=%g, want Gx=%g Gy=0", x, y, c.g.Gx[i], c.g.Gy[i], wantGx)
+					}
+				}
+			}
+			if a := c.g.Angle(5, 3); a != 0 {
+				t.Errorf("Angle = %g, want 0", a)
+			}
+		})
+	}
+}
+
+func TestBlurPlane(t *testing.T) {
+	const w, h = 31, 31
+	cases := []struct {
+		name  string
+		sigma float64
+	}{
+		{"sigma为0时不变", 0},
+		{"sigma 1", 1},
+		{"sigma 2.5", 2.5},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			plane := make([]float64, w*h)
+			for i := range plane {
+				plane[i] = 10
+			}
+			plane[15*w+15] = 1010
+			out := blurPlane(plane, w, h, c.sigma)
+			// 冲激远离边缘，总量守恒
+			sum := 0.0
+			for _, v := range out {
+				sum += v
+			}
+			if math.Abs(sum-float64(w*h*10+1000)) > 1e-6 {
+				t.Errorf("总量 %g, want %d", sum, w*h*10+1000)
+			}
+			// 结果关于冲激位置对称
+			if d := math.Abs(out[15*w+13] - out[15*w+17]); d > 1e-9 {
+				t.Errorf("水平方向不对称: %g", d)
+			}
+			if d := math.Abs(out[13*w+15] - out[17*w+15]); d > 1e-9 {
+				t.Errorf("垂直方向不对称: %g", d)
+			}
+			if math.Abs(out[0]-10) > 1e-9 {
+				t.Errorf("远处的常数区域 = %g, want 10", out[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,359 @@
+package imgHelper
+
+import (
+	_ "embed"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"math"
+	"sort"
+	"sync"
+)
+
+// faceFinderData 内置的人脸检测模型，来自 github.com/esimov/pigo 的 cascade/facefinder (MIT 协议，见 model/LICENSE-facefinder)
+//
+//go:embed model/facefinder
+var faceFinderData []byte
+
+var defaultFaceCascade struct {
+	once    sync.Once
+	cascade *FaceCascade
+	err     error
+}
+
+// FaceCascade 人脸检测级联分类器，pico 格式：由像素对比较的决策树组成的级联，只需要灰度图，无需 GPU
+type FaceCascade struct {
+	depth      int       // 每棵树的深度
+	codes      []int8    // 每个节点比较的两个像素相对窗口中心的坐标(r1, c1, r2, c2)，单位为窗口大小的 1/256
+	preds      []float32 // 叶子节点的输出
+	thresholds []float32 // 每一级的拒绝阈值
+}
+
+// LoadFaceCascade 从 pico 格式的模型数据加载级联分类器，可用于替换内置的人脸模型
+func LoadFaceCascade(data []byte) (*FaceCascade, error) {
+	if len(data) < 16 {
+		return nil, fmt.Errorf("级联分类器数据长度不足")
+	}
+	// 前 8 字节为模型的版本等信息，随后是树的深度和数量
+	pos := 8
+	depth := int(binary.LittleEndian.Uint32(data[pos:]))
+	trees := int(binary.LittleEndian.Uint32(data[pos+4:]))
+	pos += 8
+	if depth <= 0 || depth > 16 {
+		return nil, fmt.Errorf("级联分类器的树深度 %d 无效", depth)
+	}
+	leaves := 1 << depth
+	treeSize := 4*(leaves-1) + 4*leaves + 4
+	if trees <= 0 || len(data)-pos < trees*treeSize {
+		return nil, fmt.Errorf("级联分类器数据不完整")
+	}
+
+	c := &FaceCascade{
+		depth:      depth,
+		codes:      make([]int8, 0, trees*4*leaves),
+		preds:      make([]float32, 0, trees*leaves),
+		thresholds: make([]float32, 0, trees),
+	}
+	for t := 0; t < trees; t++ {
+		// 节点从 1 开始编号，补齐第 0 个节点使下标与编号一致
+		c.codes = append(c.codes, 0, 0, 0, 0)
+		for _, b := range data[pos : pos+4*(leaves-1)] {
+			c.codes = append(c.codes, int8(b))
+		}
+		pos += 4 * (leaves - 1)
+		for i := 0; i < leaves; i++ {
+			c.preds = append(c.preds, math.Float32frombits(binary.LittleEndian.Uint32(data[pos:])))
+			pos += 4
+		}
+		c.thresholds = append(c.thresholds, math.Float32frombits(binary.LittleEndian.Uint32(data[pos:])))
+		pos += 4
+	}
+	return c, nil
+}
+
+// classify 对以(row, col)为中心、边长为 size 的窗口分类，返回值大于0表示是人脸，值越大置信度越高
+func (c *FaceCascade) classify(pixels []uint8, stride, row, col, size int) float32 {
+	leaves := 1 << c.depth
+	r, cc := row*256, col*256
+	var out float32
+	for t, threshold := range c.thresholds {
+		root := t * 4 * leaves
+		idx := 1
+		for j := 0; j < c.depth; j++ {
+			code := c.codes[root+4*idx : root+4*idx+4]
+			p1 := ((r+int(code[0])*size)>>8)*stride + (cc+int(code[1])*size)>>8
+			p2 := ((r+int(code[2])*size)>>8)*stride + (cc+int(code[3])*size)>>8
+			idx = 2 * idx
+			if pixels[p1] <= pixels[p2] {
+				idx++
+			}
+		}
+		out += c.preds[t*leaves+idx-leaves]
+		if out <= threshold {
+			return -1
+		}
+	}
+	return out - c.thresholds[len(c.thresholds)-1]
+}
+
+// FaceOptions 人脸检测参数，零值字段使用默认值
+type FaceOptions struct {
+	Cascade      *FaceCascade // 级联分类器，默认使用内置模型
+	MinSize      int          // 最小人脸尺寸，默认为20
+	MaxSize      int          // 最大人脸尺寸，默认为图像的短边
+	ShiftFactor  float64      // 检测窗口的移动步长与窗口大小之比，默认为0.1
+	ScaleFactor  float64      // 相邻两次检测的窗口大小之比，默认为1.1
+	IoUThreshold float64      // 重叠度超过该值的检测结果合并为一个，默认为0.2
+	MinQuality   float64      // 最低置信度，低于该值的结果丢弃，默认为5
+}
+
+// Face 检测到的人脸
+type Face struct {
+	Rect    Range   // 人脸所在的正方形范围
+	Quality float64 // 置信度
+}
+
+// faceDetection 检测窗口，(Row, Col)为中心，Size为边长
+type faceDetection struct {
+	Row, Col, Size int
+	Quality        float64
+}
+
+// DetectFaces 人脸检测，返回按置信度从高到低排序的人脸
+// 使用 pico 算法：在多个尺度上滑动窗口，用决策树级联判断窗口是否为正脸，再合并重叠的窗口
+func DetectFaces(src image.Image, opts FaceOptions) ([]Face, error) {
+	cascade := opts.Cascade
+	if cascade == nil {
+		defaultFaceCascade.once.Do(func() {
+			defaultFaceCascade.cascade, defaultFaceCascade.err = LoadFaceCascade(faceFinderData)
+		})
+		if defaultFaceCascade.err != nil {
+			return nil, defaultFaceCascade.err
+		}
+		cascade = defaultFaceCascade.cascade
+	}
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	minSize := opts.MinSize
+	if minSize <= 0 {
+		minSize = 20
+	}
+	maxSize := opts.MaxSize
+	if maxSize <= 0 {
+		maxSize = minValue(w, h)
+	}
+	shift := opts.ShiftFactor
+	if shift <= 0 {
+		shift = 0.1
+	}
+	scaleFactor := opts.ScaleFactor
+	if scaleFactor <= 1 {
+		scaleFactor = 1.1
+	}
+	iou := opts.IoUThreshold
+	if iou <= 0 {
+		iou = 0.2
+	}
+	minQuality := opts.MinQuality
+	if minQuality <= 0 {
+		minQuality = 5
+	}
+
+	nrgba := imageToNRGBA(src)
+	pixels := make([]uint8, w*h)
+	for i := range pixels {
+		o := i * 4
+		pixels[i] = luminance(nrgba.Pix[o], nrgba.Pix[o+1], nrgba.Pix[o+2])
+	}
+
+	var detections []faceDetection
+	for size := minSize; size <= maxSize; size = maxValue(size+1, int(float64(size)*scaleFactor)) {
+		step := maxValue(int(shift*float64(size)), 1)
+		offset := size/2 + 1
+		for row := offset; row <= h-offset; row += step {
+			for col := offset; col <= w-offset; col += step {
+				if q := cascade.classify(pixels, w, row, col, size); q > 0 {
+					detections = append(detections, faceDetection{Row: row, Col: col, Size: size, Quality: float64(q)})
+				}
+			}
+		}
+	}
+
+	var faces []Face
+	for _, d := range clusterFaceDetections(detections, iou) {
+		if d.Quality < minQuality {
+			continue
+		}
+		x0, y0 := bounds.Min.X+d.Col-d.Size/2, bounds.Min.Y+d.Row-d.Size/2
+		faces = append(faces, Face{
+			Rect:    Range{X0: x0, Y0: y0, X1: x0 + d.Size, Y1: y0 + d.Size},
+			Quality: d.Quality,
+		})
+	}
+	sort.SliceStable(faces, func(i, j int) bool {
+		return faces[i].Quality > faces[j].Quality
+	})
+	return faces, nil
+}
+
+// clusterFaceDetections 合并重叠的检测窗口：位置和大小取平均，置信度累加
+func clusterFaceDetections(detections []faceDetection, iouThreshold float64) []faceDetection {
+	sort.Slice(detections, func(i, j int) bool {
+		return detections[i].Quality > detections[j].Quality
+	})
+	assigned := make([]bool, len(detections))
+	var clusters []faceDetection
+	for i, d := range detections {
+		if assigned[i] {
+			continue
+		}
+		var row, col, size, n int
+		var quality float64
+		for j, o := range detections {
+			if assigned[j] || faceIoU(d, o) <= iouThreshold {
+				continue
+			}
+			assigned[j] = true
+			row += o.Row
+			col += o.Col
+			size += o.Size
+			quality += o.Quality
+			n++
+		}
+		clusters = append(clusters, faceDetection{Row: row / n, Col: col / n, Size: size / n, Quality: quality})
+	}
+	return clusters
+}
+
+// faceIoU 两个检测窗口的交并比
+func faceIoU(a, b faceDetection) float64 {
+	r1, c1, s1 := float64(a.Row), float64(a.Col), float64(a.Size)
+	r2, c2, s2 := float64(b.Row), float64(b.Col), float64(b.Size)
+	overRow := math.Max(0, math.Min(r1+s1/2, r2+s2/2)-math.Max(r1-s1/2, r2-s2/2))
+	overCol := math.Max(0, math.Min(c1+s1/2, c2+s2/2)-math.Max(c1-s1/2, c2-s2/2))
+	return overRow * overCol / (s1*s1 + s2*s2 - overRow*overCol)
+}
+
+// FaceRanges 返回检测到的人脸范围，可直接用于 OpsMosaic、OpsCrop 等
+func FaceRanges(src image.Image, opts FaceOptions) ([]Range, error) {
+	faces, err := DetectFaces(src, opts)
+	if err != nil {
+		return nil, err
+	}
+	ranges := make([]Range, len(faces))
+	for i, f := range faces {
+		ranges[i] = f.Rect
+	}
+	return ranges, nil
+}
+
+// MosaicFaces 人脸打码：检测图像中的人脸并对每张人脸进行马赛克
+// 参数:
+// - blockSize 马赛克块大小
+// - opts 人脸检测参数
+func MosaicFaces(src image.Image, blockSize int, opts FaceOptions) (image.Image, error) {
+	faces, err := DetectFaces(src, opts)
+	if err != nil {
+		return nil, err
+	}
+	dst := imageToRGBA(src)
+	bounds := dst.Bounds()
+	for _, f := range faces {
+		rect := image.Rect(f.Rect.X0, f.Rect.Y0, f.Rect.X1, f.Rect.Y1).Intersect(bounds)
+		dst = Mosaic(dst, rect.Min.X, rect.Min.Y, rect.Max.X, rect.Max.Y, blockSize).(*image.RGBA)
+	}
+	return dst, nil
+}
+
+// CropFaceCircle 人脸头像：以置信度最高的人脸为中心进行圆形裁剪
+// 参数:
+// - scale 圆的半径与人脸大小一半的比值，默认为1.5，使头像包含头发和下巴
+// - opts 人脸检测参数
+func CropFaceCircle(src image.Image, scale float64, opts FaceOptions) (image.Image, error) {
+	faces, err := DetectFaces(src, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(faces) == 0 {
+		return nil, fmt.Errorf("未检测到人脸")
+	}
+	if scale <= 0 {
+		scale = 1.5
+	}
+	rect := faces[0].Rect
+	r := int(math.Round(float64(rect.X1-rect.X0) / 2 * scale))
+	return CropCircle(src, (rect.X0+rect.X1)/2, (rect.Y0+rect.Y1)/2, r), nil
+}
+
+// CropFaceCenter 以人脸为中心裁剪：裁剪出 width*height 的区域，使所有人脸的外接矩形尽量位于中心，
+// 裁剪区域不超出图像；没有检测到人脸时以图像中心裁剪
+// 参数:
+// - width, height 裁剪区域的宽高，大于图像时取图像的宽高
+// - opts 人脸检测参数
+func CropFaceCenter(src image.Image, width, height int, opts FaceOptions) (image.Image, error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("裁剪宽高必须大于0")
+	}
+	faces, err := DetectFaces(src, opts)
+	if err != nil {
+		return nil, err
+	}
+	bounds := src.Bounds()
+	width, height = minValue(width, bounds.Dx()), minValue(height, bounds.Dy())
+	center := image.Pt((bounds.Min.X+bounds.Max.X)/2, (bounds.Min.Y+bounds.Max.Y)/2)
+	if len(faces) > 0 {
+		union := image.Rect(faces[0].Rect.X0, faces[0].Rect.Y0, faces[0].Rect.X1, faces[0].Rect.Y1)
+		for _, f := range faces[1:] {
+			union = union.Union(image.Rect(f.Rect.X0, f.Rect.Y0, f.Rect.X1, f.Rect.Y1))
+		}
+		center = image.Pt((union.Min.X+union.Max.X)/2, (union.Min.Y+union.Max.Y)/2)
+	}
+	x0 := clamp(center.X-width/2, bounds.Min.X, bounds.Max.X-width)
+	y0 := clamp(center.Y-height/2, bounds.Min.Y, bounds.Max.Y-height)
+	return Crop(src, x0, y0, x0+width, y0+height), nil
+}
+
+// OpsMosaicFaces 人脸打码操作
+// 参数:
+// - blockSize 马赛克块大小
+// - opts 人脸检测参数
+func OpsMosaicFaces(blockSize int, opts FaceOptions) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		dst, err := MosaicFaces(ctx.Dst, blockSize, opts)
+		if err != nil {
+			return err
+		}
+		ctx.Dst = dst.(*image.RGBA)
+		return nil
+	}
+}
+
+// OpsCropFaceCircle 人脸头像操作
+// 参数:
+// - scale 圆的半径与人脸大小一半的比值，默认为1.5
+// - opts 人脸检测参数
+func OpsCropFaceCircle(scale float64, opts FaceOptions) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		dst, err := CropFaceCircle(ctx.Dst, scale, opts)
+		if err != nil {
+			return err
+		}
+		ctx.Dst = dst.(*image.RGBA)
+		return nil
+	}
+}
+
+// OpsCropFaceCenter 以人脸为中心裁剪操作
+// 参数:
+// - width, height 裁剪区域的宽高
+// - opts 人脸检测参数
+func OpsCropFaceCenter(width, height int, opts FaceOptions) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		dst, err := CropFaceCenter(ctx.Dst, width, height, opts)
+		if err != nil {
+			return err
+		}
+		ctx.Dst = dst.(*image.RGBA)
+		return nil
+	}
+}
@@ -0,0 +1,133 @@
+package imgHelper
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// tinyCascade 只有一棵深度为1的树的级联分类器：窗口中心上方 size/4 处的像素比中心亮时判为人脸
+func tinyCascade() []byte {
+	data := make([]byte, 16, 32)
+	binary.LittleEndian.PutUint32(data[8:], 1)
+	binary.LittleEndian.PutUint32(data[12:], 1)
+	code := int8(-64)
+	data = append(data, byte(code), 0, 0, 0)
+	for _, v := range []float32{10, -10, 0} {
+		data = binary.LittleEndian.AppendUint32(data, math.Float32bits(v))
+	}
+	return data
+}
+
+func TestLoadFaceCascade(t *testing.T) {
+	c, err := LoadFaceCascade(faceFinderData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaves := 1 << c.depth
+	trees := len(c.thresholds)
+	if trees == 0 || len(c.codes) != trees*4*leaves || len(c.preds) != trees*leaves {
+		t.Errorf("深度 %d, %d 棵树, %d 个节点, %d 个叶子", c.depth, trees, len(c.codes)/4, len(c.preds))
+	}
+
+	tiny, err := LoadFaceCascade(tinyCascade())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tiny.depth != 1 || len(tiny.thresholds) != 1 || tiny.codes[4] != -64 || tiny.preds[0] != 10 {
+		t.Errorf("解析结果 %+v", tiny)
+	}
+
+	badDepth := tinyCascade()
+	binary.LittleEndian.PutUint32(badDepth[8:], 0)
+	cases := []struct {
+		name string
+		data []byte
+	}{
+		{"空数据", nil},
+		{"数据不完整", faceFinderData[:len(faceFinderData)-1]},
+		{"树深度无效", badDepth},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := LoadFaceCascade(c.data); err == nil {
+				t.Error("want error")
+			}
+		})
+	}
+}
+
+func TestDetectFacesFlatImage(t *testing.T) {
+	src := uniformRGBA(120, 90, color.RGBA{R: 128, G: 128, B: 128, A: 255})
+	faces, err := DetectFaces(src, FaceOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(faces) != 0 {
+		t.Errorf("纯色图像检测到 %d 张人脸", len(faces))
+	}
+	if _, err := CropFaceCircle(src, 0, FaceOptions{}); err == nil {
+		t.Error("没有人脸时 CropFaceCircle want error")
+	}
+	// 没有人脸时以图像中心裁剪
+	dst, err := CropFaceCenter(src, 40, 200, FaceOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dst.Bounds() != image.Rect(40, 0, 80, 90) {
+		t.Errorf("CropFaceCenter Bounds = %v, want (40,0)-(80,90)", dst.Bounds())
+	}
+}
+
+func TestDetectFacesSynthetic(t *testing.T) {
+	cascade, err := LoadFaceCascade(tinyCascade())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 黑色背景上的白色方块：方块下方的窗口满足"上亮下暗"
+	src := uniformRGBA(60, 60, color.RGBA{A: 255})
+	for y := 20; y < 30; y++ {
+		for x := 20; x < 40; x++ {
+			src.SetRGBA(x, y, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+		}
+	}
+	opts := FaceOptions{Cascade: cascade, MinSize: 20, MaxSize: 24}
+	faces, err := DetectFaces(src, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(faces) == 0 {
+		t.Fatal("未检测到人脸")
+	}
+	for i, f := range faces {
+		if f.Rect.X0 < 0 || f.Rect.Y0 < 0 || f.Rect.X1 > 60 || f.Rect.Y1 > 60 || f.Rect.X1-f.Rect.X0 != f.Rect.Y1-f.Rect.Y0 {
+			t.Errorf("人脸 %d 范围 %+v 无效", i, f.Rect)
+		}
+		if f.Quality < 5 || i > 0 && f.Quality > faces[i-1].Quality {
+			t.Errorf("人脸 %d 置信度 %g 无效或未排序", i, f.Quality)
+		}
+	}
+
+	// 非零原点：结果平移相同的距离
+	src.Rect = src.Rect.Add(image.Pt(7, -3))
+	shifted, err := DetectFaces(src, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shifted) != len(faces) {
+		t.Fatalf("非零原点检测到 %d 张人脸, want %d", len(shifted), len(faces))
+	}
+	for i, f := range shifted {
+		want := Range{X0: faces[i].Rect.X0 + 7, Y0: faces[i].Rect.Y0 - 3, X1: faces[i].Rect.X1 + 7, Y1: faces[i].Rect.Y1 - 3}
+		if f.Rect != want {
+			t.Errorf("人脸 %d 范围 %+v, want %+v", i, f.Rect, want)
+		}
+	}
+
+	// 纯色图像上同一个分类器不会误检
+	if faces, _ := DetectFaces(uniformRGBA(60, 60, color.RGBA{A: 255}), opts); len(faces) != 0 {
+		t.Errorf("纯色图像检测到 %d 张人脸", len(faces))
+	}
+}
@@ -0,0 +1,747 @@
+package imgHelper
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// 前景提取掩码的取值，用于 ForegroundOptions.Mask
+const (
+	MaskBackground         uint8 = 0   // 确定背景
+	MaskProbableBackground uint8 = 64  // 可能是背景，值在 1~127 之间均视为可能背景
+	MaskProbableForeground uint8 = 192 // 可能是前景，值在 128~254 之间均视为可能前景
+	MaskForeground         uint8 = 255 // 确定前景
+)
+
+// ForegroundOptions 前景提取参数，零值字段使用默认值
+type ForegroundOptions struct {
+	Rect       Range       // 前景所在的矩形，矩形外为确定背景，矩形内为可能前景；Mask 不为 nil 时忽略
+	Mask       *image.Gray // 粗略掩码，坐标与源图像一致，取值见 MaskBackground 等常量
+	Iterations int         // 迭代次数，默认为5
+	Components int         // 前景和背景颜色模型(高斯混合模型)的分量数，默认为5
+	Smoothness float64     // 平滑项权重，越大边缘越平滑、越不容易出现碎片，默认为50
+	Feather    float64     // 边缘羽化的高斯标准差，默认为1.5，小于0时不羽化
+}
+
+// ForegroundMask 前景提取，返回前景的 Alpha 掩码(0为背景，255为前景，边缘为羽化过渡)
+// 类似 GrabCut：分别用高斯混合模型描述前景和背景的颜色分布，结合颜色模型和相邻像素的平滑约束，用图割迭代优化前景区域
+// 大图会先在缩小的图像上分割，再在全分辨率下细化边界
+// 掩码可用作 RangeMask 进行裁剪或马赛克
+func ForegroundMask(src image.Image, opts ForegroundOptions) (*image.Gray, error) {
+	nrgba := imageToNRGBA(src)
+	bounds := src.Bounds()
+	w, h := nrgba.Bounds().Dx(), nrgba.Bounds().Dy()
+	n := w * h
+	if n == 0 {
+		return nil, fmt.Errorf("源图像为空")
+	}
+	iterations := opts.Iterations
+	if iterations <= 0 {
+		iterations = 5
+	}
+	components := opts.Components
+	if components <= 0 {
+		components = 5
+	}
+	smoothness := opts.Smoothness
+	if smoothness <= 0 {
+		smoothness = 50
+	}
+	feather := opts.Feather
+	if feather == 0 {
+		feather = 1.5
+	}
+
+	// trimap：0 确定背景，1 可能背景，2 可能前景，3 确定前景
+	trimap := make([]uint8, n)
+	if opts.Mask != nil {
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				v := opts.Mask.GrayAt(bounds.Min.X+x, bounds.Min.Y+y).Y
+				switch {
+				case v == MaskBackground:
+					trimap[y*w+x] = 0
+				case v < 128:
+					trimap[y*w+x] = 1
+				case v < MaskForeground:
+					trimap[y*w+x] = 2
+				default:
+					trimap[y*w+x] = 3
+				}
+			}
+		}
+	} else {
+		rect := image.Rect(opts.Rect.X0, opts.Rect.Y0, opts.Rect.X1, opts.Rect.Y1).Sub(bounds.Min).Intersect(image.Rect(0, 0, w, h))
+		if rect.Empty() {
+			return nil, fmt.Errorf("前景矩形 %+v 与源图像没有交集", opts.Rect)
+		}
+		for y := rect.Min.Y; y < rect.Max.Y; y++ {
+			for x := rect.Min.X; x < rect.Max.X; x++ {
+				trimap[y*w+x] = 2
+			}
+		}
+	}
+
+	colors := make([][3]float64, n)
+	hasFg, hasBg := false, false
+	for i := range colors {
+		o := i * 4
+		colors[i] = [3]float64{float64(nrgba.Pix[o]), float64(nrgba.Pix[o+1]), float64(nrgba.Pix[o+2])}
+		hasFg = hasFg || trimap[i] >= 2
+		hasBg = hasBg || trimap[i] < 2
+	}
+	if !hasFg || !hasBg {
+		return nil, fmt.Errorf("前景和背景区域都不能为空")
+	}
+
+	var fg []bool
+	if n > foregroundMaxPixels {
+		fg = coarseToFineCut(colors, trimap, w, h, iterations, components, smoothness)
+	} else {
+		fg = graphCutSegment(colors, trimap, w, h, iterations, components, smoothness)
+	}
+
+	plane := make([]float64, n)
+	for i, f := range fg {
+		if f {
+			plane[i] = 255
+		}
+	}
+	if feather > 0 {
+		plane = blurPlane(plane, w, h, feather)
+		// 确定背景和确定前景不受羽化影响
+		for i, t := range trimap {
+			if t == 0 {
+				plane[i] = 0
+			} else if t == 3 {
+				plane[i] = 255
+			}
+		}
+	}
+	return planeToGray(plane, w, h, bounds), nil
+}
+
+// foregroundMaxPixels 超过该像素数时先在缩小的图像上分割，再在全分辨率下只细化边界附近的像素
+const foregroundMaxPixels = 100000
+
+// graphCutSegment 迭代拟合颜色模型并图割，返回每个像素是否为前景
+// trimap：0 确定背景，1 可能背景，2 可能前景，3 确定前景
+func graphCutSegment(colors [][3]float64, trimap []uint8, w, h, iterations, components int, smoothness float64) []bool {
+	n := w * h
+	fg := make([]bool, n)
+	for i, t := range trimap {
+		fg[i] = t >= 2
+	}
+
+	// 相邻像素的平滑权重：颜色差异越大权重越小，使分割边界落在颜色变化大的地方
+	var sumDiff float64
+	var count int
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			for _, d := range neighbors[:4] {
+				nx, ny := x+d.X, y+d.Y
+				if nx < 0 || nx >= w || ny < 0 || ny >= h {
+					continue
+				}
+				sumDiff += colorDistSq(colors[y*w+x], colors[ny*w+nx])
+				count++
+			}
+		}
+	}
+	beta := 0.0
+	if sumDiff > 0 {
+		beta = 1 / (2 * sumDiff / float64(count))
+	}
+	// pairWeight[i*8+d] 为像素 i 与其 neighbors[d] 方向上相邻像素的平滑权重
+	pairWeight := make([]float32, n*8)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			for d, off := range neighbors {
+				nx, ny := x+off.X, y+off.Y
+				if nx < 0 || nx >= w || ny < 0 || ny >= h {
+					continue
+				}
+				dist := math.Hypot(float64(off.X), float64(off.Y))
+				pairWeight[(y*w+x)*8+d] = float32(smoothness / dist * math.Exp(-beta*colorDistSq(colors[y*w+x], colors[ny*w+nx])))
+			}
+		}
+	}
+
+	graph := newGridGraph(w, h)
+	terminal := make([]float32, n)
+	for iter := 0; iter < iterations; iter++ {
+		// 用当前的前景和背景像素分别拟合颜色模型
+		var fgSamples, bgSamples [][3]float64
+		for i, c := range colors {
+			if fg[i] {
+				fgSamples = append(fgSamples, c)
+			} else {
+				bgSamples = append(bgSamples, c)
+			}
+		}
+		if len(fgSamples) == 0 || len(bgSamples) == 0 {
+			break
+		}
+		fgModel := newColorGMM(fgSamples, components)
+		bgModel := newColorGMM(bgSamples, components)
+		// 颜色项：源点(前景)一侧的容量为标为背景的代价，汇点(背景)一侧为标为前景的代价，这里合并为两者之差
+		for i, c := range colors {
+			switch trimap[i] {
+			case 0:
+				terminal[i] = -math.MaxFloat32
+			case 3:
+				terminal[i] = math.MaxFloat32
+			default:
+				terminal[i] = float32(bgModel.negLogLikelihood(c) - fgModel.negLogLikelihood(c))
+			}
+		}
+		// 图割：求最小割得到 颜色项 + 平滑项 全局最小的前景区域
+		graph.reset(terminal, pairWeight)
+		graph.maxflow()
+		for i := range fg {
+			fg[i] = graph.isSource(i)
+		}
+	}
+	return fg
+}
+
+// coarseToFineCut 大图的分割：先在缩小到约 foregroundMaxPixels 像素的图像上完成迭代，
+// 再把结果放大回原尺寸，只把分割边界附近的像素作为未确定区域，在全分辨率下再做一次图割
+func coarseToFineCut(colors [][3]float64, trimap []uint8, w, h, iterations, components int, smoothness float64) []bool {
+	scale := math.Sqrt(float64(w*h) / foregroundMaxPixels)
+	sw, sh := maxValue(int(float64(w)/scale), 1), maxValue(int(float64(h)/scale), 1)
+	smallColors := make([][3]float64, sw*sh)
+	smallTrimap := make([]uint8, sw*sh)
+	for sy := 0; sy < sh; sy++ {
+		y0, y1 := sy*h/sh, (sy+1)*h/sh
+		for sx := 0; sx < sw; sx++ {
+			x0, x1 := sx*w/sw, (sx+1)*w/sw
+			// 颜色取块内均值，trimap 取块中心像素
+			var sum [3]float64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					c := colors[y*w+x]
+					sum[0] += c[0]
+					sum[1] += c[1]
+					sum[2] += c[2]
+				}
+			}
+			count := float64((y1 - y0) * (x1 - x0))
+			si := sy*sw + sx
+			smallColors[si] = [3]float64{sum[0] / count, sum[1] / count, sum[2] / count}
+			smallTrimap[si] = trimap[((y0+y1)/2)*w+(x0+x1)/2]
+		}
+	}
+	smallFg := graphCutSegment(smallColors, smallTrimap, sw, sh, iterations, components, smoothness)
+
+	// 放大回原尺寸，从分割边界出发向外扩展 band 个像素作为未确定区域
+	fg := make([]bool, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			fg[y*w+x] = smallFg[(y*sh/h)*sw+x*sw/w]
+		}
+	}
+	band := int(math.Ceil(scale)) + 1
+	dist := make([]int, w*h)
+	var queue []int
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			dist[i] = -1
+			for _, d := range neighbors4 {
+				nx, ny := x+d.X, y+d.Y
+				if nx >= 0 && nx < w && ny >= 0 && ny < h && fg[ny*w+nx] != fg[i] {
+					dist[i] = 0
+					queue = append(queue, i)
+					break
+				}
+			}
+		}
+	}
+	for head := 0; head < len(queue); head++ {
+		i := queue[head]
+		if dist[i] >= band {
+			continue
+		}
+		x, y := i%w, i/w
+		for _, d := range neighbors {
+			nx, ny := x+d.X, y+d.Y
+			if nx < 0 || nx >= w || ny < 0 || ny >= h || dist[ny*w+nx] >= 0 {
+				continue
+			}
+			dist[ny*w+nx] = dist[i] + 1
+			queue = append(queue, ny*w+nx)
+		}
+	}
+	refined := make([]uint8, w*h)
+	for i, t := range trimap {
+		switch {
+		case t == 0 || t == 3:
+			refined[i] = t
+		case dist[i] >= 0 && fg[i]:
+			refined[i] = 2
+		case dist[i] >= 0:
+			refined[i] = 1
+		case fg[i]:
+			refined[i] = 3
+		default:
+			refined[i] = 0
+		}
+	}
+	return graphCutSegment(colors, refined, w, h, 1, components, smoothness)
+}
+
+// ExtractForeground 前景提取(抠图)，返回背景透明的图像，边缘为羽化过渡
+// 参数:
+// - opts 前景提取参数，至少需要指定前景矩形 Rect 或粗略掩码 Mask
+func ExtractForeground(src image.Image, opts ForegroundOptions) (*image.NRGBA, error) {
+	mask, err := ForegroundMask(src, opts)
+	if err != nil {
+		return nil, err
+	}
+	dst := image.NewNRGBA(src.Bounds())
+	draw.Draw(dst, dst.Bounds(), src, src.Bounds().Min, draw.Src)
+	for y := 0; y < dst.Bounds().Dy(); y++ {
+		for x := 0; x < dst.Bounds().Dx(); x++ {
+			o := y*dst.Stride + x*4
+			m := uint32(mask.Pix[y*mask.Stride+x])
+			dst.Pix[o+3] = uint8((uint32(dst.Pix[o+3])*m + 127) / 255)
+		}
+	}
+	return dst, nil
+}
+
+// OpsExtractForeground 前景提取操作，背景变为透明
+func OpsExtractForeground(opts ForegroundOptions) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		dst, err := ExtractForeground(ctx.Dst, opts)
+		if err != nil {
+			return err
+		}
+		ctx.Dst = imageToRGBA(dst)
+		return nil
+	}
+}
+
+// OpsForegroundOnColor 前景提取后放到纯色背景的画布(NewColorCanvas)上，常用于电商白底图
+// 参数:
+// - opts 前景提取参数
+// - background 背景颜色
+func OpsForegroundOnColor(opts ForegroundOptions, background color.RGBA) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		cutout, err := ExtractForeground(ctx.Dst, opts)
+		if err != nil {
+			return err
+		}
+		bounds := ctx.Dst.Bounds()
+		// 画布坐标从 (0,0) 开始
+		cutout.Rect = cutout.Rect.Sub(bounds.Min)
+		canvas := NewColorCanvas(bounds.Dx(), bounds.Dy(), background)
+		canvas.AddLayer(NewImgLayer(cutout, Range{}))
+		ctx.Dst = canvas.Dst
+		return canvas.Err
+	}
+}
+
+// colorGMM RGB 颜色的高斯混合模型
+type colorGMM struct {
+	weights []float64
+	means   [][3]float64
+	inverse [][3][3]float64 // 协方差矩阵的逆
+	logNorm []float64       // log(权重) - 0.5*log(det(协方差))
+}
+
+// newColorGMM 用 k-means 将样本分为 k 类，每类拟合一个高斯分量
+func newColorGMM(samples [][3]float64, k int) *colorGMM {
+	k = minValue(k, len(samples))
+	// 初始中心均匀取自样本，k-means 迭代若干次
+	centers := make([][3]float64, k)
+	for c := range centers {
+		centers[c] = samples[c*len(samples)/k]
+	}
+	assign := make([]int, len(samples))
+	for iter := 0; iter < 5; iter++ {
+		for i, s := range samples {
+			best, bestDist := 0, math.Inf(1)
+			for c, center := range centers {
+				if d := colorDistSq(s, center); d < bestDist {
+					best, bestDist = c, d
+				}
+			}
+			assign[i] = best
+		}
+		sums := make([][4]float64, k)
+		for i, s := range samples {
+			sums[assign[i]][0] += s[0]
+			sums[assign[i]][1] += s[1]
+			sums[assign[i]][2] += s[2]
+			sums[assign[i]][3]++
+		}
+		for c, s := range sums {
+			if s[3] > 0 {
+				centers[c] = [3]float64{s[0] / s[3], s[1] / s[3], s[2] / s[3]}
+			}
+		}
+	}
+
+	gmm := &colorGMM{}
+	counts := make([]float64, k)
+	covs := make([][3][3]float64, k)
+	for i, s := range samples {
+		c := assign[i]
+		counts[c]++
+		for a := 0; a < 3; a++ {
+			for b := 0; b < 3; b++ {
+				covs[c][a][b] += (s[a] - centers[c][a]) * (s[b] - centers[c][b])
+			}
+		}
+	}
+	for c := 0; c < k; c++ {
+		if counts[c] == 0 {
+			continue
+		}
+		cov := covs[c]
+		for a := 0; a < 3; a++ {
+			for b := 0; b < 3; b++ {
+				cov[a][b] /= counts[c]
+			}
+			// 加上少量正则项，避免纯色区域的协方差矩阵不可逆
+			cov[a][a] += 0.01 * 255
+		}
+		inv, det := invert3x3(cov)
+		weight := counts[c] / float64(len(samples))
+		gmm.weights = append(gmm.weights, weight)
+		gmm.means = append(gmm.means, centers[c])
+		gmm.inverse = append(gmm.inverse, inv)
+		gmm.logNorm = append(gmm.logNorm, math.Log(weight)-0.5*math.Log(det))
+	}
+	return gmm
+}
+
+// negLogLikelihood 颜色在模型下的负对数似然(省略常数项)
+func (g *colorGMM) negLogLikelihood(c [3]float64) float64 {
+	// 使用 log-sum-exp 避免下溢
+	best := math.Inf(-1)
+	terms := make([]float64, len(g.means))
+	for k, mean := range g.means {
+		d := [3]float64{c[0] - mean[0], c[1] - mean[1], c[2] - mean[2]}
+		inv := g.inverse[k]
+		m := 0.0
+		for a := 0; a < 3; a++ {
+			for b := 0; b < 3; b++ {
+				m += d[a] * inv[a][b] * d[b]
+			}
+		}
+		terms[k] = g.logNorm[k] - 0.5*m
+		best = math.Max(best, terms[k])
+	}
+	sum := 0.0
+	for _, t := range terms {
+		sum += math.Exp(t - best)
+	}
+	return -(best + math.Log(sum))
+}
+
+// invert3x3 3x3 矩阵求逆，返回逆矩阵和行列式
+func invert3x3(m [3][3]float64) ([3][3]float64, float64) {
+	det := m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+		m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+		m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+	var inv [3][3]float64
+	inv[0][0] = (m[1][1]*m[2][2] - m[1][2]*m[2][1]) / det
+	inv[0][1] = (m[0][2]*m[2][1] - m[0][1]*m[2][2]) / det
+	inv[0][2] = (m[0][1]*m[1][2] - m[0][2]*m[1][1]) / det
+	inv[1][0] = (m[1][2]*m[2][0] - m[1][0]*m[2][2]) / det
+	inv[1][1] = (m[0][0]*m[2][2] - m[0][2]*m[2][0]) / det
+	inv[1][2] = (m[0][2]*m[1][0] - m[0][0]*m[1][2]) / det
+	inv[2][0] = (m[1][0]*m[2][1] - m[1][1]*m[2][0]) / det
+	inv[2][1] = (m[0][1]*m[2][0] - m[0][0]*m[2][1]) / det
+	inv[2][2] = (m[0][0]*m[1][1] - m[0][1]*m[1][0]) / det
+	return inv, det
+}
+
+// colorDistSq RGB 颜色距离的平方
+func colorDistSq(a, b [3]float64) float64 {
+	d0, d1, d2 := a[0]-b[0], a[1]-b[1], a[2]-b[2]
+	return d0*d0 + d1*d1 + d2*d2
+}
+
+// 图割使用的节点状态
+const (
+	graphNone     int8 = -1 // 不属于任何搜索树
+	graphTerminal int8 = -2 // 直接与源点或汇点相连的树根
+	graphOrphan   int8 = -3 // 失去父节点的孤儿节点
+)
+
+// gridGraph 8邻域像素网格上的最大流/最小割 (Boykov-Kolmogorov 算法)
+// 节点 i 沿 neighbors[d] 方向的边的剩余容量存放在 capacity[i*8+d]，反向边为相邻节点的 (d+4)%8 方向
+type gridGraph struct {
+	w, h     int
+	offset   [8]int // 各方向相邻节点的下标偏移
+	capacity []float32
+	terminal []float32 // 大于0为源点到节点的剩余容量，小于0为节点到汇点的剩余容量
+	parent   []int8    // 指向父节点的方向，或 graphNone 等状态
+	sink     []bool    // 是否属于汇点树
+	ts       []int32   // 距离信息的时间戳
+	dist     []int32   // 到树根的距离
+	active   []bool
+	queue    []int
+	orphans  []int
+	time     int32
+}
+
+func newGridGraph(w, h int) *gridGraph {
+	n := w * h
+	g := &gridGraph{
+		w:        w,
+		h:        h,
+		capacity: make([]float32, n*8),
+		terminal: make([]float32, n),
+		parent:   make([]int8, n),
+		sink:     make([]bool, n),
+		ts:       make([]int32, n),
+		dist:     make([]int32, n),
+		active:   make([]bool, n),
+	}
+	for d, p := range neighbors {
+		g.offset[d] = p.Y*w + p.X
+	}
+	return g
+}
+
+// reset 设置源汇容量和边容量，并将与源点或汇点相连的节点作为两棵搜索树的根
+func (g *gridGraph) reset(terminal, capacity []float32) {
+	copy(g.terminal, terminal)
+	copy(g.capacity, capacity)
+	g.queue = g.queue[:0]
+	g.orphans = g.orphans[:0]
+	g.time = 0
+	for i, t := range g.terminal {
+		g.active[i] = false
+		g.ts[i], g.dist[i] = 0, 1
+		if t == 0 {
+			g.parent[i] = graphNone
+			continue
+		}
+		g.parent[i] = graphTerminal
+		g.sink[i] = t < 0
+		g.activate(i)
+	}
+}
+
+// isSource 最小割后节点是否属于源点一侧
+func (g *gridGraph) isSource(i int) bool {
+	return g.parent[i] != graphNone && !g.sink[i]
+}
+
+// neighbor 返回节点 i 沿 d 方向的相邻节点，(x,y) 为节点 i 的坐标
+func (g *gridGraph) neighbor(i, x, y int, d int8) (int, bool) {
+	nx, ny := x+neighbors[d].X, y+neighbors[d].Y
+	if nx < 0 || nx >= g.w || ny < 0 || ny >= g.h {
+		return 0, false
+	}
+	return i + g.offset[d], true
+}
+
+func (g *gridGraph) activate(i int) {
+	if !g.active[i] {
+		g.active[i] = true
+		g.queue = append(g.queue, i)
+	}
+}
+
+// maxflow 计算最大流，完成后源点树中的节点即为最小割的源点一侧
+func (g *gridGraph) maxflow() {
+	for head := 0; head < len(g.queue); head++ {
+		i := g.queue[head]
+		g.active[i] = false
+		if g.parent[i] == graphNone {
+			continue
+		}
+		// 扩展搜索树，直到两棵树相遇找到增广路径
+		from, dir := -1, int8(0)
+		x, y := i%g.w, i/g.w
+		for d := int8(0); d < 8; d++ {
+			j, ok := g.neighbor(i, x, y, d)
+			if !ok {
+				continue
+			}
+			opp := (d + 4) % 8
+			// 源点树沿 i->j 方向扩展，汇点树沿 j->i 方向扩展
+			var residual float32
+			if g.sink[i] {
+				residual = g.capacity[j*8+int(opp)]
+			} else {
+				residual = g.capacity[i*8+int(d)]
+			}
+			if residual <= 0 {
+				continue
+			}
+			if g.parent[j] == graphNone {
+				g.sink[j] = g.sink[i]
+				g.parent[j] = opp
+				g.ts[j], g.dist[j] = g.ts[i], g.dist[i]+1
+				g.activate(j)
+			} else if g.sink[j] != g.sink[i] {
+				if g.sink[i] {
+					from, dir = j, opp
+				} else {
+					from, dir = i, d
+				}
+				break
+			} else if g.ts[j] <= g.ts[i] && g.dist[j] > g.dist[i] {
+				// 选择离树根更近的父节点
+				g.parent[j] = opp
+				g.ts[j], g.dist[j] = g.ts[i], g.dist[i]+1
+			}
+		}
+		if from < 0 {
+			continue
+		}
+		g.time++
+		g.activate(i)
+		g.augment(from, dir)
+		g.adoptOrphans()
+		// 压缩队列，避免长时间运行时队列无限增长
+		if head > 4096 && head*2 > len(g.queue) {
+			g.queue = append(g.queue[:0], g.queue[head+1:]...)
+			head = -1
+		}
+	}
+}
+
+// augment 沿经过边 from->dir 的路径增广，容量饱和的树边的子节点成为孤儿
+func (g *gridGraph) augment(from int, dir int8) {
+	to := from + g.offset[dir]
+	bottleneck := g.capacity[from*8+int(dir)]
+	// 源点一侧：父节点 -> 子节点的边
+	k := from
+	for g.parent[k] != graphTerminal {
+		p := k + g.offset[g.parent[k]]
+		bottleneck = min(bottleneck, g.capacity[p*8+int((g.parent[k]+4)%8)])
+		k = p
+	}
+	bottleneck = min(bottleneck, g.terminal[k])
+	// 汇点一侧：子节点 -> 父节点的边
+	k = to
+	for g.parent[k] != graphTerminal {
+		bottleneck = min(bottleneck, g.capacity[k*8+int(g.parent[k])])
+		k += g.offset[g.parent[k]]
+	}
+	bottleneck = min(bottleneck, -g.terminal[k])
+
+	g.capacity[from*8+int(dir)] -= bottleneck
+	g.capacity[to*8+int((dir+4)%8)] += bottleneck
+	k = from
+	for g.parent[k] != graphTerminal {
+		d := g.parent[k]
+		p := k + g.offset[d]
+		g.capacity[p*8+int((d+4)%8)] -= bottleneck
+		g.capacity[k*8+int(d)] += bottleneck
+		if g.capacity[p*8+int((d+4)%8)] <= 0 {
+			g.parent[k] = graphOrphan
+			g.orphans = append(g.orphans, k)
+		}
+		k = p
+	}
+	g.terminal[k] -= bottleneck
+	if g.terminal[k] <= 0 {
+		g.parent[k] = graphOrphan
+		g.orphans = append(g.orphans, k)
+	}
+	k = to
+	for g.parent[k] != graphTerminal {
+		d := g.parent[k]
+		p := k + g.offset[d]
+		g.capacity[k*8+int(d)] -= bottleneck
+		g.capacity[p*8+int((d+4)%8)] += bottleneck
+		if g.capacity[k*8+int(d)] <= 0 {
+			g.parent[k] = graphOrphan
+			g.orphans = append(g.orphans, k)
+		}
+		k = p
+	}
+	g.terminal[k] += bottleneck
+	if g.terminal[k] >= 0 {
+		g.parent[k] = graphOrphan
+		g.orphans = append(g.orphans, k)
+	}
+}
+
+// adoptOrphans 为孤儿节点在同一棵树中寻找新的父节点，找不到时该节点变为自由节点
+func (g *gridGraph) adoptOrphans() {
+	for len(g.orphans) > 0 {
+		i := g.orphans[0]
+		g.orphans = g.orphans[1:]
+		best, bestDist := int8(-1), int32(math.MaxInt32)
+		x, y := i%g.w, i/g.w
+		for d := int8(0); d < 8; d++ {
+			j, ok := g.neighbor(i, x, y, d)
+			if !ok || g.parent[j] == graphNone || g.sink[j] != g.sink[i] || !g.treeResidual(i, j, d) {
+				continue
+			}
+			// 检查 j 是否仍连接到树根，并计算到树根的距离
+			dist := int32(0)
+			k := j
+			for {
+				if g.ts[k] == g.time {
+					dist += g.dist[k]
+					break
+				}
+				p := g.parent[k]
+				dist++
+				if p == graphTerminal {
+					g.ts[k], g.dist[k] = g.time, 1
+					break
+				}
+				if p == graphOrphan {
+					dist = math.MaxInt32
+					break
+				}
+				k += g.offset[p]
+			}
+			if dist == math.MaxInt32 {
+				continue
+			}
+			if dist < bestDist {
+				best, bestDist = d, dist
+			}
+			// 记录路径上节点的距离，加速后续查找
+			for k := j; g.ts[k] != g.time; k += g.offset[g.parent[k]] {
+				g.ts[k], g.dist[k] = g.time, dist
+				dist--
+			}
+		}
+		if best >= 0 {
+			g.parent[i] = best
+			g.ts[i], g.dist[i] = g.time, bestDist+1
+			continue
+		}
+		// 没有找到新的父节点：i 变为自由节点，相邻节点重新激活，以 i 为父节点的节点成为孤儿
+		g.parent[i] = graphNone
+		for d := int8(0); d < 8; d++ {
+			j, ok := g.neighbor(i, x, y, d)
+			if !ok || g.parent[j] == graphNone || g.sink[j] != g.sink[i] {
+				continue
+			}
+			if g.treeResidual(i, j, d) {
+				g.activate(j)
+			}
+			if p := g.parent[j]; p >= 0 && p == (d+4)%8 {
+				g.parent[j] = graphOrphan
+				g.orphans = append(g.orphans, j)
+			}
+		}
+	}
+}
+
+// treeResidual 同一棵树中 j 能否作为 i 的父节点：源点树需要 j->i 有剩余容量，汇点树需要 i->j 有剩余容量
+func (g *gridGraph) treeResidual(i, j int, d int8) bool {
+	if g.sink[i] {
+		return g.capacity[i*8+int(d)] > 0
+	}
+	return g.capacity[j*8+int((d+4)%8)] > 0
+}
@@ -0,0 +1,129 @@
+package imgHelper
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// seededImage 背景色上画一个前景色矩形
+func seededImage(w, h int, fg image.Rectangle) *image.RGBA {
+	img := uniformRGBA(w, h, color.RGBA{R: 240, G: 235, B: 220, A: 255})
+	for y := fg.Min.Y; y < fg.Max.Y; y++ {
+		for x := fg.Min.X; x < fg.Max.X; x++ {
+			img.SetRGBA(x, y, color.RGBA{R: 200, G: 30, B: 40, A: 255})
+		}
+	}
+	return img
+}
+
+func TestForegroundMask(t *testing.T) {
+	roughMask := image.NewGray(image.Rect(0, 0, 40, 30))
+	for y := 4; y < 26; y++ {
+		for x := 5; x < 35; x++ {
+			roughMask.SetGray(x, y, color.Gray{Y: MaskProbableForeground})
+		}
+	}
+	roughMask.SetGray(20, 15, color.Gray{Y: MaskForeground})
+	cases := []struct {
+		name string
+		src  *image.RGBA
+		fg   image.Rectangle
+		opts ForegroundOptions
+	}{
+		{"矩形", seededImage(40, 30, image.Rect(12, 8, 28, 22)), image.Rect(12, 8, 28, 22),
+			ForegroundOptions{Rect: Range{X0: 5, Y0: 4, X1: 35, Y1: 26}, Feather: -1}},
+		{"粗略掩码", seededImage(40, 30, image.Rect(12, 8, 28, 22)), image.Rect(12, 8, 28, 22),
+			ForegroundOptions{Mask: roughMask, Feather: -1}},
+		// 超过 foregroundMaxPixels 时先在缩小的图像上分割
+		{"大图", seededImage(400, 300, image.Rect(120, 80, 280, 220)), image.Rect(120, 80, 280, 220),
+			ForegroundOptions{Rect: Range{X0: 60, Y0: 40, X1: 340, Y1: 260}, Feather: -1}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mask, err := ForegroundMask(c.src, c.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mask.Bounds() != c.src.Bounds() {
+				t.Fatalf("Bounds = %v, want %v", mask.Bounds(), c.src.Bounds())
+			}
+			wrong := 0
+			for y := mask.Rect.Min.Y; y < mask.Rect.Max.Y; y++ {
+				for x := mask.Rect.Min.X; x < mask.Rect.Max.X; x++ {
+					want := uint8(0)
+					if image.Pt(x, y).In(c.fg) {
+						want = 255
+					}
+					if mask.GrayAt(x, y).Y != want {
+						wrong++
+					}
+				}
+			}
+			if wrong > 0 {
+				t.Errorf("%d 个像素与前景矩形 %v 不一致", wrong, c.fg)
+			}
+		})
+	}
+}
+
+func TestForegroundMaskFeather(t *testing.T) {
+	src := seededImage(40, 30, image.Rect(12, 8, 28, 22))
+	mask, err := ForegroundMask(src, ForegroundOptions{Rect: Range{X0: 5, Y0: 4, X1: 35, Y1: 26}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 默认羽化：边缘为过渡值，矩形外的确定背景仍为0
+	if v := mask.GrayAt(12, 15).Y; v == 0 || v == 255 {
+		t.Errorf("边缘像素 %d, want 过渡值", v)
+	}
+	if v := mask.GrayAt(20, 15).Y; v != 255 {
+		t.Errorf("前景中心 %d, want 255", v)
+	}
+	if v := mask.GrayAt(4, 15).Y; v != 0 {
+		t.Errorf("矩形外 %d, want 0", v)
+	}
+}
+
+func TestExtractForeground(t *testing.T) {
+	src := seededImage(40, 30, image.Rect(12, 8, 28, 22))
+	src.Rect = src.Rect.Add(image.Pt(100, 50))
+	dst, err := ExtractForeground(src, ForegroundOptions{Rect: Range{X0: 105, Y0: 54, X1: 135, Y1: 76}, Feather: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dst.Bounds() != src.Bounds() {
+		t.Fatalf("Bounds = %v, want %v", dst.Bounds(), src.Bounds())
+	}
+	if got := dst.NRGBAAt(120, 65); got != (color.NRGBA{R: 200, G: 30, B: 40, A: 255}) {
+		t.Errorf("前景像素 %v", got)
+	}
+	if got := dst.NRGBAAt(101, 51).A; got != 0 {
+		t.Errorf("背景 Alpha %d, want 0", got)
+	}
+}
+
+func TestForegroundMaskInvalid(t *testing.T) {
+	src := seededImage(20, 20, image.Rect(5, 5, 15, 15))
+	full := image.NewGray(src.Bounds())
+	for i := range full.Pix {
+		full.Pix[i] = MaskForeground
+	}
+	cases := []struct {
+		name string
+		src  image.Image
+		opts ForegroundOptions
+	}{
+		{"空图像", image.NewRGBA(image.Rect(0, 0, 0, 0)), ForegroundOptions{Rect: Range{X1: 1, Y1: 1}}},
+		{"矩形与图像没有交集", src, ForegroundOptions{Rect: Range{X0: 30, Y0: 30, X1: 40, Y1: 40}}},
+		{"矩形覆盖整个图像", src, ForegroundOptions{Rect: Range{X1: 20, Y1: 20}}},
+		{"掩码全为前景", src, ForegroundOptions{Mask: full}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := ForegroundMask(c.src, c.opts); err == nil {
+				t.Error("want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,317 @@
+package imgHelper
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// Histogram 图像直方图，分别统计 R,G,B,A 通道与亮度(Luma)在 0~255 上的像素数量
+type Histogram struct {
+	R     [256]int
+	G     [256]int
+	B     [256]int
+	A     [256]int
+	Luma  [256]int // 亮度直方图，亮度 = 0.299R + 0.587G + 0.114B
+	Total int      // 参与统计的像素总数
+}
+
+// ComputeHistogram 计算图像的各通道直方图与亮度直方图
+func ComputeHistogram(src image.Image) *Histogram {
+	nrgba := imageToNRGBA(src)
+	hist := &Histogram{}
+	for i := 0; i+3 < len(nrgba.Pix); i += 4 {
+		r, g, b, a := nrgba.Pix[i], nrgba.Pix[i+1], nrgba.Pix[i+2], nrgba.Pix[i+3]
+		hist.R[r]++
+		hist.G[g]++
+		hist.B[b]++
+		hist.A[a]++
+		hist.Luma[luminance(r, g, b)]++
+		hist.Total++
+	}
+	return hist
+}
+
+// CDF 返回亮度直方图的累积分布
+func (hist *Histogram) CDF() [256]int {
+	var cdf [256]int
+	sum := 0
+	for i := 0; i < 256; i++ {
+		sum += hist.Luma[i]
+		cdf[i] = sum
+	}
+	return cdf
+}
+
+// Mean 返回亮度均值
+func (hist *Histogram) Mean() float64 {
+	if hist.Total == 0 {
+		return 0
+	}
+	sum := 0
+	for i := 0; i < 256; i++ {
+		sum += i * hist.Luma[i]
+	}
+	return float64(sum) / float64(hist.Total)
+}
+
+// Chart 将直方图渲染成图像，白色背景，灰色填充为亮度直方图，红绿蓝折线为各通道直方图
+// 参数: width, height 图表的宽高
+func (hist *Histogram) Chart(width, height int) image.Image {
+	if width < 1 {
+		width = 256
+	}
+	if height < 1 {
+		height = 100
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), &image.Uniform{C: color.RGBA{R: 255, G: 255, B: 255, A: 255}}, image.Point{}, draw.Src)
+
+	maxCount := 1
+	for i := 0; i < 256; i++ {
+		maxCount = maxValue(maxCount, hist.R[i], hist.G[i], hist.B[i], hist.Luma[i])
+	}
+	// 将 bin 的数量映射到图表中的 y 坐标
+	toY := func(count int) int {
+		return height - 1 - int(float64(count)/float64(maxCount)*float64(height-1))
+	}
+	// 将图表的 x 坐标映射到 bin
+	toBin := func(x int) int {
+		return clamp(x*256/width, 0, 255)
+	}
+
+	// 亮度直方图用灰色填充
+	lumaColor := color.RGBA{R: 180, G: 180, B: 180, A: 255}
+	for x := 0; x < width; x++ {
+		for y := toY(hist.Luma[toBin(x)]); y < height; y++ {
+			dst.SetRGBA(x, y, lumaColor)
+		}
+	}
+
+	// 通道直方图用折线绘制
+	channels := []struct {
+		bins *[256]int
+		c    color.RGBA
+	}{
+		{&hist.R, color.RGBA{R: 255, A: 255}},
+		{&hist.G, color.RGBA{G: 200, A: 255}},
+		{&hist.B, color.RGBA{B: 255, A: 255}},
+	}
+	for _, ch := range channels {
+		prevY := toY(ch.bins[toBin(0)])
+		for x := 0; x < width; x++ {
+			y := toY(ch.bins[toBin(x)])
+			y0, y1 := minValue(prevY, y), maxValue(prevY, y)
+			for yy := y0; yy <= y1; yy++ {
+				setPixel(dst, x, yy, ch.c, 255)
+			}
+			prevY = y
+		}
+	}
+	return dst
+}
+
+// HistogramChart 计算图像直方图并渲染成图像
+// 参数: width, height 图表的宽高
+func HistogramChart(src image.Image, width, height int) image.Image {
+	return ComputeHistogram(src).Chart(width, height)
+}
+
+// OpsHistogramChart 将画布替换为当前画布的直方图图表
+func OpsHistogramChart(width, height int) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		ctx.Dst = HistogramChart(ctx.Dst, width, height).(*image.RGBA)
+		return nil
+	}
+}
+
+// EqualizeHist 直方图均衡化
+// 在 YCbCr 颜色空间中只对亮度通道做均衡化，保持色彩不偏移，透明度保持不变
+func EqualizeHist(src image.Image) image.Image {
+	bounds := src.Bounds()
+	nrgba := imageToNRGBA(src)
+	w, h := nrgba.Bounds().Dx(), nrgba.Bounds().Dy()
+	yPlane, cbPlane, crPlane := splitYCbCr(nrgba)
+
+	var hist [256]int
+	for _, v := range yPlane {
+		hist[v]++
+	}
+	lut := equalizeLUT(hist, w*h)
+	for i, v := range yPlane {
+		yPlane[i] = lut[v]
+	}
+
+	mergeYCbCr(nrgba, yPlane, cbPlane, crPlane)
+	return nrgbaToRGBA(nrgba, bounds)
+}
+
+// OpsEqualizeHist 直方图均衡化操作
+func OpsEqualizeHist() func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		ctx.Dst = EqualizeHist(ctx.Dst).(*image.RGBA)
+		return nil
+	}
+}
+
+// CLAHE 限制对比度的自适应直方图均衡化 (Contrast Limited Adaptive Histogram Equalization)
+// 将图像划分为若干网格块，每块单独做限制对比度的均衡化，块与块之间双线性插值避免出现块状边界
+// 参数:
+// - tileSize 网格块的边长（像素），常用 8~64
+// - clipLimit 对比度限制系数，越大对比度越强，常用 2~4；小于等于0时不做限制（退化为自适应直方图均衡化）
+func CLAHE(src image.Image, tileSize int, clipLimit float64) (image.Image, error) {
+	if tileSize < 2 {
+		return nil, fmt.Errorf("tileSize 必须大于等于2, 当前为 %d", tileSize)
+	}
+	bounds := src.Bounds()
+	nrgba := imageToNRGBA(src)
+	w, h := nrgba.Bounds().Dx(), nrgba.Bounds().Dy()
+	if w == 0 || h == 0 {
+		return nrgbaToRGBA(nrgba, bounds), nil
+	}
+	yPlane, cbPlane, crPlane := splitYCbCr(nrgba)
+
+	tilesX := (w + tileSize - 1) / tileSize
+	tilesY := (h + tileSize - 1) / tileSize
+
+	// 计算每个网格块的映射表
+	luts := make([][256]uint8, tilesX*tilesY)
+	for ty := 0; ty < tilesY; ty++ {
+		for tx := 0; tx < tilesX; tx++ {
+			x0, y0 := tx*tileSize, ty*tileSize
+			x1, y1 := minValue(x0+tileSize, w), minValue(y0+tileSize, h)
+			var hist [256]int
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					hist[yPlane[y*w+x]]++
+				}
+			}
+			area := (x1 - x0) * (y1 - y0)
+			if clipLimit > 0 {
+				clipHistogram(&hist, maxValue(1, int(clipLimit*float64(area)/256)))
+			}
+			luts[ty*tilesX+tx] = equalizeLUT(hist, area)
+		}
+	}
+
+	// 以网格块中心为插值节点做双线性插值
+	tileCoord := func(p, tiles int) (int, int, float64) {
+		f := (float64(p)+0.5)/float64(tileSize) - 0.5
+		i0 := int(math.Floor(f))
+		wt := f - float64(i0)
+		if i0 < 0 {
+			return 0, 0, 0
+		}
+		if i0 >= tiles-1 {
+			return tiles - 1, tiles - 1, 0
+		}
+		return i0, i0 + 1, wt
+	}
+	for y := 0; y < h; y++ {
+		ty0, ty1, wy := tileCoord(y, tilesY)
+		for x := 0; x < w; x++ {
+			tx0, tx1, wx := tileCoord(x, tilesX)
+			v := yPlane[y*w+x]
+			v00 := float64(luts[ty0*tilesX+tx0][v])
+			v01 := float64(luts[ty0*tilesX+tx1][v])
+			v10 := float64(luts[ty1*tilesX+tx0][v])
+			v11 := float64(luts[ty1*tilesX+tx1][v])
+			top := v00*(1-wx) + v01*wx
+			bottom := v10*(1-wx) + v11*wx
+			yPlane[y*w+x] = uint8(clamp(math.Round(top*(1-wy)+bottom*wy), 0, 255))
+		}
+	}
+
+	mergeYCbCr(nrgba, yPlane, cbPlane, crPlane)
+	return nrgbaToRGBA(nrgba, bounds), nil
+}
+
+// OpsCLAHE 限制对比度的自适应直方图均衡化操作
+// 参数:
+// - tileSize 网格块的边长（像素）
+// - clipLimit 对比度限制系数
+func OpsCLAHE(tileSize int, clipLimit float64) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		dst, err := CLAHE(ctx.Dst, tileSize, clipLimit)
+		if err != nil {
+			return err
+		}
+		ctx.Dst = dst.(*image.RGBA)
+		return nil
+	}
+}
+
+// equalizeLUT 根据直方图计算均衡化映射表
+func equalizeLUT(hist [256]int, total int) [256]uint8 {
+	var lut [256]uint8
+	if total <= 0 {
+		for i := range lut {
+			lut[i] = uint8(i)
+		}
+		return lut
+	}
+	// 跳过第一个非零 bin，使最暗的像素映射到0
+	cdfMin := 0
+	for _, v := range hist {
+		if v > 0 {
+			cdfMin = v
+			break
+		}
+	}
+	sum := 0
+	for i := 0; i < 256; i++ {
+		sum += hist[i]
+		if total == cdfMin {
+			lut[i] = uint8(i)
+			continue
+		}
+		val := math.Round(float64(sum-cdfMin) / float64(total-cdfMin) * 255)
+		lut[i] = uint8(clamp(val, 0, 255))
+	}
+	return lut
+}
+
+// clipHistogram 按限制值裁剪直方图，超出部分平均分配到所有 bin
+func clipHistogram(hist *[256]int, limit int) {
+	excess := 0
+	for i := range hist {
+		if hist[i] > limit {
+			excess += hist[i] - limit
+			hist[i] = limit
+		}
+	}
+	avg := excess / 256
+	residual := excess % 256
+	for i := range hist {
+		hist[i] += avg
+	}
+	// 余数按等间隔分配，避免集中在暗部
+	if residual > 0 {
+		step := maxValue(1, 256/residual)
+		for i := 0; i < 256 && residual > 0; i += step {
+			hist[i]++
+			residual--
+		}
+	}
+}
+
+// splitYCbCr 将 NRGBA 图像拆分为 Y,Cb,Cr 三个平面
+func splitYCbCr(src *image.NRGBA) ([]uint8, []uint8, []uint8) {
+	n := len(src.Pix) / 4
+	yPlane := make([]uint8, n)
+	cbPlane := make([]uint8, n)
+	crPlane := make([]uint8, n)
+	for i := 0; i < n; i++ {
+		yPlane[i], cbPlane[i], crPlane[i] = color.RGBToYCbCr(src.Pix[i*4], src.Pix[i*4+1], src.Pix[i*4+2])
+	}
+	return yPlane, cbPlane, crPlane
+}
+
+// mergeYCbCr 将 Y,Cb,Cr 三个平面写回 NRGBA 图像，透明度保持不变
+func mergeYCbCr(dst *image.NRGBA, yPlane, cbPlane, crPlane []uint8) {
+	for i := range yPlane {
+		dst.Pix[i*4], dst.Pix[i*4+1], dst.Pix[i*4+2] = color.YCbCrToRGB(yPlane[i], cbPlane[i], crPlane[i])
+	}
+}
@@ -0,0 +1,114 @@
+package imgHelper
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestComputeHistogram(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	for x := 0; x < 4; x++ {
+		src.SetNRGBA(x, 0, color.NRGBA{R: 255, A: 255})
+		src.SetNRGBA(x, 1, color.NRGBA{R: 10, G: 10, B: 10, A: 128})
+	}
+	hist := ComputeHistogram(src)
+	cases := []struct {
+		name string
+		got  int
+		want int
+	}{
+		{"Total", hist.Total, 8},
+		{"R[255]", hist.R[255], 4},
+		{"R[10]", hist.R[10], 4},
+		{"G[0]", hist.G[0], 4},
+		{"A[255]", hist.A[255], 4},
+		{"A[128]", hist.A[128], 4},
+		{"Luma[76]", hist.Luma[76], 4},
+		{"Luma[10]", hist.Luma[10], 4},
+		{"CDF[255]", hist.CDF()[255], 8},
+		{"CDF[50]", hist.CDF()[50], 4},
+	}
+	for _, c := range cases {
+		if c.got != c.want {
+			t.Errorf("%s = %d, want %d", c.name, c.got, c.want)
+		}
+	}
+	if mean := hist.Mean(); mean != 43 {
+		t.Errorf("Mean = %g, want 43", mean)
+	}
+}
+
+func TestEqualizeLUT(t *testing.T) {
+	var twoLevel, uniform, single [256]int
+	twoLevel[100], twoLevel[150] = 50, 50
+	for i := range uniform {
+		uniform[i] = 1
+	}
+	single[80] = 10
+	cases := []struct {
+		name  string
+		hist  [256]int
+		total int
+		check map[int]uint8
+	}{
+		{"两种灰度拉伸到两端", twoLevel, 100, map[int]uint8{100: 0, 150: 255}},
+		{"均匀分布保持不变", uniform, 256, map[int]uint8{0: 0, 128: 128, 255: 255}},
+		{"单一灰度保持不变", single, 10, map[int]uint8{80: 80}},
+		{"空直方图为恒等映射", [256]int{}, 0, map[int]uint8{0: 0, 77: 77, 255: 255}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			lut := equalizeLUT(c.hist, c.total)
+			for v, want := range c.check {
+				if lut[v] != want {
+					t.Errorf("lut[%d] = %d, want %d", v, lut[v], want)
+				}
+			}
+			for i := 1; i < 256; i++ {
+				if lut[i] < lut[i-1] {
+					t.Fatalf("映射表不单调: lut[%d]=%d < lut[%d]=%d", i, lut[i], i-1, lut[i-1])
+				}
+			}
+		})
+	}
+}
+
+func TestClipHistogram(t *testing.T) {
+	var spike, flat [256]int
+	spike[10] = 1000
+	spike[200] = 24
+	for i := range flat {
+		flat[i] = 3
+	}
+	cases := []struct {
+		name  string
+		hist  [256]int
+		limit int
+	}{
+		{"单峰", spike, 40},
+		{"余数不能整除", spike, 37},
+		{"未超出限制", flat, 10},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			before := 0
+			for _, n := range c.hist {
+				before += n
+			}
+			hist := c.hist
+			clipHistogram(&hist, c.limit)
+			after := 0
+			for i, n := range hist {
+				after += n
+				// 重新分配后每个 bin 最多比限制多出平均值加1
+				if n > c.limit+(before/256)+1 {
+					t.Errorf("hist[%d] = %d 超出限制 %d", i, n, c.limit)
+				}
+			}
+			if after != before {
+				t.Errorf("裁剪后总数 %d, want %d", after, before)
+			}
+		})
+	}
+}
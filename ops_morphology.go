@@ -0,0 +1,372 @@
+package imgHelper
+
+import (
+	"fmt"
+	"image"
+)
+
+// StructuringShape 结构元素的形状
+type StructuringShape int
+
+const (
+	StructRect    StructuringShape = iota // 矩形
+	StructCross                           // 十字形
+	StructEllipse                         // 椭圆形
+)
+
+// StructuringElement 形态学操作的结构元素
+type StructuringElement struct {
+	Width   int
+	Height  int
+	AnchorX int    // 锚点，结构元素中与当前像素对齐的位置，默认为中心
+	AnchorY int    // 锚点，结构元素中与当前像素对齐的位置，默认为中心
+	Mask    []bool // 按行存储，长度为 Width*Height，true 表示参与运算
+}
+
+// NewStructuringElement 创建指定形状和大小的结构元素，锚点位于中心
+func NewStructuringElement(shape StructuringShape, width, height int) *StructuringElement {
+	width = maxValue(width, 1)
+	height = maxValue(height, 1)
+	se := &StructuringElement{
+		Width:   width,
+		Height:  height,
+		AnchorX: width / 2,
+		AnchorY: height / 2,
+		Mask:    make([]bool, width*height),
+	}
+	// 椭圆的半轴与中心
+	rx, ry := float64(width)/2, float64(height)/2
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var on bool
+			switch shape {
+			case StructCross:
+				on = x == se.AnchorX || y == se.AnchorY
+			case StructEllipse:
+				dx := (float64(x) + 0.5 - rx) / rx
+				dy := (float64(y) + 0.5 - ry) / ry
+				on = dx*dx+dy*dy <= 1
+			default:
+				on = true
+			}
+			se.Mask[y*width+x] = on
+		}
+	}
+	return se
+}
+
+// NewCustomStructuringElement 使用自定义掩码创建结构元素
+// 参数:
+// - mask 掩码，mask[y][x] 为 true 表示参与运算，每行长度必须相同
+// - anchorX, anchorY 锚点位置
+func NewCustomStructuringElement(mask [][]bool, anchorX, anchorY int) (*StructuringElement, error) {
+	if len(mask) == 0 || len(mask[0]) == 0 {
+		return nil, fmt.Errorf("结构元素掩码不能为空")
+	}
+	height, width := len(mask), len(mask[0])
+	se := &StructuringElement{Width: width, Height: height, Mask: make([]bool, width*height)}
+	for y, row := range mask {
+		if len(row) != width {
+			return nil, fmt.Errorf("结构元素掩码每行长度必须相同, 第 %d 行长度为 %d, 期望为 %d", y, len(row), width)
+		}
+		copy(se.Mask[y*width:], row)
+	}
+	if err := se.SetAnchor(anchorX, anchorY); err != nil {
+		return nil, err
+	}
+	return se, nil
+}
+
+// SetAnchor 设置锚点
+func (se *StructuringElement) SetAnchor(anchorX, anchorY int) error {
+	if anchorX < 0 || anchorX >= se.Width || anchorY < 0 || anchorY >= se.Height {
+		return fmt.Errorf("锚点 (%d,%d) 超出结构元素范围 %dx%d", anchorX, anchorY, se.Width, se.Height)
+	}
+	se.AnchorX, se.AnchorY = anchorX, anchorY
+	return nil
+}
+
+// offsets 返回结构元素中参与运算的点相对锚点的偏移
+func (se *StructuringElement) offsets() []image.Point {
+	points := make([]image.Point, 0, len(se.Mask))
+	for y := 0; y < se.Height; y++ {
+		for x := 0; x < se.Width; x++ {
+			if se.Mask[y*se.Width+x] {
+				points = append(points, image.Point{X: x - se.AnchorX, Y: y - se.AnchorY})
+			}
+		}
+	}
+	return points
+}
+
+// MorphOptions 形态学操作参数，零值字段使用默认值
+type MorphOptions struct {
+	Element    *StructuringElement // 结构元素，为 nil 时使用 3x3 矩形
+	Iterations int                 // 迭代次数，默认为1
+	Binary     bool                // true 时先二值化(阈值128，亮为前景)再做二值形态学，否则对每个颜色通道做灰度形态学
+}
+
+func (opts MorphOptions) element() *StructuringElement {
+	if opts.Element == nil {
+		return NewStructuringElement(StructRect, 3, 3)
+	}
+	return opts.Element
+}
+
+func (opts MorphOptions) iterations() int {
+	return maxValue(opts.Iterations, 1)
+}
+
+// morphImage 形态学运算使用的图像数据，灰度形态学时处理 R,G,B,A 四个通道，二值形态学时只处理一个通道
+type morphImage struct {
+	w, h     int
+	channels [][]uint8
+	bounds   image.Rectangle
+}
+
+func newMorphImage(src image.Image, binary bool) *morphImage {
+	nrgba := imageToNRGBA(src)
+	w, h := nrgba.Bounds().Dx(), nrgba.Bounds().Dy()
+	m := &morphImage{w: w, h: h, bounds: src.Bounds()}
+	if binary {
+		ch := make([]uint8, w*h)
+		for i := range ch {
+			o := i * 4
+			if luminance(nrgba.Pix[o], nrgba.Pix[o+1], nrgba.Pix[o+2]) >= 128 {
+				ch[i] = 255
+			}
+		}
+		m.channels = [][]uint8{ch}
+		return m
+	}
+	m.channels = make([][]uint8, 4)
+	for c := range m.channels {
+		m.channels[c] = make([]uint8, w*h)
+		for i := range m.channels[c] {
+			m.channels[c][i] = nrgba.Pix[i*4+c]
+		}
+	}
+	return m
+}
+
+func (m *morphImage) clone() *morphImage {
+	c := &morphImage{w: m.w, h: m.h, bounds: m.bounds}
+	for _, ch := range m.channels {
+		c.channels = append(c.channels, append([]uint8(nil), ch...))
+	}
+	return c
+}
+
+func (m *morphImage) toRGBA() *image.RGBA {
+	nrgba := image.NewNRGBA(image.Rect(0, 0, m.w, m.h))
+	for i := 0; i < m.w*m.h; i++ {
+		o := i * 4
+		if len(m.channels) == 1 {
+			v := m.channels[0][i]
+			nrgba.Pix[o], nrgba.Pix[o+1], nrgba.Pix[o+2], nrgba.Pix[o+3] = v, v, v, 255
+			continue
+		}
+		for c, ch := range m.channels {
+			nrgba.Pix[o+c] = ch[i]
+		}
+	}
+	return nrgbaToRGBA(nrgba, image.Rect(m.bounds.Min.X, m.bounds.Min.Y, m.bounds.Min.X+m.w, m.bounds.Min.Y+m.h))
+}
+
+// morphPass 一次腐蚀(erode=true)或膨胀，超出图像范围的点不参与运算
+func (m *morphImage) morphPass(points []image.Point, erode bool) {
+	w, h := m.w, m.h
+	for c, ch := range m.channels {
+		out := make([]uint8, len(ch))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				var v uint8
+				if erode {
+					v = 255
+				}
+				for _, p := range points {
+					// 膨胀使用关于锚点反射后的结构元素
+					nx, ny := x+p.X, y+p.Y
+					if !erode {
+						nx, ny = x-p.X, y-p.Y
+					}
+					if nx < 0 || nx >= w || ny < 0 || ny >= h {
+						continue
+					}
+					s := ch[ny*w+nx]
+					if erode && s < v {
+						v = s
+					} else if !erode && s > v {
+						v = s
+					}
+				}
+				out[y*w+x] = v
+			}
+		}
+		m.channels[c] = out
+	}
+}
+
+func (m *morphImage) erode(opts MorphOptions) *morphImage {
+	points := opts.element().offsets()
+	for i := 0; i < opts.iterations(); i++ {
+		m.morphPass(points, true)
+	}
+	return m
+}
+
+func (m *morphImage) dilate(opts MorphOptions) *morphImage {
+	points := opts.element().offsets()
+	for i := 0; i < opts.iterations(); i++ {
+		m.morphPass(points, false)
+	}
+	return m
+}
+
+// subtract 逐像素相减 a - b，小于0截断为0，结果写入 a
+// 差值图像只保留颜色通道的差异，Alpha 通道置为不透明
+func (m *morphImage) subtract(b *morphImage) *morphImage {
+	for c, ch := range m.channels {
+		for i := range ch {
+			if c == 3 {
+				ch[i] = 255
+				continue
+			}
+			ch[i] = uint8(maxValue(int(ch[i])-int(b.channels[c][i]), 0))
+		}
+	}
+	return m
+}
+
+// MorphErode 使用结构元素进行腐蚀
+func MorphErode(src image.Image, opts MorphOptions) image.Image {
+	return newMorphImage(src, opts.Binary).erode(opts).toRGBA()
+}
+
+// OpsMorphErode 使用结构元素进行腐蚀操作
+func OpsMorphErode(opts MorphOptions) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		ctx.Dst = MorphErode(ctx.Dst, opts).(*image.RGBA)
+		return nil
+	}
+}
+
+// MorphDilate 使用结构元素进行膨胀
+func MorphDilate(src image.Image, opts MorphOptions) image.Image {
+	return newMorphImage(src, opts.Binary).dilate(opts).toRGBA()
+}
+
+// OpsMorphDilate 使用结构元素进行膨胀操作
+func OpsMorphDilate(opts MorphOptions) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		ctx.Dst = MorphDilate(ctx.Dst, opts).(*image.RGBA)
+		return nil
+	}
+}
+
+// MorphOpen 开运算：先腐蚀后膨胀，去除小于结构元素的亮色噪点
+func MorphOpen(src image.Image, opts MorphOptions) image.Image {
+	return newMorphImage(src, opts.Binary).erode(opts).dilate(opts).toRGBA()
+}
+
+// OpsMorphOpen 开运算操作
+func OpsMorphOpen(opts MorphOptions) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		ctx.Dst = MorphOpen(ctx.Dst, opts).(*image.RGBA)
+		return nil
+	}
+}
+
+// MorphClose 闭运算：先膨胀后腐蚀，填充小于结构元素的暗色孔洞和缝隙
+func MorphClose(src image.Image, opts MorphOptions) image.Image {
+	return newMorphImage(src, opts.Binary).dilate(opts).erode(opts).toRGBA()
+}
+
+// OpsMorphClose 闭运算操作
+func OpsMorphClose(opts MorphOptions) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		ctx.Dst = MorphClose(ctx.Dst, opts).(*image.RGBA)
+		return nil
+	}
+}
+
+// MorphGradient 形态学梯度：膨胀 - 腐蚀，得到物体的轮廓
+func MorphGradient(src image.Image, opts MorphOptions) image.Image {
+	m := newMorphImage(src, opts.Binary)
+	eroded := m.clone().erode(opts)
+	return m.dilate(opts).subtract(eroded).toRGBA()
+}
+
+// OpsMorphGradient 形态学梯度操作
+func OpsMorphGradient(opts MorphOptions) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		ctx.Dst = MorphGradient(ctx.Dst, opts).(*image.RGBA)
+		return nil
+	}
+}
+
+// TopHat 顶帽运算：原图 - 开运算，提取比周围亮且小于结构元素的细节，可用于校正不均匀的背景
+func TopHat(src image.Image, opts MorphOptions) image.Image {
+	m := newMorphImage(src, opts.Binary)
+	opened := m.clone().erode(opts).dilate(opts)
+	return m.subtract(opened).toRGBA()
+}
+
+// OpsTopHat 顶帽运算操作
+func OpsTopHat(opts MorphOptions) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		ctx.Dst = TopHat(ctx.Dst, opts).(*image.RGBA)
+		return nil
+	}
+}
+
+// BlackHat 黑帽运算：闭运算 - 原图，提取比周围暗且小于结构元素的细节，如浅色背景上的文字
+func BlackHat(src image.Image, opts MorphOptions) image.Image {
+	m := newMorphImage(src, opts.Binary)
+	closed := m.clone().dilate(opts).erode(opts)
+	return closed.subtract(m).toRGBA()
+}
+
+// OpsBlackHat 黑帽运算操作
+func OpsBlackHat(opts MorphOptions) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		ctx.Dst = BlackHat(ctx.Dst, opts).(*image.RGBA)
+		return nil
+	}
+}
+
+// HitOrMiss 击中击不中变换，用于在二值图中查找特定的像素形态(如端点、角点、孤立点)
+// 图像先以阈值128二值化，亮为前景；hit 中的点必须为前景，miss 中的点必须为背景，满足的像素输出为白色
+// 参数:
+// - hit 必须为前景的结构元素
+// - miss 必须为背景的结构元素，为 nil 时只检查 hit
+func HitOrMiss(src image.Image, hit, miss *StructuringElement) (image.Image, error) {
+	if hit == nil {
+		return nil, fmt.Errorf("hit 结构元素不能为空")
+	}
+	fg := newMorphImage(src, true)
+	bg := fg.clone()
+	for i, v := range bg.channels[0] {
+		bg.channels[0][i] = 255 - v
+	}
+	fg.erode(MorphOptions{Element: hit})
+	if miss != nil {
+		bg.erode(MorphOptions{Element: miss})
+		for i := range fg.channels[0] {
+			fg.channels[0][i] = min(fg.channels[0][i], bg.channels[0][i])
+		}
+	}
+	return fg.toRGBA(), nil
+}
+
+// OpsHitOrMiss 击中击不中变换操作
+func OpsHitOrMiss(hit, miss *StructuringElement) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		dst, err := HitOrMiss(ctx.Dst, hit, miss)
+		if err != nil {
+			return err
+		}
+		ctx.Dst = dst.(*image.RGBA)
+		return nil
+	}
+}
@@ -0,0 +1,211 @@
+package imgHelper
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+// patternImage 由字符图案生成黑白图像，'#' 为白色前景，其余为黑色背景
+func patternImage(rows ...string) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, len(rows[0]), len(rows)))
+	for y, row := range rows {
+		for x, c := range row {
+			v := uint8(0)
+			if c == '#' {
+				v = 255
+			}
+			img.SetRGBA(x, y, color.RGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+	return img
+}
+
+// imagePattern 将图像转换为字符图案，亮度不小于128的像素为 '#'
+func imagePattern(img image.Image) string {
+	b := img.Bounds()
+	var sb strings.Builder
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		if y > b.Min.Y {
+			sb.WriteByte('\n')
+		}
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y >= 128 {
+				sb.WriteByte('#')
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+	}
+	return sb.String()
+}
+
+func elementPattern(se *StructuringElement) string {
+	var sb strings.Builder
+	for y := 0; y < se.Height; y++ {
+		if y > 0 {
+			sb.WriteByte('\n')
+		}
+		for x := 0; x < se.Width; x++ {
+			if se.Mask[y*se.Width+x] {
+				sb.WriteByte('#')
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+	}
+	return sb.String()
+}
+
+func TestNewStructuringElement(t *testing.T) {
+	cases := []struct {
+		name          string
+		shape         StructuringShape
+		width, height int
+		want          string
+		anchor        image.Point
+	}{
+		{"矩形", StructRect, 3, 2, "###\n###", image.Pt(1, 1)},
+		{"十字形", StructCross, 3, 3, ".#.\n###\n.#.", image.Pt(1, 1)},
+		{"椭圆形", StructEllipse, 5, 5, ".###.\n#####\n#####\n#####\n.###.", image.Pt(2, 2)},
+		{"尺寸小于1时为1", StructRect, 0, -3, "#", image.Pt(0, 0)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			se := NewStructuringElement(c.shape, c.width, c.height)
+			if got := elementPattern(se); got != c.want {
+				t.Errorf("掩码\n%s\nwant\n%s", got, c.want)
+			}
+			if got := image.Pt(se.AnchorX, se.AnchorY); got != c.anchor {
+				t.Errorf("锚点 %v, want %v", got, c.anchor)
+			}
+		})
+	}
+}
+
+func TestNewCustomStructuringElementInvalid(t *testing.T) {
+	cases := []struct {
+		name    string
+		mask    [][]bool
+		ax, ay  int
+		wantErr bool
+	}{
+		{"正常", [][]bool{{true, false}, {true, true}}, 1, 0, false},
+		{"空掩码", nil, 0, 0, true},
+		{"行长度不同", [][]bool{{true, true}, {true}}, 0, 0, true},
+		{"锚点超出范围", [][]bool{{true, true}}, 2, 0, true},
+		{"锚点为负", [][]bool{{true, true}}, 0, -1, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := NewCustomStructuringElement(c.mask, c.ax, c.ay)
+			if (err != nil) != c.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, c.wantErr)
+			}
+		})
+	}
+}
+
+func TestMorphology(t *testing.T) {
+	square := patternImage(
+		".......",
+		".#####.",
+		".#####.",
+		".#####.",
+		".#####.",
+		".#####.",
+		".......",
+	)
+	noisy := patternImage(
+		"#......",
+		"...##..",
+		"..####.",
+		"..#.##.",
+		"...##..",
+		".......",
+		"......#",
+	)
+	cross := NewStructuringElement(StructCross, 3, 3)
+	oneByTwo, _ := NewCustomStructuringElement([][]bool{{true, true}}, 0, 0)
+	cases := []struct {
+		name string
+		got  image.Image
+		want string
+	}{
+		{"腐蚀", MorphErode(square, MorphOptions{Binary: true}), "" +
+			".......\n.......\n..###..\n..###..\n..###..\n.......\n......."},
+		{"腐蚀两次", MorphErode(square, MorphOptions{Binary: true, Iterations: 2}), "" +
+			".......\n.......\n.......\n...#...\n.......\n.......\n......."},
+		{"膨胀", MorphDilate(square, MorphOptions{Binary: true}), "" +
+			"#######\n#######\n#######\n#######\n#######\n#######\n#######"},
+		{"十字形腐蚀", MorphErode(square, MorphOptions{Binary: true, Element: cross}), "" +
+			".......\n.......\n..###..\n..###..\n..###..\n.......\n......."},
+		{"十字形膨胀", MorphDilate(patternImage(".....", ".....", "..#..", ".....", "....."), MorphOptions{Binary: true, Element: cross}), "" +
+			".....\n..#..\n.###.\n..#..\n....."},
+		{"锚点不在中心的膨胀", MorphDilate(patternImage(".....", "..#..", "....."), MorphOptions{Binary: true, Element: oneByTwo}), "" +
+			".....\n..##.\n....."},
+		{"开运算去掉孤立点和细小部分", MorphOpen(noisy, MorphOptions{Binary: true, Element: cross}), "" +
+			".......\n....#..\n...###.\n....#..\n.......\n.......\n......."},
+		{"闭运算填上孔洞", MorphClose(noisy, MorphOptions{Binary: true, Element: cross}), "" +
+			"#......\n...##..\n..####.\n..####.\n...##..\n.......\n......#"},
+		{"形态学梯度为边界", MorphGradient(square, MorphOptions{Binary: true}), "" +
+			"#######\n#######\n##...##\n##...##\n##...##\n#######\n#######"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := imagePattern(c.got); got != c.want {
+				t.Errorf("结果\n%s\nwant\n%s", got, c.want)
+			}
+		})
+	}
+}
+
+func TestMorphGrayscale(t *testing.T) {
+	// 灰度形态学逐通道取最小/最大值
+	src := uniformRGBA(5, 5, color.RGBA{R: 100, G: 100, B: 100, A: 255})
+	src.SetRGBA(2, 2, color.RGBA{R: 200, G: 50, B: 100, A: 255})
+	cases := []struct {
+		name string
+		got  image.Image
+		at   image.Point
+		want color.RGBA
+	}{
+		{"膨胀取最大值", MorphDilate(src, MorphOptions{}), image.Pt(1, 1), color.RGBA{R: 200, G: 100, B: 100, A: 255}},
+		{"腐蚀取最小值", MorphErode(src, MorphOptions{}), image.Pt(3, 3), color.RGBA{R: 100, G: 50, B: 100, A: 255}},
+		{"膨胀不影响远处", MorphDilate(src, MorphOptions{}), image.Pt(0, 0), color.RGBA{R: 100, G: 100, B: 100, A: 255}},
+		{"顶帽为亮细节", TopHat(src, MorphOptions{}), image.Pt(2, 2), color.RGBA{R: 100, G: 0, B: 0, A: 255}},
+		{"黑帽为暗细节", BlackHat(src, MorphOptions{}), image.Pt(2, 2), color.RGBA{R: 0, G: 50, B: 0, A: 255}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := color.RGBAModel.Convert(c.got.At(c.at.X, c.at.Y)); got != c.want {
+				t.Errorf("%v = %v, want %v", c.at, got, c.want)
+			}
+		})
+	}
+}
+
+func TestHitOrMiss(t *testing.T) {
+	src := patternImage(
+		"......",
+		".#....",
+		"......",
+		"...##.",
+		"......",
+	)
+	// 查找孤立点：中心为前景，周围8个点为背景
+	hit := NewStructuringElement(StructRect, 1, 1)
+	miss, _ := NewCustomStructuringElement([][]bool{{true, true, true}, {true, false, true}, {true, true, true}}, 1, 1)
+	dst, err := HitOrMiss(src, hit, miss)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "......\n.#....\n......\n......\n......"
+	if got := imagePattern(dst); got != want {
+		t.Errorf("结果\n%s\nwant\n%s", got, want)
+	}
+	if _, err := HitOrMiss(src, nil, miss); err == nil {
+		t.Error("hit 为 nil 时 want error")
+	}
+}
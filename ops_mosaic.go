@@ -1,7 +1,6 @@
 package imgHelper
 
 import (
-	"errors"
 	"fmt"
 	"image"
 	"image/color"
@@ -10,42 +9,41 @@ import (
 
 // OpsMosaic 马赛克操作
 // 参数:
-// - rg 马赛克范围
+// - rg 马赛克范围（矩形，圆，三角形，多边形，曲边多边形，掩码）
 // - blockSize 马赛克块大小
-func OpsMosaic(rg RangeValue, blockSize int) func(ctx *CanvasContext) error {
+// - opts 羽化半径和反选，可选，矩形不传时为普通的矩形马赛克
+func OpsMosaic(rg RangeValue, blockSize int, opts ...ShapeOptions) func(ctx *CanvasContext) error {
 	return func(ctx *CanvasContext) error {
-
-		switch rg.Type() {
-
-		case RangeRectType:
+		if opt := getShapeOptions(opts); rg.Type() == RangeRectType && opt.Feather <= 0 && !opt.Invert {
 			x0, y0, x1, y1 := rg.(Range).Value()
 			ctx.Dst = Mosaic(ctx.Dst, x0, y0, x1, y1, blockSize).(*image.RGBA)
-
-		case RangeCircleType:
-			rgObj := rg.(RangeCircle)
-			ctx.Dst = MosaicCircle(ctx.Dst, rgObj.Cx, rgObj.Cy, rgObj.R, blockSize).(*image.RGBA)
-
-		case RangeTriangleType:
-			rgObj := rg.(RangeTriangle)
-			ctx.Dst = MosaicTriangle(ctx.Dst, rgObj.X0, rgObj.Y0, rgObj.X1, rgObj.Y1, rgObj.X2, rgObj.Y2, blockSize).(*image.RGBA)
-
-		case RangePolygonType:
-			rgObj := rg.(RangePolygon)
-			points := make([]int, 0)
-			for _, v := range rgObj.Points {
-				points = append(points, v.X)
-				points = append(points, v.Y)
-			}
-			dst, err := MosaicPolygon(ctx.Dst, blockSize, points...)
-			ctx.Dst = dst.(*image.RGBA)
-			ctx.Err = errors.Join(ctx.Err, err)
-
+			return nil
 		}
-
+		dst, err := MosaicShape(ctx.Dst, rg, blockSize, opts...)
+		if err != nil {
+			return err
+		}
+		ctx.Dst = dst.(*image.RGBA)
 		return nil
 	}
 }
 
+// MosaicShape 形状范围马赛克，形状边缘按覆盖面积与原像素混合，无锯齿，可选羽化
+// 参数:
+// - rg 马赛克范围（矩形，圆，三角形，多边形，曲边多边形，掩码）
+// - blockSize 马赛克块大小
+// - opts 羽化半径和反选，可选
+func MosaicShape(src image.Image, rg RangeValue, blockSize int, opts ...ShapeOptions) (image.Image, error) {
+	if blockSize <= 0 {
+		return nil, fmt.Errorf("blockSize 必须大于0")
+	}
+	mask, err := shapeMask(rg, src.Bounds(), getShapeOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	return MosaicMask(src, blockSize, mask)
+}
+
 // Mosaic 马赛克
 // 参数:
 // - x0, y0, x1, y1  马赛克范围
@@ -91,164 +89,35 @@ func Mosaic(src image.Image, x0, y0, x1, y1 int, blockSize int) image.Image {
 	return drawImg
 }
 
-// MosaicCircle 圆形范围马赛克
+// MosaicCircle 圆形范围马赛克，边缘抗锯齿
 // 参数:
 // - cx, cy：圆心坐标
 // - r：圆的半径
 // - blockSize：马赛克块大小（块越大，模糊效果越强）
-func MosaicCircle(src image.Image, cx, cy, r int, blockSize int) image.Image {
-	bounds := src.Bounds()
-	drawImg := image.NewRGBA(bounds)
-	draw.Draw(drawImg, bounds, src, bounds.Min, draw.Src)
-
-	minX := cx - r
-	maxX := cx + r
-	minY := cy - r
-	maxY := cy + r
-	if minX < bounds.Min.X {
-		minX = bounds.Min.X
-	}
-	if maxX > bounds.Max.X {
-		maxX = bounds.Max.X
-	}
-	if minY < bounds.Min.Y {
-		minY = bounds.Min.Y
+// - opts：羽化半径，可选
+func MosaicCircle(src image.Image, cx, cy, r int, blockSize int, opts ...ShapeOptions) image.Image {
+	dst, err := MosaicShape(src, RangeCircle{Cx: cx, Cy: cy, R: r}, blockSize, opts...)
+	if err != nil {
+		return imageToRGBA(src)
 	}
-	if maxY > bounds.Max.Y {
-		maxY = bounds.Max.Y
-	}
-
-	for y := minY; y < maxY; y += blockSize {
-		for x := minX; x < maxX; x += blockSize {
-			var totalR, totalG, totalB, totalA uint32
-			pixelCount := 0
-			for m := 0; m < blockSize; m++ {
-				for n := 0; n < blockSize; n++ {
-					pixelX := x + n
-					pixelY := y + m
-					if pixelX >= bounds.Min.X && pixelX < bounds.Max.X &&
-						pixelY >= bounds.Min.Y && pixelY < bounds.Max.Y {
-						dx := pixelX - cx
-						dy := pixelY - cy
-						if dx*dx+dy*dy <= r*r {
-							r, g, b, a := drawImg.At(pixelX, pixelY).RGBA()
-							totalR += r
-							totalG += g
-							totalB += b
-							totalA += a
-							pixelCount++
-						}
-					}
-				}
-			}
-
-			if pixelCount > 0 {
-				avgR := totalR / uint32(pixelCount)
-				avgG := totalG / uint32(pixelCount)
-				avgB := totalB / uint32(pixelCount)
-				avgA := totalA / uint32(pixelCount)
-				avgColor := color.RGBA64{
-					R: uint16(avgR),
-					G: uint16(avgG),
-					B: uint16(avgB),
-					A: uint16(avgA),
-				}
-
-				for m := 0; m < blockSize; m++ {
-					for n := 0; n < blockSize; n++ {
-						pixelX := x + n
-						pixelY := y + m
-						if pixelX >= bounds.Min.X && pixelX < bounds.Max.X &&
-							pixelY >= bounds.Min.Y && pixelY < bounds.Max.Y {
-							dx := pixelX - cx
-							dy := pixelY - cy
-							if dx*dx+dy*dy <= r*r {
-								drawImg.Set(pixelX, pixelY, avgColor)
-							}
-						}
-					}
-				}
-
-			}
-		}
-	}
-
-	return drawImg
+	return dst
 }
 
-// MosaicTriangle 三角形范围马赛克
+// MosaicTriangle 三角形范围马赛克，边缘抗锯齿
 // 参数:
 // - x1,y1, x2,y2, x3,y3：三角形三个顶点坐标
 // - blockSize：马赛克块大小（块越大，颗粒感越强）
-func MosaicTriangle(src image.Image, x1, y1, x2, y2, x3, y3 int, blockSize int) image.Image {
-	bounds := src.Bounds()
-	drawImg := image.NewRGBA(bounds)
-	draw.Draw(drawImg, bounds, src, bounds.Min, draw.Src)
-	minX := minValue(x1, x2, x3)
-	maxX := maxValue(x1, x2, x3)
-	minY := minValue(y1, y2, y3)
-	maxY := maxValue(y1, y2, y3)
-	if minX < bounds.Min.X {
-		minX = bounds.Min.X
-	}
-	if maxX > bounds.Max.X {
-		maxX = bounds.Max.X
+// - opts：羽化半径，可选
+func MosaicTriangle(src image.Image, x1, y1, x2, y2, x3, y3 int, blockSize int, opts ...ShapeOptions) image.Image {
+	dst, err := MosaicShape(src, RangeTriangle{X0: x1, Y0: y1, X1: x2, Y1: y2, X2: x3, Y2: y3}, blockSize, opts...)
+	if err != nil {
+		return imageToRGBA(src)
 	}
-	if minY < bounds.Min.Y {
-		minY = bounds.Min.Y
-	}
-	if maxY > bounds.Max.Y {
-		maxY = bounds.Max.Y
-	}
-	for y := minY; y < maxY; y += blockSize {
-		for x := minX; x < maxX; x += blockSize {
-			var totalR, totalG, totalB, totalA uint32
-			pixelCount := 0
-			for m := 0; m < blockSize; m++ {
-				for n := 0; n < blockSize; n++ {
-					pixelX := x + n
-					pixelY := y + m
-					if pixelX >= bounds.Min.X && pixelX < bounds.Max.X &&
-						pixelY >= bounds.Min.Y && pixelY < bounds.Max.Y &&
-						isPointInTriangle(pixelX, pixelY, x1, y1, x2, y2, x3, y3) {
-						r, g, b, a := drawImg.At(pixelX, pixelY).RGBA()
-						totalR += r
-						totalG += g
-						totalB += b
-						totalA += a
-						pixelCount++
-					}
-				}
-			}
-			if pixelCount > 0 {
-				avgR := totalR / uint32(pixelCount)
-				avgG := totalG / uint32(pixelCount)
-				avgB := totalB / uint32(pixelCount)
-				avgA := totalA / uint32(pixelCount)
-				avgColor := color.RGBA64{
-					R: uint16(avgR),
-					G: uint16(avgG),
-					B: uint16(avgB),
-					A: uint16(avgA),
-				}
-				for m := 0; m < blockSize; m++ {
-					for n := 0; n < blockSize; n++ {
-						pixelX := x + n
-						pixelY := y + m
-						if pixelX >= bounds.Min.X && pixelX < bounds.Max.X &&
-							pixelY >= bounds.Min.Y && pixelY < bounds.Max.Y &&
-							isPointInTriangle(pixelX, pixelY, x1, y1, x2, y2, x3, y3) {
-							drawImg.Set(pixelX, pixelY, avgColor)
-						}
-					}
-				}
-			}
-		}
-	}
-	return drawImg
+	return dst
 }
 
-// MosaicPolygon 多边形范围马赛克
+// MosaicPolygon 多边形范围马赛克，边缘抗锯齿
+// 需要羽化时使用 MosaicShape(src, RangePolygon{...}, blockSize, ShapeOptions{...})
 // 参数:
 //
 //	src：源图像
@@ -256,104 +125,60 @@ func MosaicTriangle(src image.Image, x1, y1, x2, y2, x3, y3 int, blockSize int)
 //	points：多边形顶点坐标，格式为 [x0,y0, x1,y1, ..., xn,yn]（至少3个顶点，长度≥6）
 func MosaicPolygon(src image.Image, blockSize int, points ...int) (image.Image, error) {
 	if len(points) < 6 || len(points)%2 != 0 || blockSize <= 0 {
-		bounds := src.Bounds()
-		dst := image.NewRGBA(bounds)
-		draw.Draw(dst, bounds, src, bounds.Min, draw.Src)
-		return dst, fmt.Errorf("至少需要三个顶点")
+		return imageToRGBA(src), fmt.Errorf("至少需要三个顶点")
 	}
+	return MosaicShape(src, pointsToPolygon(points), blockSize)
+}
 
-	vertexCount := len(points) / 2
-	minX, maxX := points[0], points[0]
-	minY, maxY := points[1], points[1]
-	vertices := make([][2]int, vertexCount)
-	for i := 0; i < vertexCount; i++ {
-		x := points[2*i]
-		y := points[2*i+1]
-		vertices[i] = [2]int{x, y}
-		if x < minX {
-			minX = x
-		}
-		if x > maxX {
-			maxX = x
-		}
-		if y < minY {
-			minY = y
-		}
-		if y > maxY {
-			maxY = y
-		}
+// MosaicMask 掩码范围马赛克
+// 每个马赛克块取块内掩码加权的平均颜色，掩码值作为马赛克与原像素的混合比例，掩码边缘可以是柔和过渡
+// 参数:
+//
+//	src：源图像
+//	blockSize：马赛克块大小
+//	mask：掩码，坐标与源图像一致
+func MosaicMask(src image.Image, blockSize int, mask *image.Gray) (image.Image, error) {
+	if mask == nil || blockSize <= 0 {
+		return nil, fmt.Errorf("掩码不能为空且 blockSize 必须大于0")
 	}
-
 	bounds := src.Bounds()
-	srcMinX, srcMinY := bounds.Min.X, bounds.Min.Y
-	srcMaxX, srcMaxY := bounds.Max.X, bounds.Max.Y
-	if minX < srcMinX {
-		minX = srcMinX
-	}
-	if maxX > srcMaxX {
-		maxX = srcMaxX
-	}
-	if minY < srcMinY {
-		minY = srcMinY
-	}
-	if maxY > srcMaxY {
-		maxY = srcMaxY
-	}
-
 	drawImg := image.NewRGBA(bounds)
 	draw.Draw(drawImg, bounds, src, bounds.Min, draw.Src)
-
-	for y := minY; y < maxY; y += blockSize {
-		for x := minX; x < maxX; x += blockSize {
-
-			var totalR, totalG, totalB, totalA uint32
-			var pixelCount = 0
-
-			for m := 0; m < blockSize; m++ {
-				for n := 0; n < blockSize; n++ {
-					pixelX := x + n
-					pixelY := y + m
-					if pixelX >= srcMinX && pixelX < srcMaxX &&
-						pixelY >= srcMinY && pixelY < srcMaxY &&
-						isPointInPolygon(pixelX, pixelY, vertices) {
-						r, g, b, a := drawImg.At(pixelX, pixelY).RGBA()
-						totalR += r
-						totalG += g
-						totalB += b
-						totalA += a
-						pixelCount++
+	rect := maskBounds(mask).Intersect(bounds)
+
+	for y := rect.Min.Y; y < rect.Max.Y; y += blockSize {
+		for x := rect.Min.X; x < rect.Max.X; x += blockSize {
+			block := image.Rect(x, y, x+blockSize, y+blockSize).Intersect(rect)
+			// 每个像素最多累加 255*255，块较大时 uint32 会溢出
+			var total [4]uint64
+			var weight uint64
+			for py := block.Min.Y; py < block.Max.Y; py++ {
+				for px := block.Min.X; px < block.Max.X; px++ {
+					m := uint64(mask.GrayAt(px, py).Y)
+					o := drawImg.PixOffset(px, py)
+					for c := 0; c < 4; c++ {
+						total[c] += uint64(drawImg.Pix[o+c]) * m
 					}
+					weight += m
 				}
 			}
-
-			if pixelCount > 0 {
-				avgR := totalR / uint32(pixelCount)
-				avgG := totalG / uint32(pixelCount)
-				avgB := totalB / uint32(pixelCount)
-				avgA := totalA / uint32(pixelCount)
-				avgColor := color.RGBA64{
-					R: uint16(avgR),
-					G: uint16(avgG),
-					B: uint16(avgB),
-					A: uint16(avgA),
-				}
-
-				for m := 0; m < blockSize; m++ {
-					for n := 0; n < blockSize; n++ {
-						pixelX := x + n
-						pixelY := y + m
-						if pixelX >= srcMinX && pixelX < srcMaxX &&
-							pixelY >= srcMinY && pixelY < srcMaxY &&
-							isPointInPolygon(pixelX, pixelY, vertices) {
-							drawImg.Set(pixelX, pixelY, avgColor)
-						}
+			if weight == 0 {
+				continue
+			}
+			for py := block.Min.Y; py < block.Max.Y; py++ {
+				for px := block.Min.X; px < block.Max.X; px++ {
+					m := uint64(mask.GrayAt(px, py).Y)
+					if m == 0 {
+						continue
+					}
+					o := drawImg.PixOffset(px, py)
+					for c := 0; c < 4; c++ {
+						avg := total[c] / weight
+						drawImg.Pix[o+c] = uint8((avg*m + uint64(drawImg.Pix[o+c])*(255-m) + 127) / 255)
 					}
 				}
-
 			}
-
 		}
 	}
-
 	return drawImg, nil
 }
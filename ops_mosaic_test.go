@@ -0,0 +1,75 @@
+package imgHelper
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestMosaicMask(t *testing.T) {
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	full := func(r image.Rectangle) *image.Gray {
+		mask := image.NewGray(r)
+		for i := range mask.Pix {
+			mask.Pix[i] = 255
+		}
+		return mask
+	}
+	cases := []struct {
+		name      string
+		size      int
+		blockSize int
+	}{
+		{"小块", 40, 8},
+		// 600*600 个像素的累加值超出 uint32 的范围
+		{"整张图为一块", 600, 600},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			src := uniformRGBA(c.size, c.size, white)
+			dst, err := MosaicMask(src, c.blockSize, full(src.Bounds()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rgba := dst.(*image.RGBA)
+			for i := 0; i < len(rgba.Pix); i += 4 {
+				if got := (color.RGBA{R: rgba.Pix[i], G: rgba.Pix[i+1], B: rgba.Pix[i+2], A: rgba.Pix[i+3]}); got != white {
+					t.Fatalf("像素 %d = %v, want %v", i/4, got, white)
+				}
+			}
+		})
+	}
+}
+
+func TestMosaicMaskAverage(t *testing.T) {
+	// 左黑右白，掩码只覆盖上半部分：块内取掩码内像素的平均值，掩码外不变
+	src := splitImage(8, 8, 4, color.RGBA{A: 255}, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	mask := image.NewGray(src.Bounds())
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 8; x++ {
+			mask.SetGray(x, y, color.Gray{Y: 255})
+		}
+	}
+	dst, err := MosaicMask(src, 8, mask)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rgba := dst.(*image.RGBA)
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			want := src.RGBAAt(x, y)
+			if y < 4 {
+				want = color.RGBA{R: 127, G: 127, B: 127, A: 255}
+			}
+			if got := rgba.RGBAAt(x, y); got != want {
+				t.Fatalf("(%d, %d) = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+	if _, err := MosaicMask(src, 0, mask); err == nil {
+		t.Error("blockSize 为0时 want error")
+	}
+	if _, err := MosaicMask(src, 4, nil); err == nil {
+		t.Error("掩码为 nil 时 want error")
+	}
+}
@@ -0,0 +1,244 @@
+package imgHelper
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+)
+
+// FindHomography 由4组对应点求透视变换(单应性)矩阵 H，使 to = H * from (齐次坐标)
+// 返回的矩阵可直接用于 PerspectiveTransform33；任意3点共线时无解
+func FindHomography(from, to [4]Point) ([3][3]float64, error) {
+	var f, t [4][2]float64
+	for i := range from {
+		f[i] = [2]float64{float64(from[i].X), float64(from[i].Y)}
+		t[i] = [2]float64{float64(to[i].X), float64(to[i].Y)}
+	}
+	return homography(f, t)
+}
+
+// homography 解 8 元线性方程组求单应性矩阵，h33 固定为1
+func homography(from, to [4][2]float64) ([3][3]float64, error) {
+	// 每组点提供两个方程:
+	// x' = (h11 x + h12 y + h13) / (h31 x + h32 y + 1)
+	// y' = (h21 x + h22 y + h23) / (h31 x + h32 y + 1)
+	var m [8][9]float64
+	for i := 0; i < 4; i++ {
+		x, y := from[i][0], from[i][1]
+		u, v := to[i][0], to[i][1]
+		m[2*i] = [9]float64{x, y, 1, 0, 0, 0, -u * x, -u * y, u}
+		m[2*i+1] = [9]float64{0, 0, 0, x, y, 1, -v * x, -v * y, v}
+	}
+	// 列主元高斯消元
+	for col := 0; col < 8; col++ {
+		pivot := col
+		for r := col + 1; r < 8; r++ {
+			if math.Abs(m[r][col]) > math.Abs(m[pivot][col]) {
+				pivot = r
+			}
+		}
+		if math.Abs(m[pivot][col]) < 1e-10 {
+			return [3][3]float64{}, fmt.Errorf("对应点退化(存在三点共线)，无法求解透视变换矩阵")
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+		for r := 0; r < 8; r++ {
+			if r == col {
+				continue
+			}
+			k := m[r][col] / m[col][col]
+			for c := col; c < 9; c++ {
+				m[r][c] -= k * m[col][c]
+			}
+		}
+	}
+	var h [8]float64
+	for i := range h {
+		h[i] = m[i][8] / m[i][i]
+	}
+	return [3][3]float64{
+		{h[0], h[1], h[2]},
+		{h[3], h[4], h[5]},
+		{h[6], h[7], 1},
+	}, nil
+}
+
+// orderCorners 将四边形的顶点按 左上、右上、右下、左下 的顺序排列
+// 按顶点绕中心的角度顺时针排序，从最靠近外接矩形左上角的顶点开始，旋转约45度的四边形也能正确排序
+func orderCorners(corners [4]Point) [4]Point {
+	var cx, cy float64
+	minX, minY := corners[0].X, corners[0].Y
+	for _, p := range corners {
+		cx, cy = cx+float64(p.X)/4, cy+float64(p.Y)/4
+		minX, minY = minValue(minX, p.X), minValue(minY, p.Y)
+	}
+	sorted := corners[:]
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return math.Atan2(float64(sorted[i].Y)-cy, float64(sorted[i].X)-cx) < math.Atan2(float64(sorted[j].Y)-cy, float64(sorted[j].X)-cx)
+	})
+	start := 0
+	for i, p := range sorted {
+		d := math.Hypot(float64(p.X-minX), float64(p.Y-minY))
+		if d < math.Hypot(float64(sorted[start].X-minX), float64(sorted[start].Y-minY))-1e-9 {
+			start = i
+		}
+	}
+	var ordered [4]Point
+	for i := range ordered {
+		ordered[i] = sorted[(start+i)%4]
+	}
+	return ordered
+}
+
+// Rectify 透视矫正：将源图像中四边形区域(如斜拍的文档、屏幕、招牌)拉正为 outW*outH 的矩形图像
+// 顶点坐标与 Transform 一致，(0, 0) 为图像左上角像素的外角，四边形 (0,0),(w,0),(w,h),(0,h) 即为整幅 w*h 图像
+// 参数:
+// - corners 四边形的四个顶点，按 左上、右上、右下、左下 的顺序，顶点顺序不确定时可先用 DetectDocument 检测
+// - outW, outH 输出的宽高，小于等于0时按四边形对边长度的较大值自动计算
+func Rectify(src image.Image, corners [4]Point, outW, outH int) (image.Image, error) {
+	dist := func(a, b Point) float64 {
+		return math.Hypot(float64(a.X-b.X), float64(a.Y-b.Y))
+	}
+	if outW <= 0 {
+		outW = int(math.Round(math.Max(dist(corners[0], corners[1]), dist(corners[3], corners[2]))))
+	}
+	if outH <= 0 {
+		outH = int(math.Round(math.Max(dist(corners[0], corners[3]), dist(corners[1], corners[2]))))
+	}
+	if outW <= 0 || outH <= 0 {
+		return nil, fmt.Errorf("四边形的顶点重合，无法矫正")
+	}
+
+	// 源四边形到输出矩形的映射，由 transformInto 对每个输出像素的中心反向采样
+	var from, to [4][2]float64
+	rect := [4][2]float64{{0, 0}, {float64(outW), 0}, {float64(outW), float64(outH)}, {0, float64(outH)}}
+	for i, p := range corners {
+		from[i] = [2]float64{float64(p.X), float64(p.Y)}
+		to[i] = rect[i]
+	}
+	h, err := homography(from, to)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := transformInto(src, TransformFromMatrix(h), image.Rect(0, 0, outW, outH), TransformOptions{Border: BorderClamp})
+	if err != nil {
+		return nil, err
+	}
+	return dst, nil
+}
+
+// DetectDocument 自动检测图像中文档(纸张、票据、卡片)的四边形轮廓，返回按 左上、右上、右下、左下 排列的四个顶点
+// 在缩小的图像上做 Canny 边缘检测并膨胀连接断开的边缘，提取轮廓后用多边形逼近，取面积最大的凸四边形
+func DetectDocument(src image.Image) ([4]Point, error) {
+	bounds := src.Bounds()
+	if bounds.Empty() {
+		return [4]Point{}, fmt.Errorf("源图像为空")
+	}
+	const analyseSize = 500
+	small := src
+	ratio := 1.0
+	if maxValue(bounds.Dx(), bounds.Dy()) > analyseSize {
+		small = ResizeFit(src, analyseSize, analyseSize)
+		ratio = float64(small.Bounds().Dx()) / float64(bounds.Dx())
+	}
+	edges, err := Canny(small, 30, 90, 2)
+	if err != nil {
+		return [4]Point{}, err
+	}
+	closed := MorphDilate(edges, MorphOptions{Binary: true, Element: NewStructuringElement(StructRect, 5, 5)})
+
+	sb := small.Bounds()
+	minArea := float64(sb.Dx()*sb.Dy()) * 0.1
+	var best []Point
+	bestArea := 0.0
+	for _, c := range FindContours(closed) {
+		if c.Hole {
+			continue
+		}
+		hull := ConvexHull(c.Points)
+		area := ContourArea(hull)
+		if area < minArea || area <= bestArea {
+			continue
+		}
+		// 在简化后的凸包顶点中取面积最大的四边形，与凸包面积接近时认为是四边形
+		approx := ApproxPolyDP(hull, 0.005*ArcLength(hull, true), true)
+		if len(approx) > 40 {
+			approx = ApproxPolyDP(hull, 0.02*ArcLength(hull, true), true)
+		}
+		quad, quadArea := maxAreaQuad(approx)
+		if quadArea >= 0.9*area {
+			best, bestArea = quad, quadArea
+		}
+	}
+	if best == nil {
+		return [4]Point{}, fmt.Errorf("未检测到文档四边形")
+	}
+
+	var corners [4]Point
+	for i, p := range best {
+		corners[i] = Point{
+			X: bounds.Min.X + int(math.Round(float64(p.X-sb.Min.X)/ratio)),
+			Y: bounds.Min.Y + int(math.Round(float64(p.Y-sb.Min.Y)/ratio)),
+		}
+	}
+	return orderCorners(corners), nil
+}
+
+// maxAreaQuad 在凸多边形的顶点中选取面积最大的四边形
+func maxAreaQuad(points []Point) ([]Point, float64) {
+	n := len(points)
+	if n < 4 {
+		return nil, 0
+	}
+	var best []Point
+	bestArea := 0.0
+	for a := 0; a < n; a++ {
+		for b := a + 1; b < n; b++ {
+			for c := b + 1; c < n; c++ {
+				for d := c + 1; d < n; d++ {
+					quad := []Point{points[a], points[b], points[c], points[d]}
+					if area := ContourArea(quad); area > bestArea {
+						best, bestArea = quad, area
+					}
+				}
+			}
+		}
+	}
+	return best, bestArea
+}
+
+// ScanDocument 文档扫描：自动检测文档四边形并透视矫正，输出拉正、裁剪后的文档图像
+func ScanDocument(src image.Image) (image.Image, error) {
+	corners, err := DetectDocument(src)
+	if err != nil {
+		return nil, err
+	}
+	return Rectify(src, corners, 0, 0)
+}
+
+// OpsRectify 透视矫正操作
+// 参数:
+// - corners 四边形的四个顶点
+// - outW, outH 输出的宽高，小于等于0时自动计算
+func OpsRectify(corners [4]Point, outW, outH int) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		dst, err := Rectify(ctx.Dst, corners, outW, outH)
+		if err != nil {
+			return err
+		}
+		ctx.Dst = dst.(*image.RGBA)
+		return nil
+	}
+}
+
+// OpsScanDocument 文档扫描操作
+func OpsScanDocument() func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		dst, err := ScanDocument(ctx.Dst)
+		if err != nil {
+			return err
+		}
+		ctx.Dst = dst.(*image.RGBA)
+		return nil
+	}
+}
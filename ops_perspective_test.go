@@ -0,0 +1,60 @@
+package imgHelper
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFindHomography(t *testing.T) {
+	square := [4]Point{{0, 0}, {100, 0}, {100, 100}, {0, 100}}
+	cases := []struct {
+		name     string
+		from, to [4]Point
+		wantErr  bool
+	}{
+		{"单位变换", square, square, false},
+		{"平移", square, [4]Point{{10, 5}, {110, 5}, {110, 105}, {10, 105}}, false},
+		{"缩放", square, [4]Point{{0, 0}, {200, 0}, {200, 50}, {0, 50}}, false},
+		{"透视", square, [4]Point{{20, 10}, {90, 0}, {120, 110}, {0, 80}}, false},
+		{"三点共线", [4]Point{{0, 0}, {50, 50}, {100, 100}, {0, 100}}, square, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			h, err := FindHomography(c.from, c.to)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, c.wantErr)
+			}
+			if c.wantErr {
+				return
+			}
+			tr := TransformFromMatrix(h)
+			for i, p := range c.from {
+				x, y := tr.Apply(float64(p.X), float64(p.Y))
+				if math.Abs(x-float64(c.to[i].X)) > 1e-6 || math.Abs(y-float64(c.to[i].Y)) > 1e-6 {
+					t.Errorf("点 %v 映射为 (%.6f, %.6f)，期望 %v", p, x, y, c.to[i])
+				}
+			}
+		})
+	}
+}
+
+func TestOrderCorners(t *testing.T) {
+	want := [4]Point{{10, 10}, {90, 12}, {88, 95}, {8, 90}}
+	cases := []struct {
+		name    string
+		corners [4]Point
+		want    [4]Point
+	}{
+		{"已排序", want, want},
+		{"逆序", [4]Point{{8, 90}, {88, 95}, {90, 12}, {10, 10}}, want},
+		{"打乱", [4]Point{{88, 95}, {10, 10}, {8, 90}, {90, 12}}, want},
+		{"旋转约45度", [4]Point{{55, 0}, {45, 100}, {0, 45}, {100, 55}}, [4]Point{{0, 45}, {55, 0}, {100, 55}, {45, 100}}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := orderCorners(c.corners); got != c.want {
+				t.Errorf("orderCorners(%v) = %v, want %v", c.corners, got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package imgHelper
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestRegion(t *testing.T) {
+	src := imageToRGBA(noisyImage(image.Rect(10, 20, 40, 45)))
+	red := color.RGBA{R: 255, A: 255}
+	paint := func(img image.Image) image.Image {
+		dst := image.NewRGBA(img.Bounds())
+		for i := 0; i < len(dst.Pix); i += 4 {
+			dst.Pix[i], dst.Pix[i+3] = 255, 255
+		}
+		return dst
+	}
+	rect := Range{X0: 15, Y0: 25, X1: 30, Y1: 35}
+	inRect := func(x, y int) bool { return x >= 15 && x < 30 && y >= 25 && y < 35 }
+	cases := []struct {
+		name    string
+		opts    []ShapeOptions
+		changed func(x, y int) bool // 结果为红色的像素，其余像素保持原样
+		margin  int                 // 范围边缘附近不检查的宽度
+	}{
+		{"矩形", nil, inRect, 0},
+		{"反选", []ShapeOptions{{Invert: true}}, func(x, y int) bool { return !inRect(x, y) }, 0},
+		{"羽化", []ShapeOptions{{Feather: 2}}, inRect, 3},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dst, err := Region(src, rect, paint, c.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dst.Bounds() != src.Bounds() {
+				t.Fatalf("Bounds = %v, want %v", dst.Bounds(), src.Bounds())
+			}
+			for y := 20; y < 45; y++ {
+				for x := 10; x < 40; x++ {
+					if c.margin > 0 && nearEdge(inRect, x, y, c.margin) {
+						continue
+					}
+					want := src.RGBAAt(x, y)
+					if c.changed(x, y) {
+						want = red
+					}
+					if got := color.RGBAModel.Convert(dst.At(x, y)); got != want {
+						t.Fatalf("(%d, %d) = %v, want %v", x, y, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+// nearEdge (x, y) 周围 margin 以内是否有与其内外不同的像素
+func nearEdge(inside func(x, y int) bool, x, y, margin int) bool {
+	for dy := -margin; dy <= margin; dy++ {
+		for dx := -margin; dx <= margin; dx++ {
+			if inside(x+dx, y+dy) != inside(x, y) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestRegionErrors(t *testing.T) {
+	src := uniformRGBA(20, 20, color.RGBA{A: 255})
+	rect := Range{X0: 5, Y0: 5, X1: 15, Y1: 15}
+	cases := []struct {
+		name string
+		run  func() error
+	}{
+		{"处理函数为空", func() error {
+			_, err := Region(src, rect, nil)
+			return err
+		}},
+		{"改变尺寸", func() error {
+			_, err := Region(src, rect, func(img image.Image) image.Image { return Crop(img, 0, 0, 10, 10) })
+			return err
+		}},
+		{"操作为空", func() error { return NewImgCanvas(src).Ext(OpsRegion(rect, nil)).Err }},
+		{"操作改变尺寸", func() error { return NewImgCanvas(src).Ext(OpsRegion(rect, OpsScaleAuto(10, 10))).Err }},
+		{"操作返回错误", func() error {
+			return NewImgCanvas(src).Ext(OpsRegion(rect, func(ctx *CanvasContext) error { return errors.New("失败") })).Err
+		}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.run(); err == nil {
+				t.Error("want error")
+			}
+		})
+	}
+}
+
+func TestOpsRegion(t *testing.T) {
+	src := imageToRGBA(noisyImage(image.Rect(0, 0, 30, 20)))
+	circle := RangeCircle{Cx: 15, Cy: 10, R: 6}
+	ctx := NewImgCanvas(src).Ext(OpsRegion(circle, OpsColorReversal()))
+	if ctx.Err != nil {
+		t.Fatal(ctx.Err)
+	}
+	reversed := imageToRGBA(ColorReversal(src))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 30; x++ {
+			dx, dy := x-15, y-10
+			d2 := dx*dx + dy*dy
+			switch {
+			case d2 <= 25:
+				if got, want := ctx.Dst.RGBAAt(x, y), reversed.RGBAAt(x, y); got != want {
+					t.Fatalf("圆内 (%d, %d) = %v, want %v", x, y, got, want)
+				}
+			case d2 >= 49:
+				if got, want := ctx.Dst.RGBAAt(x, y), src.RGBAAt(x, y); got != want {
+					t.Fatalf("圆外 (%d, %d) = %v, want %v", x, y, got, want)
+				}
+			}
+		}
+	}
+
+	// 范围在图像以外时不执行操作
+	called := false
+	ctx = NewImgCanvas(src).Ext(OpsRegion(Range{X0: 50, Y0: 50, X1: 60, Y1: 60}, func(ctx *CanvasContext) error {
+		called = true
+		return nil
+	}))
+	if ctx.Err != nil || called {
+		t.Errorf("范围在图像以外: err = %v, 执行了操作 %v", ctx.Err, called)
+	}
+}
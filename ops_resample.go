@@ -0,0 +1,296 @@
+package imgHelper
+
+import (
+	"image"
+	"math"
+)
+
+// ResampleFilter 重采样滤波器
+type ResampleFilter int
+
+const (
+	FilterLanczos  ResampleFilter = iota // Lanczos-3，锐利，适合缩小
+	FilterMitchell                       // Mitchell-Netravali(B=C=1/3)，比 Lanczos 柔和，振铃更少
+	FilterArea                           // 区域平均，按覆盖面积加权，缩小时无混叠
+)
+
+// ScaleLanczos Lanczos-3 插值缩放，在线性光空间中计算，缩小时滤波器随缩小比例展宽以避免混叠
+func ScaleLanczos(src image.Image, targetWidth, targetHeight int) image.Image {
+	return ScaleWithFilter(src, targetWidth, targetHeight, FilterLanczos)
+}
+
+// ScaleMitchell Mitchell-Netravali 插值缩放，在线性光空间中计算
+func ScaleMitchell(src image.Image, targetWidth, targetHeight int) image.Image {
+	return ScaleWithFilter(src, targetWidth, targetHeight, FilterMitchell)
+}
+
+// ScaleArea 区域平均缩放，每个目标像素为其覆盖的源像素按面积加权的平均值，在线性光空间中计算
+func ScaleArea(src image.Image, targetWidth, targetHeight int) image.Image {
+	return ScaleWithFilter(src, targetWidth, targetHeight, FilterArea)
+}
+
+// ScaleWithFilter 使用指定的重采样滤波器缩放，在线性光空间中计算
+func ScaleWithFilter(src image.Image, targetWidth, targetHeight int, filter ResampleFilter) image.Image {
+	if targetWidth <= 0 || targetHeight <= 0 || src.Bounds().Empty() {
+		return image.NewRGBA(image.Rect(0, 0, maxValue(targetWidth, 0), maxValue(targetHeight, 0)))
+	}
+	plane, w, h := linearPlane(src)
+	plane = resamplePlane(plane, w, h, targetWidth, targetHeight, filter)
+	return linearPlaneToRGBA(plane, targetWidth, targetHeight)
+}
+
+// ScaleAuto 自动选择策略的高质量缩放：大比例缩小时先在线性光空间中按2的整数次幂倍区域平均快速缩小(相当于反复减半，速度快且无混叠)，
+// 直到剩余的缩小比例不足2倍，再用 Lanczos-3 完成最后一步，适合生成缩略图
+func ScaleAuto(src image.Image, targetWidth, targetHeight int) image.Image {
+	if targetWidth <= 0 || targetHeight <= 0 || src.Bounds().Empty() {
+		return image.NewRGBA(image.Rect(0, 0, maxValue(targetWidth, 0), maxValue(targetHeight, 0)))
+	}
+	fx, fy := 1, 1
+	for src.Bounds().Dx()/(fx*2) >= targetWidth {
+		fx *= 2
+	}
+	for src.Bounds().Dy()/(fy*2) >= targetHeight {
+		fy *= 2
+	}
+	plane, w, h := linearPlaneReduced(src, fx, fy)
+	plane = resamplePlane(plane, w, h, targetWidth, targetHeight, FilterLanczos)
+	return linearPlaneToRGBA(plane, targetWidth, targetHeight)
+}
+
+// srgbToLinearTable sRGB 分量到线性光的查找表
+var srgbToLinearTable = func() [256]float32 {
+	var table [256]float32
+	for i := range table {
+		c := float64(i) / 255
+		if c <= 0.04045 {
+			table[i] = float32(c / 12.92)
+		} else {
+			table[i] = float32(math.Pow((c+0.055)/1.055, 2.4))
+		}
+	}
+	return table
+}()
+
+// linearToSRGBTable 线性光到 sRGB 分量的查找表，下标为线性值乘以 linearToSRGBSteps
+const linearToSRGBSteps = 4096
+
+var linearToSRGBTable = func() [linearToSRGBSteps + 1]uint8 {
+	var table [linearToSRGBSteps + 1]uint8
+	for i := range table {
+		c := float64(i) / linearToSRGBSteps
+		if c <= 0.0031308 {
+			c *= 12.92
+		} else {
+			c = 1.055*math.Pow(c, 1/2.4) - 0.055
+		}
+		table[i] = uint8(clamp(int(math.Round(c*255)), 0, 255))
+	}
+	return table
+}()
+
+// linearPlane 将图像转换为线性光、预乘 Alpha 的 RGBA 浮点数据，每个像素4个值
+func linearPlane(src image.Image) ([]float32, int, int) {
+	return linearPlaneReduced(src, 1, 1)
+}
+
+// linearPlaneReduced 转换为线性光数据的同时按 fx*fy 的块求平均进行缩小，不能整除时最后一块包含剩余的像素
+func linearPlaneReduced(src image.Image, fx, fy int) ([]float32, int, int) {
+	nrgba := imageToNRGBA(src)
+	w, h := nrgba.Bounds().Dx(), nrgba.Bounds().Dy()
+	nw, nh := maxValue(w/fx, 1), maxValue(h/fy, 1)
+	plane := make([]float32, nw*nh*4)
+	for y := 0; y < h; y++ {
+		by := minValue(y/fy, nh-1)
+		for x := 0; x < w; x++ {
+			o := (y*w + x) * 4
+			p := (by*nw + minValue(x/fx, nw-1)) * 4
+			a := float32(nrgba.Pix[o+3]) / 255
+			plane[p] += srgbToLinearTable[nrgba.Pix[o]] * a
+			plane[p+1] += srgbToLinearTable[nrgba.Pix[o+1]] * a
+			plane[p+2] += srgbToLinearTable[nrgba.Pix[o+2]] * a
+			plane[p+3] += a
+		}
+	}
+	if fx == 1 && fy == 1 {
+		return plane, nw, nh
+	}
+	for by := 0; by < nh; by++ {
+		rows := fy
+		if by == nh-1 {
+			rows = h - by*fy
+		}
+		for bx := 0; bx < nw; bx++ {
+			cols := fx
+			if bx == nw-1 {
+				cols = w - bx*fx
+			}
+			n := float32(rows * cols)
+			p := (by*nw + bx) * 4
+			plane[p] /= n
+			plane[p+1] /= n
+			plane[p+2] /= n
+			plane[p+3] /= n
+		}
+	}
+	return plane, nw, nh
+}
+
+// linearPlaneToRGBA 将线性光、预乘 Alpha 的浮点数据转换回 sRGB 的 *image.RGBA
+func linearPlaneToRGBA(plane []float32, w, h int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < w*h; i++ {
+		o := i * 4
+		a := plane[o+3]
+		if a <= 0 {
+			continue
+		}
+		if a > 1 {
+			a = 1
+		}
+		// 先去掉预乘转换为 sRGB，再按 *image.RGBA 的要求重新预乘
+		for c := 0; c < 3; c++ {
+			v := plane[o+c] / a
+			idx := clamp(int(v*linearToSRGBSteps+0.5), 0, linearToSRGBSteps)
+			dst.Pix[o+c] = uint8(float32(linearToSRGBTable[idx])*a + 0.5)
+		}
+		dst.Pix[o+3] = uint8(a*255 + 0.5)
+	}
+	return dst
+}
+
+// resampleWeights 一维重采样的权重，目标像素 i 的值为 sum(weights[k] * src[index[k]])，k 在 offset[i] 到 offset[i+1] 之间
+type resampleWeights struct {
+	offset  []int
+	index   []int
+	weights []float32
+}
+
+// resampleKernel 返回滤波器的核函数和支撑半径
+func resampleKernel(filter ResampleFilter) (func(float64) float64, float64) {
+	switch filter {
+	case FilterMitchell:
+		return mitchellKernel, 2
+	default:
+		return lanczos3Kernel, 3
+	}
+}
+
+func lanczos3Kernel(x float64) float64 {
+	x = math.Abs(x)
+	if x < 1e-8 {
+		return 1
+	}
+	if x >= 3 {
+		return 0
+	}
+	px := math.Pi * x
+	return 3 * math.Sin(px) * math.Sin(px/3) / (px * px)
+}
+
+func mitchellKernel(x float64) float64 {
+	const b, c = 1.0 / 3, 1.0 / 3
+	x = math.Abs(x)
+	switch {
+	case x < 1:
+		return ((12-9*b-6*c)*x*x*x + (-18+12*b+6*c)*x*x + (6 - 2*b)) / 6
+	case x < 2:
+		return ((-b-6*c)*x*x*x + (6*b+30*c)*x*x + (-12*b-48*c)*x + (8*b + 24*c)) / 6
+	}
+	return 0
+}
+
+// newResampleWeights 计算从 srcLen 个像素重采样到 dstLen 个像素的权重，超出范围的源像素取最近的边缘像素
+func newResampleWeights(srcLen, dstLen int, filter ResampleFilter) *resampleWeights {
+	rw := &resampleWeights{offset: make([]int, dstLen+1)}
+	ratio := float64(srcLen) / float64(dstLen)
+	for i := 0; i < dstLen; i++ {
+		start := len(rw.index)
+		if filter == FilterArea {
+			// 目标像素覆盖源图像的 [lo, hi) 区间，按重叠长度加权
+			lo, hi := float64(i)*ratio, float64(i+1)*ratio
+			for j := int(lo); j < srcLen && float64(j) < hi; j++ {
+				overlap := math.Min(hi, float64(j+1)) - math.Max(lo, float64(j))
+				if overlap > 0 {
+					rw.index = append(rw.index, j)
+					rw.weights = append(rw.weights, float32(overlap))
+				}
+			}
+		} else {
+			kernel, support := resampleKernel(filter)
+			// 缩小时按比例展宽滤波器，起到低通滤波的作用
+			scale := math.Max(ratio, 1)
+			center := (float64(i)+0.5)*ratio - 0.5
+			radius := support * scale
+			for j := int(math.Ceil(center - radius)); j <= int(math.Floor(center+radius)); j++ {
+				wt := kernel((float64(j) - center) / scale)
+				if wt == 0 {
+					continue
+				}
+				idx := clamp(j, 0, srcLen-1)
+				if n := len(rw.index); n > start && rw.index[n-1] == idx {
+					rw.weights[n-1] += float32(wt)
+				} else {
+					rw.index = append(rw.index, idx)
+					rw.weights = append(rw.weights, float32(wt))
+				}
+			}
+		}
+		// 归一化，保证权重之和为1
+		var sum float32
+		for _, wt := range rw.weights[start:] {
+			sum += wt
+		}
+		if sum != 0 {
+			for k := start; k < len(rw.weights); k++ {
+				rw.weights[k] /= sum
+			}
+		}
+		rw.offset[i+1] = len(rw.index)
+	}
+	return rw
+}
+
+// resamplePlane 先水平后垂直地对4通道浮点数据进行可分离的重采样
+func resamplePlane(plane []float32, w, h, dw, dh int, filter ResampleFilter) []float32 {
+	if w == dw && h == dh {
+		return plane
+	}
+	tmp := make([]float32, dw*h*4)
+	xw := newResampleWeights(w, dw, filter)
+	for y := 0; y < h; y++ {
+		for x := 0; x < dw; x++ {
+			var sum [4]float32
+			for k := xw.offset[x]; k < xw.offset[x+1]; k++ {
+				o := (y*w + xw.index[k]) * 4
+				wt := xw.weights[k]
+				sum[0] += plane[o] * wt
+				sum[1] += plane[o+1] * wt
+				sum[2] += plane[o+2] * wt
+				sum[3] += plane[o+3] * wt
+			}
+			o := (y*dw + x) * 4
+			copy(tmp[o:o+4], sum[:])
+		}
+	}
+	out := make([]float32, dw*dh*4)
+	yw := newResampleWeights(h, dh, filter)
+	for y := 0; y < dh; y++ {
+		for k := yw.offset[y]; k < yw.offset[y+1]; k++ {
+			row := tmp[yw.index[k]*dw*4 : (yw.index[k]+1)*dw*4]
+			wt := yw.weights[k]
+			dst := out[y*dw*4 : (y+1)*dw*4]
+			for i, v := range row {
+				dst[i] += v * wt
+			}
+		}
+	}
+	// Lanczos 等滤波器有负权重，结果可能略微超出范围
+	for i, v := range out {
+		if v < 0 {
+			out[i] = 0
+		} else if v > 1 {
+			out[i] = 1
+		}
+	}
+	return out
+}
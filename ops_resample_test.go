@@ -0,0 +1,127 @@
+package imgHelper
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+var resampleFuncs = []struct {
+	name  string
+	scale func(src image.Image, w, h int) image.Image
+}{
+	{"ScaleLanczos", ScaleLanczos},
+	{"ScaleMitchell", ScaleMitchell},
+	{"ScaleArea", ScaleArea},
+	{"ScaleAuto", ScaleAuto},
+}
+
+func TestResampleKernel(t *testing.T) {
+	for _, filter := range []ResampleFilter{FilterLanczos, FilterMitchell} {
+		kernel, support := resampleKernel(filter)
+		// 整数点采样之和为1，平移任意小数时也接近1
+		for _, shift := range []float64{0, 0.25, 0.5} {
+			sum := 0.0
+			for i := -int(support) - 1; i <= int(support)+1; i++ {
+				sum += kernel(float64(i) + shift)
+			}
+			if math.Abs(sum-1) > 0.02 {
+				t.Errorf("滤波器 %d 平移 %g: 权重和 %g, want 1", filter, shift, sum)
+			}
+		}
+	}
+	if lanczos3Kernel(0) != 1 || math.Abs(lanczos3Kernel(1)) > 1e-9 || lanczos3Kernel(3) != 0 {
+		t.Error("Lanczos-3 应在0处为1，在其他整数点为0")
+	}
+}
+
+func TestResampleKeepsUniformImage(t *testing.T) {
+	c := color.RGBA{R: 200, G: 90, B: 30, A: 255}
+	src := uniformRGBA(64, 48, c)
+	src.Rect = src.Rect.Add(image.Pt(3, 5))
+	for _, f := range resampleFuncs {
+		for _, size := range []image.Point{{16, 12}, {7, 30}, {64, 48}, {150, 100}} {
+			dst := f.scale(src, size.X, size.Y).(*image.RGBA)
+			if dst.Bounds() != (image.Rectangle{Max: size}) {
+				t.Fatalf("%s %v: Bounds = %v", f.name, size, dst.Bounds())
+			}
+			for i := 0; i < len(dst.Pix); i += 4 {
+				got := color.RGBA{R: dst.Pix[i], G: dst.Pix[i+1], B: dst.Pix[i+2], A: dst.Pix[i+3]}
+				if absDiff(got.R, c.R) > 1 || absDiff(got.G, c.G) > 1 || absDiff(got.B, c.B) > 1 || got.A != 255 {
+					t.Fatalf("%s %v: 像素 %d = %v, want %v", f.name, size, i/4, got, c)
+				}
+			}
+		}
+	}
+}
+
+func TestResampleLinearLight(t *testing.T) {
+	// 黑白相间的棋盘格缩小后，线性光的平均值为0.5，对应 sRGB 的188，而不是 sRGB 直接平均的128
+	src := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			v := uint8(0)
+			if (x+y)%2 == 0 {
+				v = 255
+			}
+			src.SetRGBA(x, y, color.RGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+	for _, f := range resampleFuncs {
+		for _, size := range []int{32, 16, 5} {
+			dst := f.scale(src, size, size).(*image.RGBA)
+			// 边缘像素受边界处理影响，只检查内部
+			for y := 1; y < size-1; y++ {
+				for x := 1; x < size-1; x++ {
+					if got := dst.RGBAAt(x, y).R; absDiff(got, 188) > 3 {
+						t.Fatalf("%s %d: (%d, %d) = %d, want 188", f.name, size, x, y, got)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestResamplePremultipliedAlpha(t *testing.T) {
+	// 透明像素的颜色不参与混合，半透明的结果不会偏暗
+	src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	src.SetRGBA(1, 0, color.RGBA{R: 255, A: 255})
+	dst := ScaleArea(src, 1, 1).(*image.RGBA)
+	got := color.NRGBAModel.Convert(dst.RGBAAt(0, 0)).(color.NRGBA)
+	if absDiff(got.A, 128) > 1 || got.R < 253 || got.G != 0 || got.B != 0 {
+		t.Errorf("结果 %v, want 半透明的红色", got)
+	}
+}
+
+func TestResampleEmpty(t *testing.T) {
+	src := uniformRGBA(10, 10, color.RGBA{A: 255})
+	cases := []struct {
+		name string
+		src  image.Image
+		w, h int
+		want image.Rectangle
+	}{
+		{"目标宽为0", src, 0, 5, image.Rect(0, 0, 0, 5)},
+		{"目标高为负", src, 5, -2, image.Rect(0, 0, 5, 0)},
+		{"源图像为空", image.NewRGBA(image.Rect(0, 0, 0, 0)), 4, 3, image.Rect(0, 0, 4, 3)},
+	}
+	for _, c := range cases {
+		for _, f := range resampleFuncs {
+			if got := f.scale(c.src, c.w, c.h).Bounds(); got != c.want {
+				t.Errorf("%s %s: Bounds = %v, want %v", c.name, f.name, got, c.want)
+			}
+		}
+	}
+}
+
+func TestOpsScaleAuto(t *testing.T) {
+	canvas := NewImgCanvas(uniformRGBA(300, 200, color.RGBA{R: 10, G: 200, B: 60, A: 255}))
+	canvas.Ext(OpsScaleAuto(30, 20))
+	if canvas.Err != nil {
+		t.Fatal(canvas.Err)
+	}
+	if canvas.Dst.Bounds() != image.Rect(0, 0, 30, 20) {
+		t.Errorf("Bounds = %v, want 30x20", canvas.Dst.Bounds())
+	}
+}
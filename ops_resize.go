@@ -0,0 +1,184 @@
+package imgHelper
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// Gravity 对齐方位，缩放后裁剪或填充时决定保留或放置内容的位置
+type Gravity int
+
+const (
+	GravityCenter    Gravity = iota // 居中（默认）
+	GravityNorth                    // 上
+	GravitySouth                    // 下
+	GravityWest                     // 左
+	GravityEast                     // 右
+	GravityNorthWest                // 左上
+	GravityNorthEast                // 右上
+	GravitySouthWest                // 左下
+	GravitySouthEast                // 右下
+)
+
+// offset 按对齐方位分配多余的空间，返回内容相对容器左上角的偏移
+func (g Gravity) offset(freeX, freeY int) image.Point {
+	p := image.Pt(freeX/2, freeY/2)
+	switch g {
+	case GravityWest, GravityNorthWest, GravitySouthWest:
+		p.X = 0
+	case GravityEast, GravityNorthEast, GravitySouthEast:
+		p.X = freeX
+	}
+	switch g {
+	case GravityNorth, GravityNorthWest, GravityNorthEast:
+		p.Y = 0
+	case GravitySouth, GravitySouthWest, GravitySouthEast:
+		p.Y = freeY
+	}
+	return p
+}
+
+func getGravity(gravity []Gravity) Gravity {
+	if len(gravity) > 0 {
+		return gravity[0]
+	}
+	return GravityCenter
+}
+
+// fitSize 保持宽高比缩放到 maxWidth*maxHeight 以内的尺寸，小于等于0的一边不限制
+func fitSize(w, h, maxWidth, maxHeight int) (int, int) {
+	scale := math.Inf(1)
+	if maxWidth > 0 {
+		scale = float64(maxWidth) / float64(w)
+	}
+	if maxHeight > 0 {
+		scale = math.Min(scale, float64(maxHeight)/float64(h))
+	}
+	if math.IsInf(scale, 1) {
+		return w, h
+	}
+	return maxValue(int(math.Round(float64(w)*scale)), 1), maxValue(int(math.Round(float64(h)*scale)), 1)
+}
+
+// ResizeFit 等比缩放，使图像完整地放入 width*height 以内(contain)，结果的一边与目标相等
+// width 或 height 小于等于0时只按另一边缩放
+// 本文件的各个缩放都使用 ScaleAuto，大比例缩小时先区域平均再 Lanczos-3，不会产生混叠
+func ResizeFit(src image.Image, width, height int) image.Image {
+	bounds := src.Bounds()
+	if bounds.Empty() {
+		return imageToRGBA(src)
+	}
+	w, h := fitSize(bounds.Dx(), bounds.Dy(), width, height)
+	return ScaleAuto(src, w, h)
+}
+
+// ResizeFill 等比缩放使图像铺满 width*height(cover)，再按对齐方位裁掉多余部分，结果大小为 width*height
+// width 或 height 小于等于0时等同于 ResizeFit
+// 参数:
+// - gravity 保留内容的方位，默认居中
+func ResizeFill(src image.Image, width, height int, gravity ...Gravity) image.Image {
+	bounds := src.Bounds()
+	if width <= 0 || height <= 0 || bounds.Empty() {
+		return ResizeFit(src, width, height)
+	}
+	// 源图像中与目标宽高比相同的最大区域，缩放后恰好铺满目标
+	scale := math.Max(float64(width)/float64(bounds.Dx()), float64(height)/float64(bounds.Dy()))
+	sw := minValue(int(math.Round(float64(width)/scale)), bounds.Dx())
+	sh := minValue(int(math.Round(float64(height)/scale)), bounds.Dy())
+	off := getGravity(gravity).offset(bounds.Dx()-sw, bounds.Dy()-sh)
+	sr := image.Rect(0, 0, sw, sh).Add(bounds.Min).Add(off)
+	return ScaleAuto(Crop(src, sr.Min.X, sr.Min.Y, sr.Max.X, sr.Max.Y), width, height)
+}
+
+// ResizePad 等比缩放使图像完整地放入 width*height 以内，再放到 width*height 的纯色背景上，结果大小为 width*height
+// width 或 height 小于等于0时等同于 ResizeFit
+// 参数:
+// - background 填充的背景色，可使用 color.Transparent 得到透明背景
+// - gravity 图像放置的方位，默认居中
+func ResizePad(src image.Image, width, height int, background color.Color, gravity ...Gravity) image.Image {
+	if width <= 0 || height <= 0 || src.Bounds().Empty() {
+		return ResizeFit(src, width, height)
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	return padOnto(dst, src, gravity)
+}
+
+// ResizePadBlur 与 ResizePad 相同，但填充的背景为图像本身铺满后的模糊版本，常用于竖图转横图等场景
+// 参数:
+// - sigma 背景模糊的高斯标准差，小于等于0时默认为目标长边的1/20
+// - gravity 图像放置的方位，默认居中
+func ResizePadBlur(src image.Image, width, height int, sigma float64, gravity ...Gravity) image.Image {
+	if width <= 0 || height <= 0 || src.Bounds().Empty() {
+		return ResizeFit(src, width, height)
+	}
+	if sigma <= 0 {
+		sigma = float64(maxValue(width, height)) / 20
+	}
+	backdrop := FastGaussianBlur(ResizeFill(src, width, height), sigma).(*image.RGBA)
+	return padOnto(backdrop, src, gravity)
+}
+
+// padOnto 将源图像等比缩放到 dst 以内，按对齐方位绘制到 dst 上
+func padOnto(dst *image.RGBA, src image.Image, gravity []Gravity) *image.RGBA {
+	bounds := src.Bounds()
+	width, height := dst.Bounds().Dx(), dst.Bounds().Dy()
+	w, h := fitSize(bounds.Dx(), bounds.Dy(), width, height)
+	off := getGravity(gravity).offset(width-w, height-h)
+	draw.Draw(dst, image.Rect(0, 0, w, h).Add(off), ScaleAuto(src, w, h), image.Point{}, draw.Over)
+	return dst
+}
+
+// ResizeLimit 只缩小不放大：图像超出 maxWidth*maxHeight 时等比缩小到以内，否则保持原尺寸
+// maxWidth 或 maxHeight 小于等于0时不限制该边
+func ResizeLimit(src image.Image, maxWidth, maxHeight int) image.Image {
+	bounds := src.Bounds()
+	if (maxWidth <= 0 || bounds.Dx() <= maxWidth) && (maxHeight <= 0 || bounds.Dy() <= maxHeight) {
+		dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+		draw.Draw(dst, dst.Bounds(), src, bounds.Min, draw.Src)
+		return dst
+	}
+	return ResizeFit(src, maxWidth, maxHeight)
+}
+
+// OpsResizeFit 等比缩放到 width*height 以内操作
+func OpsResizeFit(width, height int) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		ctx.Dst = ResizeFit(ctx.Dst, width, height).(*image.RGBA)
+		return nil
+	}
+}
+
+// OpsResizeFill 等比缩放铺满并裁剪到 width*height 操作
+func OpsResizeFill(width, height int, gravity ...Gravity) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		ctx.Dst = ResizeFill(ctx.Dst, width, height, gravity...).(*image.RGBA)
+		return nil
+	}
+}
+
+// OpsResizePad 等比缩放并用纯色填充到 width*height 操作
+func OpsResizePad(width, height int, background color.Color, gravity ...Gravity) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		ctx.Dst = ResizePad(ctx.Dst, width, height, background, gravity...).(*image.RGBA)
+		return nil
+	}
+}
+
+// OpsResizePadBlur 等比缩放并用模糊背景填充到 width*height 操作
+func OpsResizePadBlur(width, height int, sigma float64, gravity ...Gravity) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		ctx.Dst = ResizePadBlur(ctx.Dst, width, height, sigma, gravity...).(*image.RGBA)
+		return nil
+	}
+}
+
+// OpsResizeLimit 只缩小不放大操作
+func OpsResizeLimit(maxWidth, maxHeight int) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		ctx.Dst = ResizeLimit(ctx.Dst, maxWidth, maxHeight).(*image.RGBA)
+		return nil
+	}
+}
@@ -0,0 +1,136 @@
+package imgHelper
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestFitSize(t *testing.T) {
+	cases := []struct {
+		name             string
+		w, h, maxW, maxH int
+		wantW, wantH     int
+	}{
+		{"宽受限", 400, 200, 100, 100, 100, 50},
+		{"高受限", 200, 400, 100, 100, 50, 100},
+		{"放大", 40, 30, 80, 80, 80, 60},
+		{"只限制宽", 400, 200, 100, 0, 100, 50},
+		{"只限制高", 400, 200, 0, 50, 100, 50},
+		{"都不限制", 400, 200, 0, -1, 400, 200},
+		{"至少为1", 1000, 1, 10, 10, 10, 1},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if w, h := fitSize(c.w, c.h, c.maxW, c.maxH); w != c.wantW || h != c.wantH {
+				t.Errorf("fitSize = %dx%d, want %dx%d", w, h, c.wantW, c.wantH)
+			}
+		})
+	}
+}
+
+func TestGravityOffset(t *testing.T) {
+	cases := []struct {
+		g    Gravity
+		want image.Point
+	}{
+		{GravityCenter, image.Pt(5, 3)},
+		{GravityNorth, image.Pt(5, 0)},
+		{GravitySouth, image.Pt(5, 6)},
+		{GravityWest, image.Pt(0, 3)},
+		{GravityEast, image.Pt(10, 3)},
+		{GravityNorthWest, image.Pt(0, 0)},
+		{GravityNorthEast, image.Pt(10, 0)},
+		{GravitySouthWest, image.Pt(0, 6)},
+		{GravitySouthEast, image.Pt(10, 6)},
+	}
+	for _, c := range cases {
+		if got := c.g.offset(10, 6); got != c.want {
+			t.Errorf("Gravity %d: offset = %v, want %v", c.g, got, c.want)
+		}
+	}
+}
+
+func TestResizeSize(t *testing.T) {
+	src := noisyImage(image.Rect(10, 20, 410, 220)) // 400x200，非零原点
+	cases := []struct {
+		name string
+		dst  image.Image
+		want image.Point
+	}{
+		{"Fit", ResizeFit(src, 100, 100), image.Pt(100, 50)},
+		{"Fit 只限制高", ResizeFit(src, 0, 50), image.Pt(100, 50)},
+		{"Fill", ResizeFill(src, 100, 100), image.Pt(100, 100)},
+		{"Fill 放大", ResizeFill(src, 600, 600), image.Pt(600, 600)},
+		{"Fill 宽为0时等同于 Fit", ResizeFill(src, 0, 100), image.Pt(200, 100)},
+		{"Pad", ResizePad(src, 100, 100, color.White), image.Pt(100, 100)},
+		{"PadBlur", ResizePadBlur(src, 120, 90, 0), image.Pt(120, 90)},
+		{"Limit 缩小", ResizeLimit(src, 100, 0), image.Pt(100, 50)},
+		{"Limit 不放大", ResizeLimit(src, 800, 800), image.Pt(400, 200)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if c.dst.Bounds() != (image.Rectangle{Max: c.want}) {
+				t.Errorf("Bounds = %v, want %v", c.dst.Bounds(), image.Rectangle{Max: c.want})
+			}
+		})
+	}
+	// 只缩小不放大时内容不变
+	limited := ResizeLimit(src, 800, 800)
+	if got, want := color.NRGBAModel.Convert(limited.At(7, 9)), src.At(17, 29); got != want {
+		t.Errorf("ResizeLimit (7, 9) = %v, want %v", got, want)
+	}
+}
+
+func TestResizeFillGravity(t *testing.T) {
+	red, blue := color.RGBA{R: 255, A: 255}, color.RGBA{B: 255, A: 255}
+	src := splitImage(200, 100, 100, red, blue)
+	cases := []struct {
+		name        string
+		gravity     []Gravity
+		left, right color.RGBA
+	}{
+		{"左", []Gravity{GravityWest}, red, red},
+		{"右", []Gravity{GravityEast}, blue, blue},
+		{"默认居中", nil, red, blue},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dst := ResizeFill(src, 50, 50, c.gravity...).(*image.RGBA)
+			if got := dst.RGBAAt(2, 25); got != c.left {
+				t.Errorf("左侧 %v, want %v", got, c.left)
+			}
+			if got := dst.RGBAAt(47, 25); got != c.right {
+				t.Errorf("右侧 %v, want %v", got, c.right)
+			}
+		})
+	}
+}
+
+func TestResizePadGravity(t *testing.T) {
+	white, bg := color.RGBA{R: 255, G: 255, B: 255, A: 255}, color.RGBA{G: 128, A: 255}
+	src := uniformRGBA(100, 50, white)
+	// 100x50 缩放为 80x40，放在 80x80 的背景上
+	cases := []struct {
+		name             string
+		gravity          []Gravity
+		top, mid, bottom color.RGBA
+	}{
+		{"上", []Gravity{GravityNorth}, white, bg, bg},
+		{"下", []Gravity{GravitySouth}, bg, white, white},
+		{"默认居中", nil, bg, white, bg},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dst := ResizePad(src, 80, 80, bg, c.gravity...).(*image.RGBA)
+			for _, p := range []struct {
+				y    int
+				want color.RGBA
+			}{{2, c.top}, {40, c.mid}, {77, c.bottom}} {
+				if got := dst.RGBAAt(40, p.y); got != p.want {
+					t.Errorf("第 %d 行 %v, want %v", p.y, got, p.want)
+				}
+			}
+		})
+	}
+}
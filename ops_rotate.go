@@ -2,109 +2,132 @@ package imgHelper
 
 import (
 	"image"
-	"image/color"
 	"math"
 )
 
 // opsRotate 旋转操作
 type opsRotate struct {
 	Angle float64
+	Opts  []TransformOptions
 }
 
 // OpsRotate 旋转画布
-func OpsRotate(angle float64) func(ctx *CanvasContext) error {
+// 参数:
+// - angle 顺时针旋转的角度
+// - opts 插值方式、边界处理方式，以及是否保持原尺寸，可选
+func OpsRotate(angle float64, opts ...TransformOptions) func(ctx *CanvasContext) error {
 	ops := &opsRotate{
 		Angle: angle,
+		Opts:  opts,
 	}
 	return ops.Draw
 }
 
-// OpsRotate90 旋转画布90度的操作
+// OpsRotate90 旋转画布90度的操作，无损
 func OpsRotate90() func(ctx *CanvasContext) error {
-	return OpsRotate(90)
+	return func(ctx *CanvasContext) error {
+		ctx.Dst = Rotate90(ctx.Dst).(*image.RGBA)
+		return nil
+	}
 }
 
+// OpsRotate180 旋转画布180度的操作，无损
 func OpsRotate180() func(ctx *CanvasContext) error {
-	return OpsRotate(180)
+	return func(ctx *CanvasContext) error {
+		ctx.Dst = Rotate180(ctx.Dst).(*image.RGBA)
+		return nil
+	}
 }
 
+// OpsRotate270 旋转画布270度的操作，无损
 func OpsRotate270() func(ctx *CanvasContext) error {
-	return OpsRotate(270)
+	return func(ctx *CanvasContext) error {
+		ctx.Dst = Rotate270(ctx.Dst).(*image.RGBA)
+		return nil
+	}
 }
 
 func (layer *opsRotate) Draw(ctx *CanvasContext) error {
-	ctx.Dst = Rotate(ctx.Dst, layer.Angle).(*image.RGBA)
+	ctx.Dst = Rotate(ctx.Dst, layer.Angle, layer.Opts...).(*image.RGBA)
 	return nil
 }
 
-// Rotate 图像顺时针旋转，angle是旋转度
-func Rotate(src image.Image, angle float64) image.Image {
-	srcBounds := src.Bounds()
-	srcWidth := srcBounds.Dx()
-	srcHeight := srcBounds.Dy()
-
-	rad := angle * math.Pi / 180
-	cos := math.Cos(rad)
-	sin := math.Sin(rad)
-
-	x1 := math.Abs(float64(srcWidth)*cos) + math.Abs(float64(srcHeight)*sin)
-	y1 := math.Abs(float64(srcWidth)*sin) + math.Abs(float64(srcHeight)*cos)
-
-	dstWidth := int(math.Ceil(x1))
-	dstHeight := int(math.Ceil(y1))
-	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
-
-	// 计算旋转中心
-	srcCenterX := float64(srcWidth) / 2
-	srcCenterY := float64(srcHeight) / 2
-	dstCenterX := float64(dstWidth) / 2
-	dstCenterY := float64(dstHeight) / 2
-
-	// 遍历目标图像的每个像素
-	for y := 0; y < dstHeight; y++ {
-		for x := 0; x < dstWidth; x++ {
-			// 计算目标像素相对于旋转中心的坐标
-			dx := float64(x) - dstCenterX
-			dy := float64(y) - dstCenterY
-
-			// 逆向旋转得到源图像中的坐标
-			srcX := cos*dx + sin*dy + srcCenterX
-			srcY := -sin*dx + cos*dy + srcCenterY
-
-			// 检查源坐标是否在源图像范围内
-			if srcX >= 0 && srcX < float64(srcWidth) && srcY >= 0 && srcY < float64(srcHeight) {
-				// 双线性插值
-				x0 := int(math.Floor(srcX))
-				y0 := int(math.Floor(srcY))
-				x1 := x0 + 1
-				y1 := y0 + 1
-
-				if x1 >= srcWidth {
-					x1 = srcWidth - 1
-				}
-				if y1 >= srcHeight {
-					y1 = srcHeight - 1
-				}
+// Rotate 图像绕中心顺时针旋转，angle是旋转度
+// 默认扩展输出范围以容纳旋转后的完整图像，空白部分透明；TransformOptions.KeepSize 为 true 时保持原尺寸，超出部分被裁掉
+// angle 为90的整数倍时直接交换像素位置，无损且不需要插值
+// 参数:
+// - opts 插值方式、边界处理方式，以及是否保持原尺寸，可选
+func Rotate(src image.Image, angle float64, opts ...TransformOptions) image.Image {
+	bounds := src.Bounds()
+	if quarter := math.Mod(angle, 360) / 90; quarter == math.Trunc(quarter) {
+		// 保持原尺寸时，非正方形图像旋转90度或270度会被裁剪，仍走通用的变换
+		switch q := (int(quarter) + 4) % 4; {
+		case q == 0:
+			return permutePixels(src, false, false, false)
+		case q == 2:
+			return Rotate180(src)
+		case !getTransformOptions(opts).KeepSize || bounds.Dx() == bounds.Dy():
+			if q == 1 {
+				return Rotate90(src)
+			}
+			return Rotate270(src)
+		}
+	}
+	cx := float64(bounds.Min.X) + float64(bounds.Dx())/2
+	cy := float64(bounds.Min.Y) + float64(bounds.Dy())/2
+	dst, _, err := TransformImage(src, NewTransform().About(cx, cy).Rotate(angle), opts...)
+	if err != nil {
+		// 旋转变换总是可逆且有界的，不会走到这里
+		return image.NewRGBA(image.Rect(0, 0, 0, 0))
+	}
+	return dst
+}
 
-				srcColor00 := src.At(x0, y0)
-				srcColor01 := src.At(x0, y1)
-				srcColor10 := src.At(x1, y0)
-				srcColor11 := src.At(x1, y1)
+// Rotate90 图像顺时针旋转90度，直接交换像素位置，无损
+func Rotate90(src image.Image) image.Image {
+	return permutePixels(src, true, true, false)
+}
 
-				// 计算插值权重
-				u := srcX - float64(x0)
-				v := srcY - float64(y0)
+// Rotate180 图像旋转180度，直接交换像素位置，无损
+func Rotate180(src image.Image) image.Image {
+	return permutePixels(src, false, true, true)
+}
 
-				// 双线性插值计算颜色
-				r0, g0, b0, a0 := interpolateColor(srcColor00, srcColor10, u)
-				r1, g1, b1, a1 := interpolateColor(srcColor01, srcColor11, u)
-				r, g, b, a := interpolateColor(
-					color.RGBA{R: r0, G: g0, B: b0, A: a0},
-					color.RGBA{R: r1, G: g1, B: b1, A: a1},
-					v)
+// Rotate270 图像顺时针旋转270度(即逆时针旋转90度)，直接交换像素位置，无损
+func Rotate270(src image.Image) image.Image {
+	return permutePixels(src, true, false, true)
+}
 
-				dst.Set(x, y, color.RGBA{R: r, G: g, B: b, A: a})
+// permutePixels 按行列交换和翻转重新排列像素，直接复制 Pix 中的数据，结果从 (0,0) 开始
+// 参数:
+// - swap 交换行列(转置)，flipX 水平翻转，flipY 垂直翻转，先转置后翻转
+func permutePixels(src image.Image, swap, flipX, flipY bool) *image.RGBA {
+	rgba, ok := src.(*image.RGBA)
+	if !ok {
+		rgba = imageToRGBA(src)
+	}
+	bounds := rgba.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	dw, dh := w, h
+	if swap {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		row := rgba.Pix[rgba.PixOffset(bounds.Min.X, bounds.Min.Y+y):]
+		for x := 0; x < w; x++ {
+			tx, ty := x, y
+			if swap {
+				tx, ty = y, x
+			}
+			if flipX {
+				tx = dw - 1 - tx
+			}
+			if flipY {
+				ty = dh - 1 - ty
 			}
+			o := ty*dst.Stride + tx*4
+			copy(dst.Pix[o:o+4], row[x*4:x*4+4])
 		}
 	}
 	return dst
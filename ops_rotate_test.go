@@ -0,0 +1,83 @@
+package imgHelper
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// indexedImage 每个像素的颜色编码了自身坐标的测试图像
+func indexedImage(r image.Rectangle) *image.RGBA {
+	img := image.NewRGBA(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetRGBA(x, y, color.RGBA{R: uint8(x - r.Min.X), G: uint8(y - r.Min.Y), B: 7, A: 255})
+		}
+	}
+	return img
+}
+
+func TestRotatePermutation(t *testing.T) {
+	const w, h = 5, 3
+	cases := []struct {
+		name   string
+		rotate func(image.Image) image.Image
+		size   image.Point
+		// to 源像素 (x, y) 在结果中的位置
+		to func(x, y int) (int, int)
+	}{
+		{"Rotate90", Rotate90, image.Pt(h, w), func(x, y int) (int, int) { return h - 1 - y, x }},
+		{"Rotate180", Rotate180, image.Pt(w, h), func(x, y int) (int, int) { return w - 1 - x, h - 1 - y }},
+		{"Rotate270", Rotate270, image.Pt(h, w), func(x, y int) (int, int) { return y, w - 1 - x }},
+	}
+	for _, origin := range []image.Point{{}, {7, -4}} {
+		src := indexedImage(image.Rectangle{Max: image.Pt(w, h)}.Add(origin))
+		for _, c := range cases {
+			t.Run(fmt.Sprintf("%s/原点%v", c.name, origin), func(t *testing.T) {
+				dst := c.rotate(src).(*image.RGBA)
+				if got := dst.Bounds(); got != (image.Rectangle{Max: c.size}) {
+					t.Fatalf("Bounds = %v, want %v", got, image.Rectangle{Max: c.size})
+				}
+				for y := 0; y < h; y++ {
+					for x := 0; x < w; x++ {
+						tx, ty := c.to(x, y)
+						want := src.RGBAAt(x+origin.X, y+origin.Y)
+						if got := dst.RGBAAt(tx, ty); got != want {
+							t.Errorf("源像素 (%d, %d) 应在 (%d, %d)，得到 %v, want %v", x, y, tx, ty, got, want)
+						}
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestRotateMatchesPermutation(t *testing.T) {
+	// 任意角度的旋转在90度的整数倍时应与直接交换像素的结果一致
+	src := indexedImage(image.Rect(0, 0, 6, 4))
+	cases := []struct {
+		angle float64
+		want  image.Image
+	}{
+		{90, Rotate90(src)},
+		{180, Rotate180(src)},
+		{270, Rotate270(src)},
+		{-90, Rotate270(src)},
+	}
+	for _, c := range cases {
+		got := imageToRGBA(Rotate(src, c.angle, TransformOptions{Interpolation: InterpNearest}))
+		want := c.want.(*image.RGBA)
+		if got.Bounds().Size() != want.Bounds().Size() {
+			t.Fatalf("angle %g: 尺寸 %v, want %v", c.angle, got.Bounds().Size(), want.Bounds().Size())
+		}
+		for y := 0; y < want.Bounds().Dy(); y++ {
+			for x := 0; x < want.Bounds().Dx(); x++ {
+				g := got.RGBAAt(got.Bounds().Min.X+x, got.Bounds().Min.Y+y)
+				if w := want.RGBAAt(x, y); g != w {
+					t.Errorf("angle %g: (%d, %d) = %v, want %v", c.angle, x, y, g, w)
+				}
+			}
+		}
+	}
+}
@@ -36,6 +36,42 @@ func OpsScaleCatmullRom(targetWidth, targetHeight int) func(ctx *CanvasContext)
 	return ops.scaleCatmullRom
 }
 
+// OpsScaleLanczos Lanczos-3 插值缩放操作，见 ScaleLanczos
+func OpsScaleLanczos(targetWidth, targetHeight int) func(ctx *CanvasContext) error {
+	ops := &opsScale{
+		TargetWidth:  targetWidth,
+		TargetHeight: targetHeight,
+	}
+	return ops.scaleLanczos
+}
+
+// OpsScaleMitchell Mitchell-Netravali 插值缩放操作，见 ScaleMitchell
+func OpsScaleMitchell(targetWidth, targetHeight int) func(ctx *CanvasContext) error {
+	ops := &opsScale{
+		TargetWidth:  targetWidth,
+		TargetHeight: targetHeight,
+	}
+	return ops.scaleMitchell
+}
+
+// OpsScaleArea 区域平均缩放操作，见 ScaleArea
+func OpsScaleArea(targetWidth, targetHeight int) func(ctx *CanvasContext) error {
+	ops := &opsScale{
+		TargetWidth:  targetWidth,
+		TargetHeight: targetHeight,
+	}
+	return ops.scaleArea
+}
+
+// OpsScaleAuto 自动选择策略的高质量缩放操作，见 ScaleAuto
+func OpsScaleAuto(targetWidth, targetHeight int) func(ctx *CanvasContext) error {
+	ops := &opsScale{
+		TargetWidth:  targetWidth,
+		TargetHeight: targetHeight,
+	}
+	return ops.scaleAuto
+}
+
 func (layer *opsScale) scale(ctx *CanvasContext) error {
 	ctx.Dst = Scale(ctx.Dst, layer.TargetWidth, layer.TargetHeight).(*image.RGBA)
 	return nil
@@ -51,6 +87,26 @@ func (layer *opsScale) scaleCatmullRom(ctx *CanvasContext) error {
 	return nil
 }
 
+func (layer *opsScale) scaleLanczos(ctx *CanvasContext) error {
+	ctx.Dst = ScaleLanczos(ctx.Dst, layer.TargetWidth, layer.TargetHeight).(*image.RGBA)
+	return nil
+}
+
+func (layer *opsScale) scaleMitchell(ctx *CanvasContext) error {
+	ctx.Dst = ScaleMitchell(ctx.Dst, layer.TargetWidth, layer.TargetHeight).(*image.RGBA)
+	return nil
+}
+
+func (layer *opsScale) scaleArea(ctx *CanvasContext) error {
+	ctx.Dst = ScaleArea(ctx.Dst, layer.TargetWidth, layer.TargetHeight).(*image.RGBA)
+	return nil
+}
+
+func (layer *opsScale) scaleAuto(ctx *CanvasContext) error {
+	ctx.Dst = ScaleAuto(ctx.Dst, layer.TargetWidth, layer.TargetHeight).(*image.RGBA)
+	return nil
+}
+
 // Scale 使用双线性插值算法将源图片拉伸或压缩到目标大小
 func Scale(src image.Image, targetWidth, targetHeight int) image.Image {
 	dst := image.NewRGBA(image.Rect(0, 0, targetWidth, targetHeight))
@@ -0,0 +1,322 @@
+package imgHelper
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// seamProtectEnergy 保护区域和移除区域附加的能量，远大于梯度能量，使接缝必然避开保护区域、优先穿过移除区域
+const seamProtectEnergy = 1e6
+
+// SeamCarveOptions 接缝裁剪参数
+type SeamCarveOptions struct {
+	Protect RangeValue // 保护区域，接缝不会穿过该区域(除非无法避开)，可选
+	Remove  RangeValue // 移除区域，先移除穿过该区域的接缝直到区域完全消失，用于去除物体，可选
+}
+
+// SeamCarve 接缝裁剪(内容感知缩放)：反复移除或插入能量最低的接缝来改变宽高，重要内容既不会被裁掉也不会被拉伸
+// 能量为像素的颜色梯度，用动态规划求出从上到下(或从左到右)能量之和最小的 8 连通接缝
+// 参数:
+// - width, height 目标宽高，小于等于0时保持原尺寸；比原图大时插入接缝放大
+// - opts 保护区域和移除区域
+func SeamCarve(src image.Image, width, height int, opts SeamCarveOptions) (image.Image, error) {
+	bounds := src.Bounds()
+	if bounds.Empty() {
+		return nil, fmt.Errorf("源图像为空")
+	}
+	if width <= 0 {
+		width = bounds.Dx()
+	}
+	if height <= 0 {
+		height = bounds.Dy()
+	}
+
+	img := newSeamImage(src)
+	if opts.Protect != nil {
+		mask, err := rangeMask(opts.Protect, bounds)
+		if err != nil {
+			return nil, err
+		}
+		img.addBias(mask, seamProtectEnergy)
+	}
+	if opts.Remove != nil {
+		mask, err := rangeMask(opts.Remove, bounds)
+		if err != nil {
+			return nil, err
+		}
+		img.addBias(mask, -seamProtectEnergy)
+		// 沿移除区域较窄的方向移除接缝，需要的接缝更少
+		r := maskBounds(mask)
+		vertical := r.Dx() <= r.Dy()
+		if !vertical {
+			img = img.transpose()
+		}
+		for img.w > 1 && img.hasRemoval() {
+			img.removeSeam(img.findSeam())
+		}
+		img.clearRemoval()
+		if !vertical {
+			img = img.transpose()
+		}
+	}
+
+	img.resizeWidth(width)
+	img = img.transpose()
+	img.resizeWidth(height)
+	img = img.transpose()
+	return img.toRGBA(), nil
+}
+
+// OpsSeamCarve 接缝裁剪操作
+// 参数:
+// - width, height 目标宽高，小于等于0时保持原尺寸
+// - opts 保护区域和移除区域
+func OpsSeamCarve(width, height int, opts SeamCarveOptions) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		dst, err := SeamCarve(ctx.Dst, width, height, opts)
+		if err != nil {
+			return err
+		}
+		ctx.Dst = dst.(*image.RGBA)
+		return nil
+	}
+}
+
+// seamImage 接缝裁剪过程中的图像，按行存储，只处理垂直接缝，水平方向通过转置实现
+type seamImage struct {
+	w, h int
+	pix  []color.NRGBA
+	bias []float64 // 附加能量，保护区域为正，移除区域为负
+	e    []float64 // 能量缓存，为 nil 时重新计算
+	cost []float64 // 动态规划的累计能量
+}
+
+func newSeamImage(src image.Image) *seamImage {
+	nrgba := imageToNRGBA(src)
+	w, h := nrgba.Bounds().Dx(), nrgba.Bounds().Dy()
+	img := &seamImage{w: w, h: h, pix: make([]color.NRGBA, w*h), bias: make([]float64, w*h)}
+	for i := range img.pix {
+		o := i * 4
+		img.pix[i] = color.NRGBA{R: nrgba.Pix[o], G: nrgba.Pix[o+1], B: nrgba.Pix[o+2], A: nrgba.Pix[o+3]}
+	}
+	return img
+}
+
+// addBias 按掩码值的比例附加能量
+func (img *seamImage) addBias(mask *image.Gray, energy float64) {
+	img.e = nil
+	for y := 0; y < img.h; y++ {
+		for x := 0; x < img.w; x++ {
+			if v := mask.Pix[y*mask.Stride+x]; v > 0 {
+				img.bias[y*img.w+x] += energy * float64(v) / 255
+			}
+		}
+	}
+}
+
+func (img *seamImage) hasRemoval() bool {
+	for _, b := range img.bias {
+		if b < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (img *seamImage) clearRemoval() {
+	img.e = nil
+	for i, b := range img.bias {
+		if b < 0 {
+			img.bias[i] = 0
+		}
+	}
+}
+
+func (img *seamImage) transpose() *seamImage {
+	t := &seamImage{w: img.h, h: img.w, pix: make([]color.NRGBA, len(img.pix)), bias: make([]float64, len(img.bias))}
+	for y := 0; y < img.h; y++ {
+		for x := 0; x < img.w; x++ {
+			t.pix[x*t.w+y] = img.pix[y*img.w+x]
+			t.bias[x*t.w+y] = img.bias[y*img.w+x]
+		}
+	}
+	return t
+}
+
+func (img *seamImage) toRGBA() *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, img.w, img.h))
+	for i, c := range img.pix {
+		r, g, b, a := c.RGBA()
+		o := i * 4
+		dst.Pix[o], dst.Pix[o+1], dst.Pix[o+2], dst.Pix[o+3] = uint8(r>>8), uint8(g>>8), uint8(b>>8), uint8(a>>8)
+	}
+	return dst
+}
+
+// energy 每个像素的能量：左右和上下相邻像素 RGB 差值的绝对值之和，加上附加能量
+func (img *seamImage) energy() []float64 {
+	if img.e == nil {
+		img.e = make([]float64, img.w*img.h)
+		for y := 0; y < img.h; y++ {
+			for x := 0; x < img.w; x++ {
+				img.e[y*img.w+x] = img.pixelEnergy(x, y)
+			}
+		}
+	}
+	return img.e
+}
+
+func (img *seamImage) pixelEnergy(x, y int) float64 {
+	w := img.w
+	diff := func(a, b color.NRGBA) float64 {
+		return float64(abs(int(a.R)-int(b.R)) + abs(int(a.G)-int(b.G)) + abs(int(a.B)-int(b.B)))
+	}
+	up, down := maxValue(y-1, 0), minValue(y+1, img.h-1)
+	left, right := maxValue(x-1, 0), minValue(x+1, w-1)
+	return diff(img.pix[y*w+left], img.pix[y*w+right]) + diff(img.pix[up*w+x], img.pix[down*w+x]) + img.bias[y*w+x]
+}
+
+// findSeam 动态规划求能量之和最小的垂直接缝，返回每一行接缝所在的列
+func (img *seamImage) findSeam() []int {
+	w, h := img.w, img.h
+	if cap(img.cost) < w*h {
+		img.cost = make([]float64, w*h)
+	}
+	cost := img.cost[:w*h]
+	copy(cost, img.energy())
+	for y := 1; y < h; y++ {
+		prev := cost[(y-1)*w : y*w]
+		row := cost[y*w : (y+1)*w]
+		for x := range row {
+			best := prev[x]
+			if x > 0 && prev[x-1] < best {
+				best = prev[x-1]
+			}
+			if x < w-1 && prev[x+1] < best {
+				best = prev[x+1]
+			}
+			row[x] += best
+		}
+	}
+	seam := make([]int, h)
+	last := (h - 1) * w
+	for x := 1; x < w; x++ {
+		if cost[last+x] < cost[last+seam[h-1]] {
+			seam[h-1] = x
+		}
+	}
+	// 从最后一行回溯
+	for y := h - 2; y >= 0; y-- {
+		row := cost[y*w : (y+1)*w]
+		x := seam[y+1]
+		best := x
+		if x > 0 && row[x-1] < row[best] {
+			best = x - 1
+		}
+		if x < w-1 && row[x+1] < row[best] {
+			best = x + 1
+		}
+		seam[y] = best
+	}
+	return seam
+}
+
+// removeSeam 移除接缝，宽度减1，只重新计算接缝两侧受影响像素的能量
+func (img *seamImage) removeSeam(seam []int) {
+	w := img.w
+	hasEnergy := img.e != nil
+	n := 0
+	for y := 0; y < img.h; y++ {
+		for x := 0; x < w; x++ {
+			if x == seam[y] {
+				continue
+			}
+			img.pix[n] = img.pix[y*w+x]
+			img.bias[n] = img.bias[y*w+x]
+			if hasEnergy {
+				img.e[n] = img.e[y*w+x]
+			}
+			n++
+		}
+	}
+	img.w--
+	img.pix = img.pix[:n]
+	img.bias = img.bias[:n]
+	if !hasEnergy {
+		return
+	}
+	img.e = img.e[:n]
+	// 相邻行的接缝列相差不超过1，左右和上下的相邻像素发生变化的只有接缝附近的像素
+	for y := 0; y < img.h; y++ {
+		for x := maxValue(seam[y]-2, 0); x <= minValue(seam[y]+1, img.w-1); x++ {
+			img.e[y*img.w+x] = img.pixelEnergy(x, y)
+		}
+	}
+}
+
+// resizeWidth 移除或插入垂直接缝，将宽度调整为 width
+func (img *seamImage) resizeWidth(width int) {
+	for img.w > width && img.w > 1 {
+		img.removeSeam(img.findSeam())
+	}
+	for img.w < width {
+		// 同一批插入的接缝互不重叠，每批最多插入当前宽度的一半，避免反复在同一处插入造成拉伸
+		img.insertSeams(minValue(width-img.w, maxValue(img.w/2, 1)))
+	}
+}
+
+// insertSeams 插入 k 条接缝：先在副本上依次找出 k 条能量最低的接缝，再在每条接缝右侧插入与左右像素平均的新像素
+func (img *seamImage) insertSeams(k int) {
+	w, h := img.w, img.h
+	work := &seamImage{w: w, h: h, pix: append([]color.NRGBA(nil), img.pix...), bias: append([]float64(nil), img.bias...)}
+	// origin 记录副本中每个像素在原图中的列
+	origin := make([]int, w*h)
+	for i := range origin {
+		origin[i] = i % w
+	}
+	dup := make([]int, w*h) // 每个像素右侧需要插入的像素个数
+	for s := 0; s < k && work.w > 0; s++ {
+		seam := work.findSeam()
+		n := 0
+		for y := 0; y < h; y++ {
+			dup[y*w+origin[y*work.w+seam[y]]]++
+			for x := 0; x < work.w; x++ {
+				if x != seam[y] {
+					origin[n] = origin[y*work.w+x]
+					n++
+				}
+			}
+		}
+		work.removeSeam(seam)
+	}
+
+	nw := w + k
+	pix := make([]color.NRGBA, 0, nw*h)
+	bias := make([]float64, 0, nw*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := img.pix[y*w+x]
+			pix = append(pix, c)
+			bias = append(bias, img.bias[y*w+x])
+			if dup[y*w+x] == 0 {
+				continue
+			}
+			next := img.pix[y*w+minValue(x+1, w-1)]
+			avg := color.NRGBA{
+				R: uint8((int(c.R) + int(next.R)) / 2),
+				G: uint8((int(c.G) + int(next.G)) / 2),
+				B: uint8((int(c.B) + int(next.B)) / 2),
+				A: uint8((int(c.A) + int(next.A)) / 2),
+			}
+			for d := 0; d < dup[y*w+x]; d++ {
+				pix = append(pix, avg)
+				bias = append(bias, img.bias[y*w+x])
+			}
+		}
+	}
+	img.w = nw
+	img.pix = pix
+	img.bias = bias
+	img.e = nil
+}
@@ -0,0 +1,112 @@
+package imgHelper
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// blockImage 带噪声的背景上画一个纯色方块，方块内部能量为0，没有保护时接缝会优先穿过方块
+func blockImage(w, h int, block image.Rectangle, c color.RGBA) *image.RGBA {
+	img := imageToRGBA(noisyImage(image.Rect(0, 0, w, h)))
+	for y := block.Min.Y; y < block.Max.Y; y++ {
+		for x := block.Min.X; x < block.Max.X; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func countColor(img image.Image, c color.RGBA) int {
+	n := 0
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if color.RGBAModel.Convert(img.At(x, y)) == c {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func TestSeamCarveSize(t *testing.T) {
+	src := noisyImage(image.Rect(5, 5, 45, 35))
+	cases := []struct {
+		name          string
+		width, height int
+		want          image.Point
+	}{
+		{"缩小宽度", 25, 0, image.Pt(25, 30)},
+		{"缩小高度", 0, 12, image.Pt(40, 12)},
+		{"放大", 70, 45, image.Pt(70, 45)},
+		{"一边放大一边缩小", 20, 50, image.Pt(20, 50)},
+		{"保持原尺寸", 0, 0, image.Pt(40, 30)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dst, err := SeamCarve(src, c.width, c.height, SeamCarveOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dst.Bounds() != (image.Rectangle{Max: c.want}) {
+				t.Errorf("Bounds = %v, want %v", dst.Bounds(), image.Rectangle{Max: c.want})
+			}
+		})
+	}
+	if _, err := SeamCarve(image.NewRGBA(image.Rect(0, 0, 0, 0)), 10, 10, SeamCarveOptions{}); err == nil {
+		t.Error("空图像 want error")
+	}
+}
+
+func TestSeamCarveProtect(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	block := image.Rect(20, 10, 40, 30)
+	src := blockImage(60, 40, block, red)
+	area := block.Dx() * block.Dy()
+
+	// 作为对照，没有保护时接缝穿过能量为0的方块
+	dst, err := SeamCarve(src, 40, 0, SeamCarveOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := countColor(dst, red); n >= area {
+		t.Fatalf("没有保护时方块像素 %d, want < %d", n, area)
+	}
+
+	dst, err = SeamCarve(src, 40, 0, SeamCarveOptions{Protect: Range{X0: block.Min.X, Y0: block.Min.Y, X1: block.Max.X, Y1: block.Max.Y}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := countColor(dst, red); n != area {
+		t.Errorf("保护区域像素 %d, want %d", n, area)
+	}
+}
+
+func TestSeamCarveRemove(t *testing.T) {
+	blue := color.RGBA{B: 255, A: 255}
+	cases := []struct {
+		name  string
+		block image.Rectangle
+	}{
+		{"竖长物体", image.Rect(40, 8, 46, 30)},
+		// 较宽的物体沿水平方向移除接缝
+		{"横长物体", image.Rect(10, 20, 40, 25)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			src := blockImage(60, 40, c.block, blue)
+			dst, err := SeamCarve(src, 0, 0, SeamCarveOptions{Remove: Range{X0: c.block.Min.X, Y0: c.block.Min.Y, X1: c.block.Max.X, Y1: c.block.Max.Y}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			// 移除后再插入接缝恢复原尺寸
+			if dst.Bounds() != src.Bounds() {
+				t.Errorf("Bounds = %v, want %v", dst.Bounds(), src.Bounds())
+			}
+			if n := countColor(dst, blue); n != 0 {
+				t.Errorf("移除区域还剩 %d 个像素", n)
+			}
+		})
+	}
+}
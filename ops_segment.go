@@ -0,0 +1,452 @@
+package imgHelper
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"math/rand"
+)
+
+// 图像分割
+// 各分割方法均返回 *ComponentLabels，其中 Labels 为每个像素的区域标签，Components 为各区域的统计信息
+// 可通过 Mask(label) 取得区域掩码，或通过 Range(label) 直接用于 OpsCrop、OpsMosaic，RenderComponents 可用于查看分割结果
+
+// Watershed 基于标记的分水岭分割
+// 从标记区域出发，按颜色梯度由低到高淹没图像，不同标记的区域在梯度最大处(物体边缘)相遇
+// 常用于分离商品与背景：在商品内部和背景上各画一些标记，分割后取商品标签的掩码
+// 参数:
+// - markers 标记，尺寸需与 src 一致，标签0为待分割像素，其他标签为各区域的种子，可由 ConnectedComponents 从标记图中得到
+// 标签不要求连续，结果的 Components 按标签 1~最大标签 排列，没有像素的标签面积为0
+func Watershed(src image.Image, markers *ComponentLabels) (*ComponentLabels, error) {
+	nrgba := imageToNRGBA(src)
+	w, h := nrgba.Bounds().Dx(), nrgba.Bounds().Dy()
+	if markers == nil || markers.Width != w || markers.Height != h || len(markers.Labels) != w*h {
+		return nil, fmt.Errorf("标记的尺寸必须与源图像一致")
+	}
+	maxLabel := 0
+	for _, label := range markers.Labels {
+		if label < 0 {
+			return nil, fmt.Errorf("标记的标签不能为负数, 当前为 %d", label)
+		}
+		maxLabel = maxValue(maxLabel, label)
+	}
+	if maxLabel == 0 {
+		return nil, fmt.Errorf("至少需要一个标记区域")
+	}
+
+	// 颜色梯度：取 R,G,B 三个通道 Sobel 梯度幅值的最大值
+	grad := make([]float64, w*h)
+	for c := 0; c < 3; c++ {
+		plane := make([]float64, w*h)
+		for i := range plane {
+			plane[i] = float64(nrgba.Pix[i*4+c])
+		}
+		mag := gradient3x3(plane, w, h, 1, 2, src.Bounds()).magnitudePlane()
+		for i, m := range mag {
+			grad[i] = math.Max(grad[i], m)
+		}
+	}
+
+	// 分级队列，梯度量化到 levels 个等级，同一等级内先进先出
+	const levels = 1024
+	queues := make([][]int, levels)
+	levelOf := func(i int) int {
+		return minValue(int(grad[i]), levels-1)
+	}
+
+	labels := make([]int, w*h)
+	copy(labels, markers.Labels)
+	for i, label := range labels {
+		if label != 0 {
+			queues[levelOf(i)] = append(queues[levelOf(i)], i)
+		}
+	}
+	for level := 0; level < levels; level++ {
+		for len(queues[level]) > 0 {
+			cur := queues[level][0]
+			queues[level] = queues[level][1:]
+			cx, cy := cur%w, cur/w
+			for _, n := range neighbors4 {
+				nx, ny := cx+n.X, cy+n.Y
+				if nx < 0 || nx >= w || ny < 0 || ny >= h {
+					continue
+				}
+				ni := ny*w + nx
+				if labels[ni] != 0 {
+					continue
+				}
+				labels[ni] = labels[cur]
+				// 等级不低于当前等级，保证淹没过程单调
+				nl := maxValue(levelOf(ni), level)
+				queues[nl] = append(queues[nl], ni)
+			}
+		}
+	}
+	return newComponentLabels(labels, maxLabel, w, h, src.Bounds(), nrgba), nil
+}
+
+// OpsWatershed 基于标记的分水岭分割操作，输出以随机颜色渲染的分割结果
+func OpsWatershed(markers *ComponentLabels) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		labels, err := Watershed(ctx.Dst, markers)
+		if err != nil {
+			return err
+		}
+		ctx.Dst = RenderComponents(labels).(*image.RGBA)
+		return nil
+	}
+}
+
+// KMeansSegment k-means 颜色聚类分割
+// 在 Lab 颜色空间中将像素聚为 k 类，同一类的像素可能分布在图像的多个位置
+// 参数:
+// - k 聚类数量，常用 2~8
+// - maxIter 可选，最大迭代次数，默认为10
+func KMeansSegment(src image.Image, k int, maxIter ...int) (*ComponentLabels, error) {
+	if k < 1 {
+		return nil, fmt.Errorf("k 必须大于等于1, 当前为 %d", k)
+	}
+	iterations := 10
+	if len(maxIter) > 0 && maxIter[0] > 0 {
+		iterations = maxIter[0]
+	}
+	nrgba := imageToNRGBA(src)
+	w, h := nrgba.Bounds().Dx(), nrgba.Bounds().Dy()
+	n := w * h
+	if n == 0 {
+		return newComponentLabels(nil, 0, w, h, src.Bounds(), nrgba), nil
+	}
+	lab := labPlane(nrgba)
+	k = minValue(k, n)
+
+	// k-means++ 初始化，固定随机种子保证结果可复现
+	r := rand.New(rand.NewSource(1))
+	centers := make([][3]float64, 0, k)
+	first := r.Intn(n)
+	centers = append(centers, [3]float64{lab[first*3], lab[first*3+1], lab[first*3+2]})
+	dist := make([]float64, n)
+	for i := range dist {
+		dist[i] = math.Inf(1)
+	}
+	for len(centers) < k {
+		last := centers[len(centers)-1]
+		total := 0.0
+		for i := range dist {
+			dist[i] = math.Min(dist[i], labDistSq(lab[i*3:i*3+3], last))
+			total += dist[i]
+		}
+		if total == 0 {
+			break
+		}
+		target := r.Float64() * total
+		chosen := n - 1
+		for i, d := range dist {
+			target -= d
+			if target <= 0 {
+				chosen = i
+				break
+			}
+		}
+		centers = append(centers, [3]float64{lab[chosen*3], lab[chosen*3+1], lab[chosen*3+2]})
+	}
+
+	labels := make([]int, n)
+	for iter := 0; iter < iterations; iter++ {
+		changed := false
+		for i := 0; i < n; i++ {
+			best, bestDist := 0, math.Inf(1)
+			for c, center := range centers {
+				if d := labDistSq(lab[i*3:i*3+3], center); d < bestDist {
+					best, bestDist = c, d
+				}
+			}
+			if labels[i] != best+1 {
+				labels[i] = best + 1
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+		sums := make([][4]float64, len(centers))
+		for i, label := range labels {
+			s := &sums[label-1]
+			s[0] += lab[i*3]
+			s[1] += lab[i*3+1]
+			s[2] += lab[i*3+2]
+			s[3]++
+		}
+		for c, s := range sums {
+			if s[3] > 0 {
+				centers[c] = [3]float64{s[0] / s[3], s[1] / s[3], s[2] / s[3]}
+			}
+		}
+	}
+	return newComponentLabels(labels, len(centers), w, h, src.Bounds(), nrgba), nil
+}
+
+// OpsKMeansSegment k-means 颜色聚类分割操作，输出以随机颜色渲染的分割结果
+func OpsKMeansSegment(k int, maxIter ...int) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		labels, err := KMeansSegment(ctx.Dst, k, maxIter...)
+		if err != nil {
+			return err
+		}
+		ctx.Dst = RenderComponents(labels).(*image.RGBA)
+		return nil
+	}
+}
+
+// SLIC 超像素分割 (Simple Linear Iterative Clustering)
+// 将图像划分为大小相近、贴合物体边缘的超像素，每个超像素都是连通的
+// 参数:
+// - numSegments 期望的超像素数量
+// - compactness 紧凑度，越大超像素越规整，越小越贴合颜色边缘，常用 10~40
+func SLIC(src image.Image, numSegments int, compactness float64) (*ComponentLabels, error) {
+	if numSegments < 1 || compactness <= 0 {
+		return nil, fmt.Errorf("numSegments 必须大于等于1且 compactness 必须大于0, 当前为 %d, %v", numSegments, compactness)
+	}
+	nrgba := imageToNRGBA(src)
+	w, h := nrgba.Bounds().Dx(), nrgba.Bounds().Dy()
+	n := w * h
+	if n == 0 {
+		return newComponentLabels(nil, 0, w, h, src.Bounds(), nrgba), nil
+	}
+	lab := labPlane(nrgba)
+	step := maxValue(int(math.Sqrt(float64(n)/float64(numSegments))+0.5), 1)
+
+	// 聚类中心 [L, a, b, x, y]，初始放在网格上，并移动到 3x3 邻域内梯度最小的位置，避免落在边缘上
+	gradAt := func(x, y int) float64 {
+		x0, x1 := maxValue(x-1, 0), minValue(x+1, w-1)
+		y0, y1 := maxValue(y-1, 0), minValue(y+1, h-1)
+		return labDistSq(lab[(y*w+x1)*3:], [3]float64{lab[(y*w+x0)*3], lab[(y*w+x0)*3+1], lab[(y*w+x0)*3+2]}) +
+			labDistSq(lab[(y1*w+x)*3:], [3]float64{lab[(y0*w+x)*3], lab[(y0*w+x)*3+1], lab[(y0*w+x)*3+2]})
+	}
+	centers := make([][5]float64, 0)
+	for y := step / 2; y < h; y += step {
+		for x := step / 2; x < w; x += step {
+			bx, by, best := x, y, math.Inf(1)
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					nx, ny := x+dx, y+dy
+					if nx < 0 || nx >= w || ny < 0 || ny >= h {
+						continue
+					}
+					if g := gradAt(nx, ny); g < best {
+						bx, by, best = nx, ny, g
+					}
+				}
+			}
+			i := by*w + bx
+			centers = append(centers, [5]float64{lab[i*3], lab[i*3+1], lab[i*3+2], float64(bx), float64(by)})
+		}
+	}
+
+	labels := make([]int, n)
+	dist := make([]float64, n)
+	// 空间距离按 compactness/step 加权
+	spatial := (compactness / float64(step)) * (compactness / float64(step))
+	for iter := 0; iter < 10; iter++ {
+		for i := range dist {
+			dist[i] = math.Inf(1)
+		}
+		for c, center := range centers {
+			cx, cy := int(center[3]), int(center[4])
+			for y := maxValue(cy-step, 0); y < minValue(cy+step, h); y++ {
+				for x := maxValue(cx-step, 0); x < minValue(cx+step, w); x++ {
+					i := y*w + x
+					dx, dy := float64(x)-center[3], float64(y)-center[4]
+					d := labDistSq(lab[i*3:i*3+3], [3]float64{center[0], center[1], center[2]}) + (dx*dx+dy*dy)*spatial
+					if d < dist[i] {
+						dist[i] = d
+						labels[i] = c + 1
+					}
+				}
+			}
+		}
+		sums := make([][6]float64, len(centers))
+		for i, label := range labels {
+			if label == 0 {
+				continue
+			}
+			s := &sums[label-1]
+			s[0] += lab[i*3]
+			s[1] += lab[i*3+1]
+			s[2] += lab[i*3+2]
+			s[3] += float64(i % w)
+			s[4] += float64(i / w)
+			s[5]++
+		}
+		for c, s := range sums {
+			if s[5] > 0 {
+				centers[c] = [5]float64{s[0] / s[5], s[1] / s[5], s[2] / s[5], s[3] / s[5], s[4] / s[5]}
+			}
+		}
+	}
+
+	// 极少数未被任何中心的搜索窗口覆盖的像素先标为1，随后在连通性处理中重新编号或并入相邻的超像素
+	for i, label := range labels {
+		if label == 0 {
+			labels[i] = 1
+		}
+	}
+	labels, count := enforceConnectivity(labels, w, h, step*step/4)
+	return newComponentLabels(labels, count, w, h, src.Bounds(), nrgba), nil
+}
+
+// OpsSLIC SLIC 超像素分割操作，输出以随机颜色渲染的分割结果
+func OpsSLIC(numSegments int, compactness float64) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		labels, err := SLIC(ctx.Dst, numSegments, compactness)
+		if err != nil {
+			return err
+		}
+		ctx.Dst = RenderComponents(labels).(*image.RGBA)
+		return nil
+	}
+}
+
+// enforceConnectivity 将标签图中每个4连通块重新编号，小于 minSize 的块并入相邻的块，返回新的标签图和标签数量
+func enforceConnectivity(labels []int, w, h, minSize int) ([]int, int) {
+	result := make([]int, len(labels))
+	count := 0
+	stack := make([]int, 0, 1024)
+	segment := make([]int, 0, 1024)
+	for start := range labels {
+		if result[start] != 0 {
+			continue
+		}
+		// 记录一个已编号的相邻块，用于合并过小的块
+		adjacent := 0
+		sx, sy := start%w, start/w
+		for _, n := range neighbors4 {
+			nx, ny := sx+n.X, sy+n.Y
+			if nx >= 0 && nx < w && ny >= 0 && ny < h && result[ny*w+nx] != 0 {
+				adjacent = result[ny*w+nx]
+			}
+		}
+		count++
+		result[start] = count
+		stack = append(stack[:0], start)
+		segment = append(segment[:0], start)
+		for len(stack) > 0 {
+			cur := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			cx, cy := cur%w, cur/w
+			for _, n := range neighbors4 {
+				nx, ny := cx+n.X, cy+n.Y
+				if nx < 0 || nx >= w || ny < 0 || ny >= h {
+					continue
+				}
+				ni := ny*w + nx
+				if result[ni] == 0 && labels[ni] == labels[start] {
+					result[ni] = count
+					stack = append(stack, ni)
+					segment = append(segment, ni)
+				}
+			}
+		}
+		if len(segment) < minSize && adjacent != 0 {
+			for _, i := range segment {
+				result[i] = adjacent
+			}
+			count--
+		}
+	}
+	return result, count
+}
+
+// RegionGrow 区域生长分割
+// 从每个种子点出发，将4邻域中与区域平均颜色的距离不超过 tolerance 的像素并入区域
+// 种子 i 生长出的区域标签为 i+1，未被任何区域覆盖的像素标签为0
+// 参数:
+// - seeds 种子点，坐标与原图一致
+// - tolerance 颜色容差(RGB 欧氏距离)，常用 10~40
+func RegionGrow(src image.Image, seeds []Point, tolerance float64) (*ComponentLabels, error) {
+	if len(seeds) == 0 {
+		return nil, fmt.Errorf("至少需要一个种子点")
+	}
+	if tolerance < 0 {
+		return nil, fmt.Errorf("tolerance 不能小于0, 当前为 %v", tolerance)
+	}
+	nrgba := imageToNRGBA(src)
+	w, h := nrgba.Bounds().Dx(), nrgba.Bounds().Dy()
+	minX, minY := src.Bounds().Min.X, src.Bounds().Min.Y
+	labels := make([]int, w*h)
+	tolSq := tolerance * tolerance
+	queue := make([]int, 0, 1024)
+
+	for s, seed := range seeds {
+		sx, sy := seed.X-minX, seed.Y-minY
+		if sx < 0 || sx >= w || sy < 0 || sy >= h {
+			return nil, fmt.Errorf("种子点 (%d,%d) 超出图像范围", seed.X, seed.Y)
+		}
+		start := sy*w + sx
+		if labels[start] != 0 {
+			continue
+		}
+		label := s + 1
+		var sum [3]float64
+		count := 0.0
+		add := func(i int) {
+			labels[i] = label
+			sum[0] += float64(nrgba.Pix[i*4])
+			sum[1] += float64(nrgba.Pix[i*4+1])
+			sum[2] += float64(nrgba.Pix[i*4+2])
+			count++
+			queue = append(queue, i)
+		}
+		queue = queue[:0]
+		add(start)
+		for head := 0; head < len(queue); head++ {
+			cur := queue[head]
+			cx, cy := cur%w, cur/w
+			for _, n := range neighbors4 {
+				nx, ny := cx+n.X, cy+n.Y
+				if nx < 0 || nx >= w || ny < 0 || ny >= h {
+					continue
+				}
+				ni := ny*w + nx
+				if labels[ni] != 0 {
+					continue
+				}
+				dr := float64(nrgba.Pix[ni*4]) - sum[0]/count
+				dg := float64(nrgba.Pix[ni*4+1]) - sum[1]/count
+				db := float64(nrgba.Pix[ni*4+2]) - sum[2]/count
+				if dr*dr+dg*dg+db*db <= tolSq {
+					add(ni)
+				}
+			}
+		}
+	}
+	return newComponentLabels(labels, len(seeds), w, h, src.Bounds(), nrgba), nil
+}
+
+// OpsRegionGrow 区域生长分割操作，输出以随机颜色渲染的分割结果
+func OpsRegionGrow(seeds []Point, tolerance float64) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		labels, err := RegionGrow(ctx.Dst, seeds, tolerance)
+		if err != nil {
+			return err
+		}
+		ctx.Dst = RenderComponents(labels).(*image.RGBA)
+		return nil
+	}
+}
+
+// labPlane 将图像转换为 Lab 颜色平面，每个像素3个值，按行存储
+func labPlane(src *image.NRGBA) []float64 {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	lab := make([]float64, w*h*3)
+	for i := 0; i < w*h; i++ {
+		o := i * 4
+		lab[i*3], lab[i*3+1], lab[i*3+2] = rgbToLab(src.Pix[o], src.Pix[o+1], src.Pix[o+2])
+	}
+	return lab
+}
+
+// labDistSq Lab 颜色距离的平方
+func labDistSq(a []float64, b [3]float64) float64 {
+	d0, d1, d2 := a[0]-b[0], a[1]-b[1], a[2]-b[2]
+	return d0*d0 + d1*d1 + d2*d2
+}
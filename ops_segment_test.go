@@ -0,0 +1,194 @@
+package imgHelper
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// splitImage 左侧 split 列为 left 颜色，其余为 right 颜色
+func splitImage(w, h, split int, left, right color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x < split {
+				img.SetRGBA(x, y, left)
+			} else {
+				img.SetRGBA(x, y, right)
+			}
+		}
+	}
+	return img
+}
+
+func TestWatershed(t *testing.T) {
+	src := splitImage(12, 8, 5, color.RGBA{R: 200, G: 30, B: 30, A: 255}, color.RGBA{R: 30, G: 30, B: 200, A: 255})
+	cases := []struct {
+		name        string
+		left, right int
+	}{
+		{"连续标签", 1, 2},
+		// 标签不要求为 1~n，中间缺少的标签面积为0
+		{"不连续标签", 1, 5},
+		{"标签顺序相反", 3, 1},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			markers := &ComponentLabels{Width: 12, Height: 8, Labels: make([]int, 12*8)}
+			markers.Labels[4*12+1] = c.left
+			markers.Labels[4*12+10] = c.right
+			labels, err := Watershed(src, markers)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := maxValue(c.left, c.right); len(labels.Components) != want {
+				t.Fatalf("区域数 %d, want %d", len(labels.Components), want)
+			}
+			for y := 0; y < 8; y++ {
+				for x := 0; x < 12; x++ {
+					want := c.right
+					if x < 5 {
+						want = c.left
+					}
+					if got := labels.Label(x, y); got != want {
+						t.Fatalf("Label(%d, %d) = %d, want %d", x, y, got, want)
+					}
+				}
+			}
+			for _, comp := range labels.Components {
+				want := 0
+				switch comp.Label {
+				case c.left:
+					want = 5 * 8
+				case c.right:
+					want = 7 * 8
+				}
+				if comp.Area != want {
+					t.Errorf("标签 %d 面积 %d, want %d", comp.Label, comp.Area, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWatershedInvalidMarkers(t *testing.T) {
+	src := uniformRGBA(4, 3, color.RGBA{A: 255})
+	negative := make([]int, 12)
+	negative[5] = -1
+	cases := []struct {
+		name    string
+		markers *ComponentLabels
+	}{
+		{"nil", nil},
+		{"尺寸不一致", &ComponentLabels{Width: 3, Height: 3, Labels: make([]int, 9)}},
+		{"标签数量不足", &ComponentLabels{Width: 4, Height: 3, Labels: make([]int, 5)}},
+		{"没有标记", &ComponentLabels{Width: 4, Height: 3, Labels: make([]int, 12)}},
+		{"负数标签", &ComponentLabels{Width: 4, Height: 3, Labels: negative}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := Watershed(src, c.markers); err == nil {
+				t.Error("want error")
+			}
+		})
+	}
+}
+
+func TestKMeansSegment(t *testing.T) {
+	src := splitImage(10, 6, 4, color.RGBA{R: 250, G: 240, B: 10, A: 255}, color.RGBA{R: 10, G: 20, B: 120, A: 255})
+	labels, err := KMeansSegment(src, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(labels.Components) != 2 {
+		t.Fatalf("区域数 %d, want 2", len(labels.Components))
+	}
+	left, right := labels.Label(0, 0), labels.Label(9, 5)
+	if left == right {
+		t.Fatalf("两种颜色被分到同一类 %d", left)
+	}
+	for y := 0; y < 6; y++ {
+		for x := 0; x < 10; x++ {
+			want := right
+			if x < 4 {
+				want = left
+			}
+			if got := labels.Label(x, y); got != want {
+				t.Fatalf("Label(%d, %d) = %d, want %d", x, y, got, want)
+			}
+		}
+	}
+	if _, err := KMeansSegment(src, 0); err == nil {
+		t.Error("k 为0时 want error")
+	}
+	// k 大于像素数时按像素数聚类
+	if labels, err := KMeansSegment(uniformRGBA(2, 1, color.RGBA{A: 255}), 5); err != nil || len(labels.Components) > 2 {
+		t.Errorf("k 大于像素数: err = %v", err)
+	}
+}
+
+func TestSLIC(t *testing.T) {
+	src := noisyImage(image.Rect(0, 0, 40, 30))
+	labels, err := SLIC(src, 12, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := 0
+	for _, comp := range labels.Components {
+		if comp.Area == 0 {
+			t.Errorf("标签 %d 为空", comp.Label)
+		}
+		total += comp.Area
+	}
+	if total != 40*30 {
+		t.Errorf("区域面积之和 %d, want %d", total, 40*30)
+	}
+	if n := len(labels.Components); n < 6 || n > 24 {
+		t.Errorf("超像素数量 %d, want 接近12", n)
+	}
+	// 每个超像素都是连通的：按4连通重新编号后，区域数不变
+	relabeled, _ := enforceConnectivity(append([]int(nil), labels.Labels...), 40, 30, 0)
+	if count := maxLabelOf(relabeled); count != len(labels.Components) {
+		t.Errorf("连通区域数 %d, want %d", count, len(labels.Components))
+	}
+	if _, err := SLIC(src, 0, 10); err == nil {
+		t.Error("numSegments 为0时 want error")
+	}
+}
+
+func maxLabelOf(labels []int) int {
+	m := 0
+	for _, l := range labels {
+		m = maxValue(m, l)
+	}
+	return m
+}
+
+func TestRegionGrow(t *testing.T) {
+	src := splitImage(10, 6, 4, color.RGBA{R: 100, G: 100, B: 100, A: 255}, color.RGBA{R: 130, G: 100, B: 100, A: 255})
+	cases := []struct {
+		name      string
+		tolerance float64
+		areas     []int
+	}{
+		{"容差小于颜色差", 20, []int{4 * 6, 6 * 6}},
+		// 左侧区域一直生长到覆盖右侧，第二个种子已被占用
+		{"容差大于颜色差", 40, []int{10 * 6, 0}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			labels, err := RegionGrow(src, []Point{{X: 1, Y: 1}, {X: 8, Y: 4}}, c.tolerance)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, comp := range labels.Components {
+				if comp.Area != c.areas[i] {
+					t.Errorf("区域 %d 面积 %d, want %d", i+1, comp.Area, c.areas[i])
+				}
+			}
+		})
+	}
+	if _, err := RegionGrow(src, []Point{{X: 10, Y: 0}}, 10); err == nil {
+		t.Error("种子超出范围时 want error")
+	}
+}
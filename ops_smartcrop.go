@@ -0,0 +1,199 @@
+package imgHelper
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// SmartCropOptions 智能裁剪参数，零值字段使用默认值，权重小于0时不使用该项
+type SmartCropOptions struct {
+	EdgeWeight       float64     // 边缘(细节)密度的权重，默认为1
+	SaturationWeight float64     // 饱和度的权重，默认为0.5
+	SkinWeight       float64     // 肤色的权重，默认为1
+	Faces            bool        // 是否检测人脸，检测到的人脸会被优先保留
+	FaceWeight       float64     // 人脸的权重，默认为2，Faces 为 true 时有效
+	FaceOptions      FaceOptions // 人脸检测参数
+	MinScale         float64     // 候选窗口相对最大窗口的最小比例，取值 (0,1]，默认为1即只在最大窗口中选择位置
+}
+
+// smartCropAnalyseSize 评分在缩小到该尺寸以内的图像上进行
+const smartCropAnalyseSize = 128
+
+// smartCropOutsidePenalty 重要内容被裁掉时的扣分比例
+const smartCropOutsidePenalty = 0.5
+
+// SmartCrop 内容感知的智能裁剪，用于生成缩略图
+// 在保持目标宽高比的候选窗口中，按边缘密度、饱和度、肤色和人脸给窗口评分，取评分最高的窗口裁剪并用 ScaleAuto 缩放到 width*height
+// 返回裁剪结果和所选窗口在源图像中的范围
+// 参数:
+// - width, height 缩略图的宽高
+// - opts 智能裁剪参数，可选
+func SmartCrop(src image.Image, width, height int, opts ...SmartCropOptions) (image.Image, Range, error) {
+	rg, err := SmartCropRange(src, width, height, opts...)
+	if err != nil {
+		return nil, Range{}, err
+	}
+	cropped := Crop(src, rg.X0, rg.Y0, rg.X1, rg.Y1)
+	return ScaleAuto(cropped, width, height), rg, nil
+}
+
+// SmartCropRange 智能裁剪的窗口选择，返回与 width*height 宽高比相同、评分最高的窗口在源图像中的范围
+func SmartCropRange(src image.Image, width, height int, opts ...SmartCropOptions) (Range, error) {
+	if width <= 0 || height <= 0 {
+		return Range{}, fmt.Errorf("裁剪宽高必须大于0")
+	}
+	var opt SmartCropOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	edgeWeight := smartCropWeight(opt.EdgeWeight, 1)
+	saturationWeight := smartCropWeight(opt.SaturationWeight, 0.5)
+	skinWeight := smartCropWeight(opt.SkinWeight, 1)
+	faceWeight := smartCropWeight(opt.FaceWeight, 2)
+	minScale := opt.MinScale
+	if minScale <= 0 || minScale > 1 {
+		minScale = 1
+	}
+
+	bounds := src.Bounds()
+	W, H := bounds.Dx(), bounds.Dy()
+	if W == 0 || H == 0 {
+		return Range{}, fmt.Errorf("源图像为空")
+	}
+
+	// 在缩小的图像上计算每个像素的重要程度，缩小时做区域平均，避免细节混叠成虚假的边缘
+	ratio := math.Min(1, float64(smartCropAnalyseSize)/float64(maxValue(W, H)))
+	aw, ah := maxValue(int(math.Round(float64(W)*ratio)), 1), maxValue(int(math.Round(float64(H)*ratio)), 1)
+	small := imageToNRGBA(ScaleAuto(src, aw, ah))
+	importance := make([]float64, aw*ah)
+	if edgeWeight > 0 {
+		plane, _, _ := grayPlane(small)
+		for i, m := range gradient3x3(plane, aw, ah, 1, 2, small.Bounds()).magnitudePlane() {
+			importance[i] += edgeWeight * math.Min(m/255, 1)
+		}
+	}
+	if saturationWeight > 0 || skinWeight > 0 {
+		for i := range importance {
+			o := i * 4
+			r, g, b := small.Pix[o], small.Pix[o+1], small.Pix[o+2]
+			importance[i] += saturationWeight*saturationScore(r, g, b) + skinWeight*skinScore(r, g, b)
+		}
+	}
+	if opt.Faces && faceWeight > 0 {
+		faces, err := DetectFaces(src, opt.FaceOptions)
+		if err != nil {
+			return Range{}, err
+		}
+		for _, f := range faces {
+			rect := image.Rect(
+				int(float64(f.Rect.X0-bounds.Min.X)*ratio), int(float64(f.Rect.Y0-bounds.Min.Y)*ratio),
+				int(math.Ceil(float64(f.Rect.X1-bounds.Min.X)*ratio)), int(math.Ceil(float64(f.Rect.Y1-bounds.Min.Y)*ratio)),
+			).Intersect(image.Rect(0, 0, aw, ah))
+			for y := rect.Min.Y; y < rect.Max.Y; y++ {
+				for x := rect.Min.X; x < rect.Max.X; x++ {
+					importance[y*aw+x] += faceWeight
+				}
+			}
+		}
+	}
+	var total float64
+	for _, v := range importance {
+		total += v
+	}
+
+	// 源图像中与目标宽高比相同的最大窗口
+	aspect := float64(width) / float64(height)
+	maxW, maxH := float64(W), float64(W)/aspect
+	if maxH > float64(H) {
+		maxW, maxH = float64(H)*aspect, float64(H)
+	}
+
+	best := math.Inf(-1)
+	var bestX, bestY, bestW, bestH float64
+	for scale := 1.0; scale >= minScale-1e-9; scale -= 0.1 {
+		cw, ch := maxW*scale, maxH*scale
+		// 窗口在分析图像中的大小
+		acw, ach := maxValue(int(math.Round(cw*ratio)), 1), maxValue(int(math.Round(ch*ratio)), 1)
+		acw, ach = minValue(acw, aw), minValue(ach, ah)
+		step := maxValue(minValue(aw, ah)/40, 1)
+		for ay := 0; ay+ach <= ah; ay += step {
+			for ax := 0; ax+acw <= aw; ax += step {
+				score := smartCropScore(importance, aw, ax, ay, acw, ach, total)
+				if score > best {
+					best = score
+					bestX, bestY = float64(ax)/ratio, float64(ay)/ratio
+					bestW, bestH = cw, ch
+				}
+			}
+		}
+	}
+
+	w, h := maxValue(int(math.Round(bestW)), 1), maxValue(int(math.Round(bestH)), 1)
+	x0 := bounds.Min.X + clamp(int(math.Round(bestX)), 0, W-w)
+	y0 := bounds.Min.Y + clamp(int(math.Round(bestY)), 0, H-h)
+	return Range{X0: x0, Y0: y0, X1: x0 + w, Y1: y0 + h}, nil
+}
+
+// smartCropWeight 零值使用默认值，小于0表示不使用该项
+func smartCropWeight(v, def float64) float64 {
+	if v == 0 {
+		return def
+	}
+	return math.Max(v, 0)
+}
+
+// smartCropScore 窗口评分：窗口内的重要程度越靠近中心权重越高，被裁掉的重要内容扣分
+func smartCropScore(importance []float64, stride, x0, y0, w, h int, total float64) float64 {
+	var inside, weighted float64
+	for y := y0; y < y0+h; y++ {
+		v := math.Abs(2*(float64(y-y0)+0.5)/float64(h) - 1)
+		row := importance[y*stride+x0 : y*stride+x0+w]
+		for i, imp := range row {
+			u := math.Abs(2*(float64(i)+0.5)/float64(w) - 1)
+			d := math.Max(u, v)
+			inside += imp
+			weighted += imp * (1 - 0.5*d*d)
+		}
+	}
+	return weighted - smartCropOutsidePenalty*(total-inside)
+}
+
+// saturationScore 饱和度评分，过暗和过亮的像素不计
+func saturationScore(r, g, b uint8) float64 {
+	l := float64(luminance(r, g, b)) / 255
+	if l < 0.05 || l > 0.9 {
+		return 0
+	}
+	_, s, _ := RGBToHSV(r, g, b)
+	return s
+}
+
+// skinScore 肤色评分：归一化颜色向量与典型肤色越接近分值越高
+func skinScore(r, g, b uint8) float64 {
+	l := float64(luminance(r, g, b)) / 255
+	if l < 0.2 || l > 0.95 {
+		return 0
+	}
+	fr, fg, fb := float64(r), float64(g), float64(b)
+	norm := math.Sqrt(fr*fr + fg*fg + fb*fb)
+	// 典型肤色 (0.78, 0.57, 0.44) 归一化后的方向
+	const sr, sg, sb = 0.7348, 0.5369, 0.4145
+	d := math.Sqrt((fr/norm-sr)*(fr/norm-sr) + (fg/norm-sg)*(fg/norm-sg) + (fb/norm-sb)*(fb/norm-sb))
+	return math.Max(0, 1-d/0.15)
+}
+
+// OpsSmartCrop 智能裁剪操作，裁剪并缩放到 width*height
+// 参数:
+// - width, height 缩略图的宽高
+// - opts 智能裁剪参数，可选
+func OpsSmartCrop(width, height int, opts ...SmartCropOptions) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		dst, _, err := SmartCrop(ctx.Dst, width, height, opts...)
+		if err != nil {
+			return err
+		}
+		ctx.Dst = dst.(*image.RGBA)
+		return nil
+	}
+}
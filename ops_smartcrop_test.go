@@ -0,0 +1,103 @@
+package imgHelper
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// detailImage 灰色背景上在 detail 范围内画红白相间的条纹
+func detailImage(w, h int, detail image.Rectangle) *image.RGBA {
+	img := uniformRGBA(w, h, color.RGBA{R: 128, G: 128, B: 128, A: 255})
+	for y := detail.Min.Y; y < detail.Max.Y; y++ {
+		for x := detail.Min.X; x < detail.Max.X; x++ {
+			c := color.RGBA{R: 230, G: 20, B: 20, A: 255}
+			if (x/3)%2 == 0 {
+				c = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestSmartCropRange(t *testing.T) {
+	cases := []struct {
+		name          string
+		src           *image.RGBA
+		origin        image.Point
+		width, height int
+		opts          []SmartCropOptions
+		size          image.Point     // 窗口尺寸
+		contains      image.Rectangle // 窗口必须包含的细节范围(相对原点)
+	}{
+		{"正方形取细节所在一侧", detailImage(300, 100, image.Rect(220, 30, 270, 70)), image.Point{}, 50, 50, nil,
+			image.Pt(100, 100), image.Rect(220, 30, 270, 70)},
+		{"细节在左侧", detailImage(300, 100, image.Rect(10, 30, 60, 70)), image.Point{}, 50, 50, nil,
+			image.Pt(100, 100), image.Rect(10, 30, 60, 70)},
+		{"竖图裁横图", detailImage(100, 300, image.Rect(30, 200, 70, 240)), image.Point{}, 200, 100, nil,
+			image.Pt(100, 50), image.Rect(30, 200, 70, 240)},
+		{"宽高比相同时取整个图像", detailImage(200, 100, image.Rect(10, 10, 30, 30)), image.Point{}, 40, 20, nil,
+			image.Pt(200, 100), image.Rect(0, 0, 200, 100)},
+		{"非零原点", detailImage(300, 100, image.Rect(220, 30, 270, 70)), image.Pt(-40, 15), 50, 50, nil,
+			image.Pt(100, 100), image.Rect(220, 30, 270, 70)},
+		{"允许缩小窗口", detailImage(300, 100, image.Rect(220, 30, 260, 70)), image.Point{}, 50, 50, []SmartCropOptions{{MinScale: 0.5}},
+			image.Point{}, image.Rect(220, 30, 260, 70)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.src.Rect = c.src.Rect.Add(c.origin)
+			rg, err := SmartCropRange(c.src, c.width, c.height, c.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rect := image.Rect(rg.X0, rg.Y0, rg.X1, rg.Y1)
+			if !rect.In(c.src.Bounds()) {
+				t.Errorf("窗口 %v 超出图像 %v", rect, c.src.Bounds())
+			}
+			if c.size != (image.Point{}) && rect.Size() != c.size {
+				t.Errorf("窗口尺寸 %v, want %v", rect.Size(), c.size)
+			}
+			aspect := float64(c.width) / float64(c.height)
+			if got := float64(rect.Dx()) / float64(rect.Dy()); math.Abs(got-aspect) > 0.05*aspect {
+				t.Errorf("窗口宽高比 %g, want %g", got, aspect)
+			}
+			if want := c.contains.Add(c.origin); !want.In(rect) {
+				t.Errorf("窗口 %v 没有包含细节 %v", rect, want)
+			}
+		})
+	}
+}
+
+func TestSmartCrop(t *testing.T) {
+	src := detailImage(300, 100, image.Rect(220, 30, 270, 70))
+	for _, size := range []image.Point{{50, 50}, {120, 40}, {400, 400}} {
+		dst, rg, err := SmartCrop(src, size.X, size.Y)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dst.Bounds() != image.Rect(0, 0, size.X, size.Y) {
+			t.Errorf("%v: Bounds = %v", size, dst.Bounds())
+		}
+		if rg.X1 > 300 || rg.Y1 > 100 || rg.X0 < 0 || rg.Y0 < 0 {
+			t.Errorf("%v: 窗口 %+v 超出图像", size, rg)
+		}
+	}
+	cases := []struct {
+		name          string
+		src           image.Image
+		width, height int
+	}{
+		{"宽为0", src, 0, 10},
+		{"高为负", src, 10, -1},
+		{"空图像", image.NewRGBA(image.Rect(0, 0, 0, 0)), 10, 10},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, _, err := SmartCrop(c.src, c.width, c.height); err == nil {
+				t.Error("want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package imgHelper
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// ThresholdMethod 二值化的阈值计算方法
+type ThresholdMethod int
+
+const (
+	ThresholdFixed    ThresholdMethod = iota // 固定全局阈值，同 BinaryImg
+	ThresholdOtsu                            // 大津法(Otsu)，自动计算全局阈值
+	ThresholdMean                            // 局部均值自适应阈值：阈值 = 窗口均值 - C
+	ThresholdGaussian                        // 局部高斯加权自适应阈值：阈值 = 窗口高斯加权均值 - C
+	ThresholdNiblack                         // Niblack 局部阈值：阈值 = 均值 + K*标准差
+	ThresholdSauvola                         // Sauvola 局部阈值：阈值 = 均值 * (1 + K*(标准差/R - 1))
+)
+
+// BinaryOptions 二值化参数，零值字段使用默认值
+type BinaryOptions struct {
+	Method    ThresholdMethod // 阈值计算方法，默认为 ThresholdFixed
+	Threshold *int            // 固定阈值 0~255，ThresholdFixed 使用，为 nil 时使用默认值128
+	BlockSize int             // 局部窗口边长(奇数且大于等于3)，局部方法使用，默认为15
+	C         float64         // 从局部均值中减去的常数，ThresholdMean 和 ThresholdGaussian 使用
+	K         float64         // Niblack 默认为 -0.2，Sauvola 默认为 0.2
+	R         float64         // Sauvola 标准差的动态范围，默认为128
+}
+
+// OtsuThreshold 使用大津法计算全局阈值，使前景和背景的类间方差最大
+func OtsuThreshold(src image.Image) int {
+	plane, _, _ := grayPlane(src)
+	return otsuThreshold(plane)
+}
+
+// otsuThreshold 根据亮度平面计算大津法阈值
+func otsuThreshold(plane []float64) int {
+	var hist [256]int
+	for _, v := range plane {
+		hist[clamp(int(math.Round(v)), 0, 255)]++
+	}
+	total := len(plane)
+	sumAll := 0.0
+	for v, n := range hist {
+		sumAll += float64(v * n)
+	}
+	var sumB float64
+	weightB := 0
+	best, bestVar := 0, -1.0
+	for t := 0; t < 256; t++ {
+		weightB += hist[t]
+		if weightB == 0 {
+			continue
+		}
+		weightF := total - weightB
+		if weightF == 0 {
+			break
+		}
+		sumB += float64(t * hist[t])
+		meanB := sumB / float64(weightB)
+		meanF := (sumAll - sumB) / float64(weightF)
+		between := float64(weightB) * float64(weightF) * (meanB - meanF) * (meanB - meanF)
+		if between > bestVar {
+			bestVar = between
+			best = t
+		}
+	}
+	return best
+}
+
+// BinaryImgWithOptions 按指定的阈值方法进行二值化，大于阈值的像素为白色，其余为黑色
+// 局部阈值方法(均值、高斯、Niblack、Sauvola)适合光照不均的文档照片
+func BinaryImgWithOptions(src image.Image, opts BinaryOptions) (image.Image, error) {
+	if opts.Method == ThresholdFixed {
+		threshold := 128
+		if opts.Threshold != nil {
+			threshold = clamp(*opts.Threshold, 0, 255)
+		}
+		return binaryFixed(src, uint8(threshold)), nil
+	}
+	plane, w, h := grayPlane(src)
+	bounds := src.Bounds()
+	dst := image.NewGray(image.Rect(bounds.Min.X, bounds.Min.Y, bounds.Min.X+w, bounds.Min.Y+h))
+
+	blockSize := opts.BlockSize
+	if blockSize == 0 {
+		blockSize = 15
+	}
+	if opts.Method >= ThresholdMean && (blockSize < 3 || blockSize%2 == 0) {
+		return nil, fmt.Errorf("blockSize 必须为大于等于3的奇数, 当前为 %d", blockSize)
+	}
+
+	var thresholdAt func(i, x, y int) float64
+	switch opts.Method {
+	case ThresholdOtsu:
+		threshold := float64(otsuThreshold(plane))
+		thresholdAt = func(int, int, int) float64 { return threshold }
+
+	case ThresholdMean:
+		stats := newLocalStats(plane, w, h)
+		thresholdAt = func(i, x, y int) float64 {
+			mean, _ := stats.meanStd(x, y, blockSize/2)
+			return mean - opts.C
+		}
+
+	case ThresholdGaussian:
+		// 与 OpenCV 一致，由窗口大小推导高斯核的标准差
+		sigma := 0.3*(float64(blockSize-1)*0.5-1) + 0.8
+		blurred := blurPlane(plane, w, h, sigma)
+		thresholdAt = func(i, x, y int) float64 { return blurred[i] - opts.C }
+
+	case ThresholdNiblack:
+		k := opts.K
+		if k == 0 {
+			k = -0.2
+		}
+		stats := newLocalStats(plane, w, h)
+		thresholdAt = func(i, x, y int) float64 {
+			mean, std := stats.meanStd(x, y, blockSize/2)
+			return mean + k*std
+		}
+
+	case ThresholdSauvola:
+		k := opts.K
+		if k == 0 {
+			k = 0.2
+		}
+		r := opts.R
+		if r == 0 {
+			r = 128
+		}
+		stats := newLocalStats(plane, w, h)
+		thresholdAt = func(i, x, y int) float64 {
+			mean, std := stats.meanStd(x, y, blockSize/2)
+			return mean * (1 + k*(std/r-1))
+		}
+
+	default:
+		return nil, fmt.Errorf("未知的阈值方法 %d", opts.Method)
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			if plane[i] > thresholdAt(i, x, y) {
+				dst.Pix[y*dst.Stride+x] = 255
+			}
+		}
+	}
+	return dst, nil
+}
+
+// binaryFixed 固定阈值二值化，使用标准库的灰度转换，与 BinaryImg 的历史结果一致
+func binaryFixed(src image.Image, threshold uint8) *image.Gray {
+	bounds := src.Bounds()
+	binaryImg := image.NewGray(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			gray := color.GrayModel.Convert(src.At(x, y)).(color.Gray)
+			if gray.Y > threshold {
+				// 大于阈值的像素设为白色
+				binaryImg.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+	return binaryImg
+}
+
+// OpsBinaryImgWithOptions 按指定的阈值方法进行二值化操作
+func OpsBinaryImgWithOptions(opts BinaryOptions) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		dst, err := BinaryImgWithOptions(ctx.Dst, opts)
+		if err != nil {
+			return err
+		}
+		ctx.Dst = imageToRGBA(dst)
+		return nil
+	}
+}
+
+// BinaryImgOtsu 大津法自动阈值二值化
+func BinaryImgOtsu(src image.Image) image.Image {
+	dst, _ := BinaryImgWithOptions(src, BinaryOptions{Method: ThresholdOtsu})
+	return dst
+}
+
+// OpsBinaryImgOtsu 大津法自动阈值二值化操作
+func OpsBinaryImgOtsu() func(ctx *CanvasContext) error {
+	return OpsBinaryImgWithOptions(BinaryOptions{Method: ThresholdOtsu})
+}
+
+// BinaryImgAdaptive 局部均值自适应阈值二值化
+// 参数:
+// - blockSize 局部窗口边长(奇数且大于等于3)
+// - c 从局部均值中减去的常数，常用 5~15
+func BinaryImgAdaptive(src image.Image, blockSize int, c float64) (image.Image, error) {
+	return BinaryImgWithOptions(src, BinaryOptions{Method: ThresholdMean, BlockSize: blockSize, C: c})
+}
+
+// OpsBinaryImgAdaptive 局部均值自适应阈值二值化操作
+func OpsBinaryImgAdaptive(blockSize int, c float64) func(ctx *CanvasContext) error {
+	return OpsBinaryImgWithOptions(BinaryOptions{Method: ThresholdMean, BlockSize: blockSize, C: c})
+}
+
+// BinaryImgSauvola Sauvola 局部阈值二值化，适合背景不均匀的文档图像
+// 参数:
+// - blockSize 局部窗口边长(奇数且大于等于3)
+// - k 系数，为0时使用默认值0.2
+func BinaryImgSauvola(src image.Image, blockSize int, k float64) (image.Image, error) {
+	return BinaryImgWithOptions(src, BinaryOptions{Method: ThresholdSauvola, BlockSize: blockSize, K: k})
+}
+
+// OpsBinaryImgSauvola Sauvola 局部阈值二值化操作
+func OpsBinaryImgSauvola(blockSize int, k float64) func(ctx *CanvasContext) error {
+	return OpsBinaryImgWithOptions(BinaryOptions{Method: ThresholdSauvola, BlockSize: blockSize, K: k})
+}
+
+// BinaryImgNiblack Niblack 局部阈值二值化
+// 参数:
+// - blockSize 局部窗口边长(奇数且大于等于3)
+// - k 系数，为0时使用默认值-0.2
+func BinaryImgNiblack(src image.Image, blockSize int, k float64) (image.Image, error) {
+	return BinaryImgWithOptions(src, BinaryOptions{Method: ThresholdNiblack, BlockSize: blockSize, K: k})
+}
+
+// OpsBinaryImgNiblack Niblack 局部阈值二值化操作
+func OpsBinaryImgNiblack(blockSize int, k float64) func(ctx *CanvasContext) error {
+	return OpsBinaryImgWithOptions(BinaryOptions{Method: ThresholdNiblack, BlockSize: blockSize, K: k})
+}
+
+// localStats 基于积分图的局部均值和标准差计算
+type localStats struct {
+	w, h  int
+	sum   []float64
+	sumSq []float64
+}
+
+func newLocalStats(plane []float64, w, h int) *localStats {
+	s := &localStats{
+		w:     w,
+		h:     h,
+		sum:   make([]float64, (w+1)*(h+1)),
+		sumSq: make([]float64, (w+1)*(h+1)),
+	}
+	for y := 0; y < h; y++ {
+		var rowSum, rowSumSq float64
+		for x := 0; x < w; x++ {
+			v := plane[y*w+x]
+			rowSum += v
+			rowSumSq += v * v
+			s.sum[(y+1)*(w+1)+x+1] = s.sum[y*(w+1)+x+1] + rowSum
+			s.sumSq[(y+1)*(w+1)+x+1] = s.sumSq[y*(w+1)+x+1] + rowSumSq
+		}
+	}
+	return s
+}
+
+// meanStd 返回以 (x,y) 为中心、半径为 r 的窗口内的均值和标准差，窗口超出图像的部分会被裁掉
+func (s *localStats) meanStd(x, y, r int) (float64, float64) {
+	x0, y0 := maxValue(x-r, 0), maxValue(y-r, 0)
+	x1, y1 := minValue(x+r+1, s.w), minValue(y+r+1, s.h)
+	n := float64((x1 - x0) * (y1 - y0))
+	stride := s.w + 1
+	sum := s.sum[y1*stride+x1] - s.sum[y0*stride+x1] - s.sum[y1*stride+x0] + s.sum[y0*stride+x0]
+	sumSq := s.sumSq[y1*stride+x1] - s.sumSq[y0*stride+x1] - s.sumSq[y1*stride+x0] + s.sumSq[y0*stride+x0]
+	mean := sum / n
+	variance := sumSq/n - mean*mean
+	if variance < 0 {
+		variance = 0
+	}
+	return mean, math.Sqrt(variance)
+}
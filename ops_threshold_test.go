@@ -0,0 +1,190 @@
+package imgHelper
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// twoLevelImage 左半部分为 left，右半部分为 right 的灰度图
+func twoLevelImage(w, h int, left, right uint8) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x < w/2 {
+				img.SetGray(x, y, color.Gray{Y: left})
+			} else {
+				img.SetGray(x, y, color.Gray{Y: right})
+			}
+		}
+	}
+	return img
+}
+
+func TestOtsuThreshold(t *testing.T) {
+	cases := []struct {
+		name string
+		src  image.Image
+		want int
+	}{
+		{"两种灰度", twoLevelImage(20, 10, 50, 200), 50},
+		{"黑白", twoLevelImage(20, 10, 0, 255), 0},
+		{"顺序无关", twoLevelImage(20, 10, 220, 30), 30},
+		{"单一灰度", twoLevelImage(20, 10, 128, 128), 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := OtsuThreshold(c.src); got != c.want {
+				t.Errorf("OtsuThreshold = %d, want %d", got, c.want)
+			}
+		})
+	}
+}
+
+func TestOtsuThresholdSeparates(t *testing.T) {
+	// 两个带噪声的灰度簇，阈值应落在两簇之间
+	img := image.NewGray(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			v := 40 + (x*7+y*3)%30
+			if x >= 32 {
+				v = 170 + (x*5+y*11)%40
+			}
+			img.SetGray(x, y, color.Gray{Y: uint8(v)})
+		}
+	}
+	if got := OtsuThreshold(img); got < 69 || got >= 170 {
+		t.Errorf("OtsuThreshold = %d, want [69, 170)", got)
+	}
+}
+
+func TestBinaryImgWithOptionsFixed(t *testing.T) {
+	// 彩色渐变，固定阈值的结果应与 BinaryImg 逐像素一致
+	src := image.NewRGBA(image.Rect(0, 0, 64, 16))
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 64; x++ {
+			src.Set(x, y, color.RGBA{R: uint8(x * 4), G: uint8(y * 16), B: uint8(255 - x*4), A: 255})
+		}
+	}
+	cases := []struct {
+		name string
+		opts BinaryOptions
+		want int
+	}{
+		{"默认阈值", BinaryOptions{}, 128},
+		{"阈值100", BinaryOptions{Threshold: intPtr(100)}, 100},
+		{"阈值0", BinaryOptions{Threshold: intPtr(0)}, 0},
+		{"阈值255", BinaryOptions{Threshold: intPtr(255)}, 255},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := BinaryImgWithOptions(src, c.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := BinaryImg(src, c.want)
+			for y := 0; y < 16; y++ {
+				for x := 0; x < 64; x++ {
+					g := color.GrayModel.Convert(got.At(x, y)).(color.Gray).Y
+					w := color.GrayModel.Convert(want.At(x, y)).(color.Gray).Y
+					if g != w {
+						t.Fatalf("(%d, %d) = %d, BinaryImg = %d", x, y, g, w)
+					}
+				}
+			}
+		})
+	}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestBinaryImgForText(t *testing.T) {
+	// 浅色背景上的深色文字，左侧较暗：固定阈值会把暗背景当成文字，局部阈值不会
+	src := image.NewGray(image.Rect(0, 0, 60, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 60; x++ {
+			v := 40 + x*3
+			if x%10 == 5 && y > 3 && y < 16 {
+				v -= 35
+			}
+			src.SetGray(x, y, color.Gray{Y: uint8(v)})
+		}
+	}
+	isStroke := func(x, y int) bool { return x%10 == 5 && y > 3 && y < 16 }
+	cases := []struct {
+		name      string
+		opts      []BinaryOptions
+		strokes   int // 识别出的笔画像素，共72个
+		maxOthers int // 误认为文字的背景像素
+	}{
+		// 固定阈值只能识别左侧的两条笔画，并把左侧较暗的背景当成文字
+		{"默认阈值50", nil, 24, 80},
+		{"阈值0", []BinaryOptions{{Threshold: intPtr(0)}}, 0, 0},
+		{"Sauvola", []BinaryOptions{{Method: ThresholdSauvola, BlockSize: 9, K: 0.1}}, 72, 20},
+		{"无效参数退回默认阈值", []BinaryOptions{{Method: ThresholdMean, BlockSize: 4}}, 24, 80},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			bin := binaryImgForText(src, c.opts...)
+			if len(bin) != 20 || len(bin[0]) != 60 {
+				t.Fatalf("尺寸 %dx%d, want 60x20", len(bin[0]), len(bin))
+			}
+			strokes, others := 0, 0
+			for y, row := range bin {
+				for x, v := range row {
+					switch {
+					case v != 255:
+					case isStroke(x, y):
+						strokes++
+					default:
+						others++
+					}
+				}
+			}
+			if strokes != c.strokes || others > c.maxOthers {
+				t.Errorf("笔画像素 %d, 背景像素 %d, want %d, <= %d", strokes, others, c.strokes, c.maxOthers)
+			}
+		})
+	}
+}
+
+func TestThinningWithOptions(t *testing.T) {
+	// 白底上5像素粗的深灰横线，细化后每列只剩1个像素
+	src := image.NewGray(image.Rect(0, 0, 30, 15))
+	for y := 0; y < 15; y++ {
+		for x := 0; x < 30; x++ {
+			v := uint8(240)
+			if y >= 5 && y < 10 && x >= 3 && x < 27 {
+				v = 90
+			}
+			src.SetGray(x, y, color.Gray{Y: v})
+		}
+	}
+	cases := []struct {
+		name  string
+		opts  []BinaryOptions
+		empty bool
+	}{
+		{"默认阈值识别不到灰色笔画", nil, true},
+		{"大津法", []BinaryOptions{{Method: ThresholdOtsu}}, false},
+		{"固定阈值128", []BinaryOptions{{Threshold: intPtr(128)}}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dst := Thinning(src, c.opts...).(*image.RGBA)
+			for x := 6; x < 24; x++ {
+				n := 0
+				for y := 0; y < 15; y++ {
+					if dst.RGBAAt(x, y).R == 255 {
+						n++
+					}
+				}
+				if c.empty && n != 0 || !c.empty && n != 1 {
+					t.Fatalf("第 %d 列骨架像素 %d", x, n)
+				}
+			}
+		})
+	}
+}
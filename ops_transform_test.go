@@ -0,0 +1,100 @@
+package imgHelper
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// columnImage 每列颜色不同的不透明图像，R 为列号*40，G 为行号*40
+func columnImage(r image.Rectangle) *image.RGBA {
+	img := image.NewRGBA(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetRGBA(x, y, color.RGBA{R: uint8((x - r.Min.X) * 40), G: uint8((y - r.Min.Y) * 40), B: 100, A: 255})
+		}
+	}
+	return img
+}
+
+func TestTransformLegacy(t *testing.T) {
+	black := color.RGBA{A: 255}
+	src := columnImage(image.Rect(0, 0, 5, 3))
+	cases := []struct {
+		name string
+		dst  *image.RGBA
+		row  []color.RGBA // 第1行的结果
+	}{
+		// 不传 opts 时以整数坐标四舍五入取像素，0.5 舍入为 1，-0.5 舍入为 -1，超出部分为黑色
+		{"平移半个像素", AffineTransform(src, [6]float64{1, 0, 0.5, 0, 1, 0}), []color.RGBA{black, src.RGBAAt(1, 1), src.RGBAAt(2, 1), src.RGBAAt(3, 1), src.RGBAAt(4, 1)}},
+		{"平移两个像素", RigidTransform(src, 0, 1, 2.4, 0), []color.RGBA{black, black, src.RGBAAt(0, 1), src.RGBAAt(1, 1), src.RGBAAt(2, 1)}},
+		{"透视", PerspectiveTransform(src, [9]float64{1, 0, -1, 0, 1, 0, 0, 0, 1}), []color.RGBA{src.RGBAAt(1, 1), src.RGBAAt(2, 1), src.RGBAAt(3, 1), src.RGBAAt(4, 1), black}},
+		// 2x3、3x3 矩阵的版本为双线性插值
+		{"2x3 矩阵", AffineTransform23(src, [2][3]float64{{1, 0, 0.5}, {0, 1, 0}}), []color.RGBA{black, {R: 20, G: 40, B: 100, A: 255}, {R: 60, G: 40, B: 100, A: 255}, {R: 100, G: 40, B: 100, A: 255}, {R: 140, G: 40, B: 100, A: 255}}},
+		{"3x3 矩阵", PerspectiveTransform33(src, [3][3]float64{{1, 0, 1}, {0, 1, 0}, {0, 0, 1}}), []color.RGBA{black, src.RGBAAt(0, 1), src.RGBAAt(1, 1), src.RGBAAt(2, 1), src.RGBAAt(3, 1)}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if c.dst.Bounds() != src.Bounds() {
+				t.Fatalf("Bounds = %v, want %v", c.dst.Bounds(), src.Bounds())
+			}
+			for x, want := range c.row {
+				if got := c.dst.RGBAAt(x, 1); absDiff(got.R, want.R) > 1 || got.G != want.G || got.B != want.B || got.A != want.A {
+					t.Errorf("(%d, 1) = %v, want %v", x, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTransformOptionsBorder(t *testing.T) {
+	// 非零原点的图像向右平移2个像素，保持原尺寸时左侧2列为边界
+	src := columnImage(image.Rect(10, 20, 15, 23))
+	red := color.RGBA{R: 255, A: 255}
+	cases := []struct {
+		name string
+		opts TransformOptions
+		left color.RGBA
+	}{
+		{"默认透明", TransformOptions{KeepSize: true}, color.RGBA{}},
+		{"填充默认黑色", TransformOptions{KeepSize: true, Border: BorderFill}, color.RGBA{A: 255}},
+		{"填充指定颜色", TransformOptions{KeepSize: true, Border: BorderFill, Fill: red}, red},
+		{"延伸边缘", TransformOptions{KeepSize: true, Border: BorderClamp}, src.RGBAAt(10, 21)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.opts.Interpolation = InterpNearest
+			dst := AffineTransform(src, [6]float64{1, 0, 2, 0, 1, 0}, c.opts)
+			if dst.Bounds() != src.Bounds() {
+				t.Fatalf("Bounds = %v, want %v", dst.Bounds(), src.Bounds())
+			}
+			for x := 10; x < 12; x++ {
+				if got := dst.RGBAAt(x, 21); got != c.left {
+					t.Errorf("(%d, 21) = %v, want %v", x, got, c.left)
+				}
+			}
+			for x := 12; x < 15; x++ {
+				if got, want := dst.RGBAAt(x, 21), src.RGBAAt(x-2, 21); got != want {
+					t.Errorf("(%d, 21) = %v, want %v", x, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTransformOptionsExpand(t *testing.T) {
+	src := columnImage(image.Rect(0, 0, 4, 2))
+	// 绕原点旋转90度，不保持原尺寸时输出为 2x4，从 (0,0) 开始
+	dst := RigidTransform(src, 90, 1, 0, 0, TransformOptions{Interpolation: InterpNearest})
+	if dst.Bounds() != image.Rect(0, 0, 2, 4) {
+		t.Fatalf("Bounds = %v, want 2x4", dst.Bounds())
+	}
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 2; x++ {
+			// 源图像 (sx, sy) 旋转后位于 (-sy, sx)，扩展后整体右移2列
+			if got, want := dst.RGBAAt(x, y), src.RGBAAt(y, 1-x); got != want {
+				t.Errorf("(%d, %d) = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
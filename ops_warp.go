@@ -0,0 +1,313 @@
+package imgHelper
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// CoordinateMap 坐标映射表，记录输出图像每个像素在源图像中的采样坐标，用于 Remap
+// 坐标以像素为单位，整数坐标为像素中心，(0,0) 为源图像左上角的像素
+// 映射表与图像内容无关，可以计算一次后用于多张同样尺寸的图像(如同一镜头拍摄的照片)
+type CoordinateMap struct {
+	Width  int       // 输出图像的宽
+	Height int       // 输出图像的高
+	X      []float64 // 输出像素 (x, y) 的采样坐标为 (X[y*Width+x], Y[y*Width+x])
+	Y      []float64
+}
+
+// NewCoordinateMap 由映射函数生成 width*height 的坐标映射表
+// 参数:
+// - fn 输入输出像素的坐标，返回该像素在源图像中的采样坐标
+func NewCoordinateMap(width, height int, fn func(x, y int) (float64, float64)) *CoordinateMap {
+	width, height = maxValue(width, 0), maxValue(height, 0)
+	m := &CoordinateMap{Width: width, Height: height, X: make([]float64, width*height), Y: make([]float64, width*height)}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			m.X[y*width+x], m.Y[y*width+x] = fn(x, y)
+		}
+	}
+	return m
+}
+
+// Remap 按坐标映射表重新采样图像，输出图像的大小为映射表的大小
+// 参数:
+// - m 坐标映射表
+// - opts 插值方式和边界处理方式，可选，KeepSize 无效
+func Remap(src image.Image, m *CoordinateMap, opts ...TransformOptions) (image.Image, error) {
+	if m == nil || len(m.X) != m.Width*m.Height || len(m.Y) != m.Width*m.Height {
+		return nil, fmt.Errorf("坐标映射表的大小与宽高不一致")
+	}
+	return remap(src, m.Width, m.Height, func(x, y int) (float64, float64) {
+		return m.X[y*m.Width+x], m.Y[y*m.Width+x]
+	}, getTransformOptions(opts)), nil
+}
+
+// OpsRemap 按坐标映射表重新采样操作
+func OpsRemap(m *CoordinateMap, opts ...TransformOptions) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		dst, err := Remap(ctx.Dst, m, opts...)
+		if err != nil {
+			return err
+		}
+		ctx.Dst = dst.(*image.RGBA)
+		return nil
+	}
+}
+
+// remap 按映射函数逐像素采样，不需要保存映射表
+func remap(src image.Image, width, height int, fn func(x, y int) (float64, float64), opt TransformOptions) *image.RGBA {
+	rgba := imageToRGBA(src)
+	origin := rgba.Bounds().Min
+	s := newSampler(rgba, opt)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			sx, sy := fn(x, y)
+			if math.IsNaN(sx) || math.IsNaN(sy) {
+				dst.SetRGBA(x, y, s.fillColor())
+				continue
+			}
+			dst.SetRGBA(x, y, s.at(sx+float64(origin.X), sy+float64(origin.Y)))
+		}
+	}
+	return dst
+}
+
+// LensOptions 镜头畸变参数，使用 Brown–Conrady 模型，零值字段使用默认值
+// 畸变前后的坐标均以光心为原点、以焦距归一化，r 为到光心的距离:
+// x' = x(1 + K1*r² + K2*r⁴) + 2*P1*x*y + P2*(r² + 2x²)
+// y' = y(1 + K1*r² + K2*r⁴) + P1*(r² + 2y²) + 2*P2*x*y
+type LensOptions struct {
+	K1, K2 float64  // 径向畸变系数，K1 小于0为桶形畸变(广角镜头常见)，大于0为枕形畸变
+	P1, P2 float64  // 切向畸变系数，镜头与传感器不平行时产生
+	Cx, Cy *float64 // 光心的像素坐标(整数为像素中心)，为 nil 时为图像中心，可以为0
+	Focal  float64  // 焦距(像素)，默认为图像对角线的一半
+}
+
+// warpCenter 图像中心在映射坐标(整数为像素中心)中的位置，各个以图像中心为中心的变形都使用该位置
+func warpCenter(width, height int) (float64, float64) {
+	return float64(width-1) / 2, float64(height-1) / 2
+}
+
+// lensMapping 返回畸变映射：输入无畸变图像的像素坐标，返回其在有畸变图像中的坐标
+func lensMapping(width, height int, lens LensOptions) func(x, y int) (float64, float64) {
+	cx, cy := warpCenter(width, height)
+	if lens.Cx != nil {
+		cx = *lens.Cx
+	}
+	if lens.Cy != nil {
+		cy = *lens.Cy
+	}
+	f := lens.Focal
+	if f <= 0 {
+		f = math.Hypot(float64(width), float64(height)) / 2
+	}
+	return func(x, y int) (float64, float64) {
+		nx, ny := (float64(x)-cx)/f, (float64(y)-cy)/f
+		r2 := nx*nx + ny*ny
+		radial := 1 + lens.K1*r2 + lens.K2*r2*r2
+		dx := nx*radial + 2*lens.P1*nx*ny + lens.P2*(r2+2*nx*nx)
+		dy := ny*radial + lens.P1*(r2+2*ny*ny) + 2*lens.P2*nx*ny
+		return dx*f + cx, dy*f + cy
+	}
+}
+
+// LensCorrectionMap 生成 width*height 图像的镜头畸变矫正映射表，可用于 Remap 批量矫正同一镜头拍摄的照片
+func LensCorrectionMap(width, height int, lens LensOptions) *CoordinateMap {
+	return NewCoordinateMap(width, height, lensMapping(width, height, lens))
+}
+
+// LensCorrect 镜头畸变矫正，输出与源图像同样大小的无畸变图像
+// 参数:
+// - lens 镜头的畸变参数，通常由相机标定得到，也可以对照画面中的直线手动调整 K1
+// - opts 插值方式和边界处理方式，可选，矫正桶形畸变后四角会出现空白，可用 BorderClamp 填补
+func LensCorrect(src image.Image, lens LensOptions, opts ...TransformOptions) image.Image {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	return remap(src, w, h, lensMapping(w, h, lens), getTransformOptions(opts))
+}
+
+// OpsLensCorrect 镜头畸变矫正操作
+func OpsLensCorrect(lens LensOptions, opts ...TransformOptions) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		ctx.Dst = LensCorrect(ctx.Dst, lens, opts...).(*image.RGBA)
+		return nil
+	}
+}
+
+// BarrelDistort 桶形、枕形畸变效果，以图像中心为中心，图像四角的变形最大
+// 参数:
+// - strength 畸变强度，取值 [-1, 1]，大于0为桶形(中心鼓起)，小于0为枕形(中心收缩)
+// - opts 插值方式和边界处理方式，可选
+func BarrelDistort(src image.Image, strength float64, opts ...TransformOptions) image.Image {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	strength = math.Max(-1, math.Min(1, strength))
+	// 输出中到中心距离为 r 的像素取源图像中距离为 r(1+strength*r²) 的像素，strength 大于0时越靠外放大率越小
+	return remap(src, w, h, lensMapping(w, h, LensOptions{K1: strength}), getTransformOptions(opts))
+}
+
+// OpsBarrelDistort 桶形、枕形畸变效果操作
+func OpsBarrelDistort(strength float64, opts ...TransformOptions) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		ctx.Dst = BarrelDistort(ctx.Dst, strength, opts...).(*image.RGBA)
+		return nil
+	}
+}
+
+// Swirl 漩涡效果，以图像中心为中心旋转，越靠近中心旋转角度越大，半径以外不变
+// 参数:
+// - angle 中心处顺时针旋转的角度，负数为逆时针
+// - radius 漩涡半径，小于等于0时为图像短边的一半
+// - opts 插值方式和边界处理方式，可选
+func Swirl(src image.Image, angle, radius float64, opts ...TransformOptions) image.Image {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	if radius <= 0 {
+		radius = float64(minValue(w, h)) / 2
+	}
+	cx, cy := warpCenter(w, h)
+	rad := angle * math.Pi / 180
+	return remap(src, w, h, func(x, y int) (float64, float64) {
+		dx, dy := float64(x)-cx, float64(y)-cy
+		d := math.Hypot(dx, dy)
+		if d >= radius {
+			return float64(x), float64(y)
+		}
+		// 旋转角度随距离平滑衰减到0，逆向旋转得到源坐标
+		t := 1 - d/radius
+		sin, cos := math.Sincos(-rad * t * t)
+		return cx + dx*cos - dy*sin, cy + dx*sin + dy*cos
+	}, getTransformOptions(opts))
+}
+
+// OpsSwirl 漩涡效果操作
+func OpsSwirl(angle, radius float64, opts ...TransformOptions) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		ctx.Dst = Swirl(ctx.Dst, angle, radius, opts...).(*image.RGBA)
+		return nil
+	}
+}
+
+// Wave 波浪效果，像素沿正弦曲线偏移
+// 参数:
+// - amplitudeX, wavelengthX 水平偏移的振幅和波长(像素)，偏移量随 y 变化，振幅为0时不偏移
+// - amplitudeY, wavelengthY 垂直偏移的振幅和波长(像素)，偏移量随 x 变化，振幅为0时不偏移
+// - opts 插值方式和边界处理方式，可选
+func Wave(src image.Image, amplitudeX, wavelengthX, amplitudeY, wavelengthY float64, opts ...TransformOptions) image.Image {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	wave := func(v, amplitude, wavelength float64) float64 {
+		if amplitude == 0 || wavelength <= 0 {
+			return 0
+		}
+		return amplitude * math.Sin(2*math.Pi*v/wavelength)
+	}
+	return remap(src, w, h, func(x, y int) (float64, float64) {
+		return float64(x) + wave(float64(y), amplitudeX, wavelengthX), float64(y) + wave(float64(x), amplitudeY, wavelengthY)
+	}, getTransformOptions(opts))
+}
+
+// OpsWave 波浪效果操作
+func OpsWave(amplitudeX, wavelengthX, amplitudeY, wavelengthY float64, opts ...TransformOptions) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		ctx.Dst = Wave(ctx.Dst, amplitudeX, wavelengthX, amplitudeY, wavelengthY, opts...).(*image.RGBA)
+		return nil
+	}
+}
+
+// Ripple 水波纹效果，从图像中心向外的同心圆波纹，像素沿半径方向偏移
+// 参数:
+// - amplitude 振幅(像素)
+// - wavelength 波长(像素)
+// - phase 相位(度)，连续改变相位可以生成波纹扩散的动画
+// - opts 插值方式和边界处理方式，可选
+func Ripple(src image.Image, amplitude, wavelength, phase float64, opts ...TransformOptions) image.Image {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	if wavelength <= 0 {
+		return remap(src, w, h, func(x, y int) (float64, float64) { return float64(x), float64(y) }, getTransformOptions(opts))
+	}
+	cx, cy := warpCenter(w, h)
+	ph := phase * math.Pi / 180
+	return remap(src, w, h, func(x, y int) (float64, float64) {
+		dx, dy := float64(x)-cx, float64(y)-cy
+		d := math.Hypot(dx, dy)
+		if d == 0 {
+			return float64(x), float64(y)
+		}
+		offset := amplitude * math.Sin(2*math.Pi*d/wavelength-ph)
+		return float64(x) + dx/d*offset, float64(y) + dy/d*offset
+	}, getTransformOptions(opts))
+}
+
+// OpsRipple 水波纹效果操作
+func OpsRipple(amplitude, wavelength, phase float64, opts ...TransformOptions) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		ctx.Dst = Ripple(ctx.Dst, amplitude, wavelength, phase, opts...).(*image.RGBA)
+		return nil
+	}
+}
+
+// CartesianToPolar 直角坐标转极坐标：以图像中心为极点展开，输出的 x 方向为角度(从正右方开始顺时针一周)，y 方向为到中心的距离(从0到对角线的一半)
+// 常用于展开圆形的表盘、瓶身标签等
+// 参数:
+// - width, height 输出的宽高，小于等于0时与源图像相同
+// - opts 插值方式和边界处理方式，可选
+func CartesianToPolar(src image.Image, width, height int, opts ...TransformOptions) image.Image {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	if width <= 0 {
+		width = w
+	}
+	if height <= 0 {
+		height = h
+	}
+	cx, cy := warpCenter(w, h)
+	maxR := math.Hypot(float64(w), float64(h)) / 2
+	return remap(src, width, height, func(x, y int) (float64, float64) {
+		theta := 2 * math.Pi * (float64(x) + 0.5) / float64(width)
+		r := maxR * (float64(y) + 0.5) / float64(height)
+		sin, cos := math.Sincos(theta)
+		return cx + r*cos, cy + r*sin
+	}, getTransformOptions(opts))
+}
+
+// OpsCartesianToPolar 直角坐标转极坐标操作
+func OpsCartesianToPolar(width, height int, opts ...TransformOptions) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		ctx.Dst = CartesianToPolar(ctx.Dst, width, height, opts...).(*image.RGBA)
+		return nil
+	}
+}
+
+// PolarToCartesian 极坐标转直角坐标，CartesianToPolar 的逆过程：源图像的 x 方向为角度，y 方向为到中心的距离(从0到输出图像对角线的一半)
+// 对 CartesianToPolar 的结果使用原图的宽高调用可以还原图像，也可以将横向的图案卷成圆环
+// 参数:
+// - width, height 输出的宽高，小于等于0时与源图像相同
+// - opts 插值方式和边界处理方式，可选
+func PolarToCartesian(src image.Image, width, height int, opts ...TransformOptions) image.Image {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	if width <= 0 {
+		width = w
+	}
+	if height <= 0 {
+		height = h
+	}
+	cx, cy := warpCenter(width, height)
+	maxR := math.Hypot(float64(width), float64(height)) / 2
+	return remap(src, width, height, func(x, y int) (float64, float64) {
+		dx, dy := float64(x)-cx, float64(y)-cy
+		theta := math.Atan2(dy, dx)
+		if theta < 0 {
+			theta += 2 * math.Pi
+		}
+		// 角度的起止处和中心处限制在首尾像素以内，避免插值时混入图像以外的像素形成接缝
+		sx := math.Max(0, math.Min(float64(w-1), theta/(2*math.Pi)*float64(w)-0.5))
+		sy := math.Max(0, math.Hypot(dx, dy)/maxR*float64(h)-0.5)
+		return sx, sy
+	}, getTransformOptions(opts))
+}
+
+// OpsPolarToCartesian 极坐标转直角坐标操作
+func OpsPolarToCartesian(width, height int, opts ...TransformOptions) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		ctx.Dst = PolarToCartesian(ctx.Dst, width, height, opts...).(*image.RGBA)
+		return nil
+	}
+}
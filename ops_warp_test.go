@@ -0,0 +1,132 @@
+package imgHelper
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+// sameImage 比较两个图像的像素，允许 tolerance 的误差
+func sameImage(t *testing.T, got, want image.Image, tolerance uint8) {
+	t.Helper()
+	if got.Bounds().Size() != want.Bounds().Size() {
+		t.Fatalf("尺寸 %v, want %v", got.Bounds().Size(), want.Bounds().Size())
+	}
+	gb, wb := got.Bounds(), want.Bounds()
+	for y := 0; y < gb.Dy(); y++ {
+		for x := 0; x < gb.Dx(); x++ {
+			g := color.RGBAModel.Convert(got.At(gb.Min.X+x, gb.Min.Y+y)).(color.RGBA)
+			w := color.RGBAModel.Convert(want.At(wb.Min.X+x, wb.Min.Y+y)).(color.RGBA)
+			if absDiff(g.R, w.R) > tolerance || absDiff(g.G, w.G) > tolerance || absDiff(g.B, w.B) > tolerance || absDiff(g.A, w.A) > tolerance {
+				t.Fatalf("(%d, %d) = %v, want %v", x, y, g, w)
+			}
+		}
+	}
+}
+
+func TestRemapIdentity(t *testing.T) {
+	src := noisyImage(image.Rect(4, -2, 20, 10))
+	identity := NewCoordinateMap(16, 12, func(x, y int) (float64, float64) { return float64(x), float64(y) })
+	for _, interp := range []Interpolation{InterpNearest, InterpBilinear, InterpBicubic, InterpLanczos} {
+		dst, err := Remap(src, identity, TransformOptions{Interpolation: interp})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dst.Bounds() != image.Rect(0, 0, 16, 12) {
+			t.Fatalf("Bounds = %v", dst.Bounds())
+		}
+		sameImage(t, dst, src, 0)
+	}
+	if _, err := Remap(src, &CoordinateMap{Width: 2, Height: 2, X: make([]float64, 4), Y: make([]float64, 3)}); err == nil {
+		t.Error("映射表大小不一致时 want error")
+	}
+}
+
+func TestWarpIdentity(t *testing.T) {
+	src := noisyImage(image.Rect(0, 0, 15, 11))
+	cases := []struct {
+		name string
+		dst  image.Image
+	}{
+		{"无畸变", LensCorrect(src, LensOptions{})},
+		{"无畸变并指定光心", LensCorrect(src, LensOptions{Cx: floatPtr(0), Cy: floatPtr(0), Focal: 7})},
+		{"强度为0的桶形畸变", BarrelDistort(src, 0)},
+		{"角度为0的漩涡", Swirl(src, 0, 0)},
+		{"振幅为0的波浪", Wave(src, 0, 10, 0, 10)},
+		{"振幅为0的水波纹", Ripple(src, 0, 10, 0)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sameImage(t, c.dst, src, 0)
+		})
+	}
+}
+
+func TestLensMappingCenter(t *testing.T) {
+	cases := []struct {
+		name   string
+		lens   LensOptions
+		center image.Point // 光心处的像素不移动
+	}{
+		{"默认为图像中心", LensOptions{K1: 0.3}, image.Pt(10, 6)},
+		// 光心可以为 (0,0)，不会被当作默认值
+		{"光心为原点", LensOptions{K1: 0.3, Cx: floatPtr(0), Cy: floatPtr(0)}, image.Pt(0, 0)},
+		{"只指定 Cx", LensOptions{K1: 0.3, Cx: floatPtr(3)}, image.Pt(3, 6)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fn := lensMapping(21, 13, c.lens)
+			if x, y := fn(c.center.X, c.center.Y); math.Abs(x-float64(c.center.X)) > 1e-9 || math.Abs(y-float64(c.center.Y)) > 1e-9 {
+				t.Errorf("光心 %v 映射到 (%g, %g)", c.center, x, y)
+			}
+			// 枕形畸变系数为正时，离光心越远的像素向外移动
+			x, _ := fn(c.center.X+8, c.center.Y)
+			if x <= float64(c.center.X+8) {
+				t.Errorf("光心右侧8像素映射到 x=%g, want > %d", x, c.center.X+8)
+			}
+		})
+	}
+}
+
+func TestWarpCenter(t *testing.T) {
+	// 9x9 的图像中心为像素 (4,4)，只有中心像素为白色
+	src := uniformRGBA(9, 9, color.RGBA{A: 255})
+	src.SetRGBA(4, 4, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	if cx, cy := warpCenter(9, 9); cx != 4 || cy != 4 {
+		t.Fatalf("warpCenter = (%g, %g), want (4, 4)", cx, cy)
+	}
+	nearest := TransformOptions{Interpolation: InterpNearest}
+	// 极坐标图像只有距离为0的第一行为白色
+	polar := uniformRGBA(16, 40, color.RGBA{A: 255})
+	for x := 0; x < 16; x++ {
+		polar.SetRGBA(x, 0, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	}
+	cases := []struct {
+		name string
+		dst  image.Image
+		at   image.Point // 取中心像素的位置
+		off  image.Point // 不取中心像素的位置
+	}{
+		{"漩涡中心不动", Swirl(src, 90, 0, nearest), image.Pt(4, 4), image.Pt(5, 5)},
+		{"水波纹中心不动", Ripple(src, 2, 5, 30, nearest), image.Pt(4, 4), image.Pt(0, 0)},
+		{"镜头畸变中心不动", LensCorrect(src, LensOptions{K1: -0.4}, nearest), image.Pt(4, 4), image.Pt(5, 4)},
+		// 极坐标展开的第一行为中心附近的像素
+		{"极坐标展开", CartesianToPolar(src, 16, 40, nearest), image.Pt(7, 0), image.Pt(7, 20)},
+		{"极坐标还原", PolarToCartesian(polar, 9, 9, nearest), image.Pt(4, 4), image.Pt(5, 5)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := color.RGBAModel.Convert(c.dst.At(c.at.X, c.at.Y)).(color.RGBA); got.R != 255 {
+				t.Errorf("%v = %v, want 白色", c.at, got)
+			}
+			if got := color.RGBAModel.Convert(c.dst.At(c.off.X, c.off.Y)).(color.RGBA); got.R == 255 {
+				t.Errorf("%v = %v, want 黑色", c.off, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package imgHelper
+
+import (
+	"image"
+	"math"
+)
+
+// pathTolerance 曲线展开为折线时允许的最大误差(像素)
+const pathTolerance = 0.1
+
+// RangePath 曲边多边形（路径）范围，边界由直线段、圆弧、椭圆弧、二次/三次贝塞尔曲线和样条曲线首尾相连组成
+// 可包含多个子路径，按奇偶规则填充，子路径内的子路径为孔洞
+// 用 NewRangePath 创建，链式调用 LineTo、ArcTo、QuadTo、CubicTo 等添加边，坐标以像素中心为整数
+// 零值也可以直接使用，没有调用 MoveTo 时第一个子路径的起点为 (0,0)
+// 可用于 OpsCrop、OpsMosaic，也可以通过 NewSolidPath、NewOutlinePath 绘制到几何图层
+type RangePath struct {
+	rings  [][][2]float64 // 子路径展开后的折线
+	closed []bool         // 子路径是否调用了 Close，描边时闭合
+}
+
+func (*RangePath) Type() RangeType {
+	return RangePathType
+}
+
+// NewRangePath 创建路径，(x, y) 为第一个子路径的起点
+func NewRangePath(x, y float64) *RangePath {
+	p := &RangePath{}
+	return p.MoveTo(x, y)
+}
+
+// current 当前点，还没有子路径时以 (0,0) 为起点开始第一个子路径
+func (p *RangePath) current() [2]float64 {
+	if len(p.rings) == 0 {
+		p.MoveTo(0, 0)
+	}
+	ring := p.rings[len(p.rings)-1]
+	return ring[len(ring)-1]
+}
+
+// MoveTo 以 (x, y) 为起点开始新的子路径
+func (p *RangePath) MoveTo(x, y float64) *RangePath {
+	// 上一个子路径只有起点时直接替换
+	if n := len(p.rings); n > 0 && len(p.rings[n-1]) == 1 {
+		p.rings[n-1][0] = [2]float64{x, y}
+		p.closed[n-1] = false
+		return p
+	}
+	p.rings = append(p.rings, [][2]float64{{x, y}})
+	p.closed = append(p.closed, false)
+	return p
+}
+
+// LineTo 直线段连接到 (x, y)
+func (p *RangePath) LineTo(x, y float64) *RangePath {
+	if p.current() != [2]float64{x, y} {
+		n := len(p.rings) - 1
+		p.rings[n] = append(p.rings[n], [2]float64{x, y})
+	}
+	return p
+}
+
+// QuadTo 二次贝塞尔曲线连接到 (x, y)，(cx, cy) 为控制点
+func (p *RangePath) QuadTo(cx, cy, x, y float64) *RangePath {
+	p0 := p.current()
+	dx, dy := p0[0]-2*cx+x, p0[1]-2*cy+y
+	n := bezierSteps(2 * math.Hypot(dx, dy))
+	for i := 1; i <= n; i++ {
+		t := float64(i) / float64(n)
+		mt := 1 - t
+		p.LineTo(mt*mt*p0[0]+2*mt*t*cx+t*t*x, mt*mt*p0[1]+2*mt*t*cy+t*t*y)
+	}
+	return p
+}
+
+// CubicTo 三次贝塞尔曲线连接到 (x, y)，(c1x, c1y)、(c2x, c2y) 为两个控制点
+func (p *RangePath) CubicTo(c1x, c1y, c2x, c2y, x, y float64) *RangePath {
+	p0 := p.current()
+	d1 := math.Hypot(p0[0]-2*c1x+c2x, p0[1]-2*c1y+c2y)
+	d2 := math.Hypot(c1x-2*c2x+x, c1y-2*c2y+y)
+	n := bezierSteps(6 * math.Max(d1, d2))
+	for i := 1; i <= n; i++ {
+		t := float64(i) / float64(n)
+		mt := 1 - t
+		a, b, c, d := mt*mt*mt, 3*mt*mt*t, 3*mt*t*t, t*t*t
+		p.LineTo(a*p0[0]+b*c1x+c*c2x+d*x, a*p0[1]+b*c1y+c*c2y+d*y)
+	}
+	return p
+}
+
+// bezierSteps 曲线展开的段数，secondDerivative 为曲线二阶导数的上界，折线与曲线的误差不超过 pathTolerance
+func bezierSteps(secondDerivative float64) int {
+	n := int(math.Ceil(math.Sqrt(secondDerivative / (8 * pathTolerance))))
+	return clamp(n, 1, 4096)
+}
+
+// SplineTo 经过给定各点的平滑曲线(Catmull-Rom 样条)，用于由离散点拟合自由曲线
+func (p *RangePath) SplineTo(points ...[2]float64) *RangePath {
+	pts := append([][2]float64{p.current()}, points...)
+	for i := 0; i+1 < len(pts); i++ {
+		prev, next := pts[maxValue(i-1, 0)], pts[minValue(i+2, len(pts)-1)]
+		a, b := pts[i], pts[i+1]
+		p.CubicTo(
+			a[0]+(b[0]-prev[0])/6, a[1]+(b[1]-prev[1])/6,
+			b[0]-(next[0]-a[0])/6, b[1]-(next[1]-a[1])/6,
+			b[0], b[1],
+		)
+	}
+	return p
+}
+
+// Arc 以 (cx, cy) 为圆心从当前点画圆弧，angle 为扫过的角度(度)，正数为顺时针
+func (p *RangePath) Arc(cx, cy, angle float64) *RangePath {
+	p0 := p.current()
+	r := math.Hypot(p0[0]-cx, p0[1]-cy)
+	start := math.Atan2(p0[1]-cy, p0[0]-cx)
+	p.ellipseArc(cx, cy, r, r, 0, start, angle*math.Pi/180)
+	return p
+}
+
+// ArcTo 椭圆弧连接到 (x, y)，参数含义与 SVG 路径的 A 指令一致
+// 参数:
+// - rx, ry 椭圆的两个半轴，过小时自动放大到刚好能连接两个端点
+// - rotation 椭圆 x 轴的旋转角度(度)，顺时针
+// - largeArc 是否取大于180度的弧
+// - sweep 是否顺时针画弧
+func (p *RangePath) ArcTo(rx, ry, rotation float64, largeArc, sweep bool, x, y float64) *RangePath {
+	p0 := p.current()
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 || p0 == [2]float64{x, y} {
+		return p.LineTo(x, y)
+	}
+	phi := rotation * math.Pi / 180
+	sin, cos := math.Sincos(phi)
+	dx, dy := (p0[0]-x)/2, (p0[1]-y)/2
+	x1, y1 := cos*dx+sin*dy, -sin*dx+cos*dy
+	if lambda := x1*x1/(rx*rx) + y1*y1/(ry*ry); lambda > 1 {
+		rx, ry = rx*math.Sqrt(lambda), ry*math.Sqrt(lambda)
+	}
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	coef := math.Sqrt(math.Max(0, num/den))
+	if largeArc == sweep {
+		coef = -coef
+	}
+	cx1, cy1 := coef*rx*y1/ry, -coef*ry*x1/rx
+	cx := cos*cx1 - sin*cy1 + (p0[0]+x)/2
+	cy := sin*cx1 + cos*cy1 + (p0[1]+y)/2
+	start := math.Atan2((y1-cy1)/ry, (x1-cx1)/rx)
+	delta := math.Atan2((-y1-cy1)/ry, (-x1-cx1)/rx) - start
+	if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	} else if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	}
+	p.ellipseArc(cx, cy, rx, ry, phi, start, delta)
+	// 消除累计误差，终点与给定的点一致
+	n := len(p.rings) - 1
+	p.rings[n][len(p.rings[n])-1] = [2]float64{x, y}
+	return p
+}
+
+// ellipseArc 展开椭圆弧，start、delta 为参数角(弧度)
+func (p *RangePath) ellipseArc(cx, cy, rx, ry, phi, start, delta float64) {
+	r := math.Max(rx, ry)
+	if r <= 0 || delta == 0 {
+		return
+	}
+	step := math.Pi / 2
+	if r > pathTolerance {
+		step = 2 * math.Acos(1-pathTolerance/r)
+	}
+	n := clamp(int(math.Ceil(math.Abs(delta)/step)), 1, 4096)
+	sin, cos := math.Sincos(phi)
+	for i := 1; i <= n; i++ {
+		ts, tc := math.Sincos(start + delta*float64(i)/float64(n))
+		ex, ey := rx*tc, ry*ts
+		p.LineTo(cx+cos*ex-sin*ey, cy+sin*ex+cos*ey)
+	}
+}
+
+// Close 闭合当前子路径，之后的边从该子路径的起点开始新的子路径
+func (p *RangePath) Close() *RangePath {
+	p.current()
+	n := len(p.rings) - 1
+	ring := p.rings[n]
+	if len(ring) > 1 && ring[len(ring)-1] == ring[0] {
+		p.rings[n] = ring[:len(ring)-1]
+	}
+	p.closed[n] = true
+	start := p.rings[n][0]
+	p.rings = append(p.rings, [][2]float64{start})
+	p.closed = append(p.closed, false)
+	return p
+}
+
+// Bounds 路径的外接矩形，包含所有被路径覆盖的像素
+func (p *RangePath) Bounds() image.Rectangle {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, ring := range p.rings {
+		for _, pt := range ring {
+			minX, minY = math.Min(minX, pt[0]), math.Min(minY, pt[1])
+			maxX, maxY = math.Max(maxX, pt[0]), math.Max(maxY, pt[1])
+		}
+	}
+	if minX > maxX {
+		return image.Rectangle{}
+	}
+	return image.Rect(int(math.Floor(minX+0.5)), int(math.Floor(minY+0.5)), int(math.Ceil(maxX+0.5)), int(math.Ceil(maxY+0.5)))
+}
+
+// fillRings 用于填充的子路径，去掉不足3个点的子路径
+func (p *RangePath) fillRings() [][][2]float64 {
+	rings := make([][][2]float64, 0, len(p.rings))
+	for _, ring := range p.rings {
+		if len(ring) >= 3 {
+			rings = append(rings, ring)
+		}
+	}
+	return rings
+}
+
+// NewReuleauxPath 鲁洛多边形：以正 n 边形(n 为不小于3的奇数)的每个顶点为圆心、对角顶点距离为半径画弧围成的等宽图形
+// 参数:
+// - cx, cy 中心
+// - r 外接圆半径
+// - n 边数，偶数时加1
+// - rotation 旋转角度(度)，0 时第一个顶点在正上方
+func NewReuleauxPath(cx, cy, r float64, n int, rotation float64) *RangePath {
+	n = maxValue(n, 3)
+	if n%2 == 0 {
+		n++
+	}
+	vertices := make([][2]float64, n)
+	for i := range vertices {
+		sin, cos := math.Sincos((rotation-90)*math.Pi/180 + 2*math.Pi*float64(i)/float64(n))
+		vertices[i] = [2]float64{cx + r*cos, cy + r*sin}
+	}
+	p := NewRangePath(vertices[0][0], vertices[0][1])
+	for i := range vertices {
+		center := vertices[(i+(n+1)/2)%n]
+		p.Arc(center[0], center[1], 180/float64(n))
+	}
+	return p.Close()
+}
+
+// NewRoundedRectPath 圆角矩形，范围与 Range{x0, y0, x1, y1} 一致，radius 为圆角半径
+func NewRoundedRectPath(x0, y0, x1, y1 int, radius float64) *RangePath {
+	fx0, fy0, fx1, fy1 := float64(x0)-0.5, float64(y0)-0.5, float64(x1)-0.5, float64(y1)-0.5
+	radius = clamp(radius, 0, math.Min(fx1-fx0, fy1-fy0)/2)
+	return NewRangePath(fx0+radius, fy0).
+		LineTo(fx1-radius, fy0).ArcTo(radius, radius, 0, false, true, fx1, fy0+radius).
+		LineTo(fx1, fy1-radius).ArcTo(radius, radius, 0, false, true, fx1-radius, fy1).
+		LineTo(fx0+radius, fy1).ArcTo(radius, radius, 0, false, true, fx0, fy1-radius).
+		LineTo(fx0, fy0+radius).ArcTo(radius, radius, 0, false, true, fx0+radius, fy0).
+		Close()
+}
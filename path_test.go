@@ -0,0 +1,86 @@
+package imgHelper
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestRangePathZeroValue(t *testing.T) {
+	// 零值的路径以 (0,0) 为第一个子路径的起点
+	cases := []struct {
+		name string
+		got  *RangePath
+		want *RangePath
+	}{
+		{"LineTo", new(RangePath).LineTo(10, 0).LineTo(10, 10), NewRangePath(0, 0).LineTo(10, 0).LineTo(10, 10)},
+		{"QuadTo", new(RangePath).QuadTo(5, 10, 10, 0), NewRangePath(0, 0).QuadTo(5, 10, 10, 0)},
+		{"Arc", new(RangePath).Arc(5, 0, 180), NewRangePath(0, 0).Arc(5, 0, 180)},
+		{"Close", new(RangePath).Close().LineTo(3, 0), NewRangePath(0, 0).Close().LineTo(3, 0)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if len(c.got.rings) != len(c.want.rings) {
+				t.Fatalf("子路径数 %d, want %d", len(c.got.rings), len(c.want.rings))
+			}
+			for i := range c.got.rings {
+				if len(c.got.rings[i]) != len(c.want.rings[i]) || c.got.rings[i][0] != c.want.rings[i][0] || c.got.closed[i] != c.want.closed[i] {
+					t.Errorf("子路径 %d = %v, want %v", i, c.got.rings[i], c.want.rings[i])
+				}
+			}
+		})
+	}
+	var empty RangePath
+	if b := empty.Bounds(); !b.Empty() {
+		t.Errorf("空路径的 Bounds = %v", b)
+	}
+}
+
+func TestRangePathArea(t *testing.T) {
+	bounds := image.Rect(0, 0, 100, 100)
+	side := 40 * math.Sqrt(3) // 外接圆半径为40的正三角形的边长
+	cases := []struct {
+		name string
+		path *RangePath
+		want float64
+		tol  float64 // 曲线展开为内接折线，面积的误差不超过周长*pathTolerance
+	}{
+		{"矩形", NewRangePath(9.5, 19.5).LineTo(29.5, 19.5).LineTo(29.5, 24.5).LineTo(9.5, 24.5).Close(), 100, 0},
+		{"圆弧围成的圆", NewRangePath(70, 50).Arc(50, 50, 360).Close(), math.Pi * 400, 40 * math.Pi * pathTolerance},
+		{"两个半圆的 ArcTo", NewRangePath(30, 50).ArcTo(20, 20, 0, false, true, 70, 50).ArcTo(20, 20, 0, false, true, 30, 50).Close(), math.Pi * 400, 40 * math.Pi * pathTolerance},
+		{"子路径为孔洞", NewRangePath(9.5, 9.5).LineTo(29.5, 9.5).LineTo(29.5, 29.5).LineTo(9.5, 29.5).Close().
+			MoveTo(14.5, 14.5).LineTo(24.5, 14.5).LineTo(24.5, 24.5).LineTo(14.5, 24.5).Close(), 300, 0},
+		{"鲁洛三角形", NewReuleauxPath(50, 50, 40, 3, 0), (math.Pi - math.Sqrt(3)) / 2 * side * side, math.Pi * side * pathTolerance},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mask, err := rangeMask(c.path, bounds)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := maskArea(mask); math.Abs(got-c.want) > c.tol+1e-9 {
+				t.Errorf("面积 %g, want %g±%g", got, c.want, c.tol)
+			}
+		})
+	}
+}
+
+func TestRangePathEdges(t *testing.T) {
+	p := NewRangePath(0, 0).MoveTo(5, 5).LineTo(15, 5).LineTo(15, 5).ArcTo(5, 5, 0, false, true, 15, 15)
+	// 只有起点的子路径被 MoveTo 替换，重复的点不会重复添加
+	if len(p.rings) != 1 || p.rings[0][0] != [2]float64{5, 5} || p.rings[0][1] != [2]float64{15, 5} || p.rings[0][2] == p.rings[0][1] {
+		t.Errorf("子路径 %v", p.rings)
+	}
+	// ArcTo 的终点与给定的点一致，半径过小时自动放大
+	if got := p.current(); got != [2]float64{15, 15} {
+		t.Errorf("ArcTo 终点 %v, want (15, 15)", got)
+	}
+	if got, want := p.Bounds(), image.Rect(5, 5, 21, 16); got != want {
+		t.Errorf("Bounds = %v, want %v", got, want)
+	}
+	// Close 之后从该子路径的起点开始新的子路径
+	p.Close()
+	if len(p.rings) != 2 || !p.closed[0] || p.closed[1] || p.current() != [2]float64{5, 5} {
+		t.Errorf("Close 之后 %d 个子路径, 当前点 %v", len(p.rings), p.current())
+	}
+}
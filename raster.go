@@ -0,0 +1,172 @@
+package imgHelper
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sort"
+)
+
+// maskSubSamples 计算覆盖面积时每行像素使用的子扫描线数，水平方向的覆盖长度是精确计算的
+const maskSubSamples = 16
+
+// coverageMask 按奇偶规则填充由若干闭合环组成的区域，掩码值为每个像素被区域覆盖的面积比例，边缘平滑无锯齿
+// 坐标以像素中心为整数，即像素 (x, y) 覆盖 [x-0.5, x+0.5) × [y-0.5, y+0.5)；环内的环为孔洞
+func coverageMask(rings [][][2]float64, bounds image.Rectangle) *image.Gray {
+	mask := image.NewGray(bounds)
+	// 边按 y 从小到大存储，坐标平移半个像素，使像素 x 覆盖 [x, x+1)
+	type edge struct{ x0, y0, x1, y1 float64 }
+	var edges []edge
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, ring := range rings {
+		if len(ring) < 3 {
+			continue
+		}
+		for i := range ring {
+			a, b := ring[i], ring[(i+1)%len(ring)]
+			ax, ay, bx, by := a[0]+0.5, a[1]+0.5, b[0]+0.5, b[1]+0.5
+			minX, minY = math.Min(minX, ax), math.Min(minY, ay)
+			maxX, maxY = math.Max(maxX, ax), math.Max(maxY, ay)
+			if ay == by {
+				continue
+			}
+			if ay > by {
+				ax, ay, bx, by = bx, by, ax, ay
+			}
+			edges = append(edges, edge{ax, ay, bx, by})
+		}
+	}
+	if len(edges) == 0 {
+		return mask
+	}
+	r := image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX)), int(math.Ceil(maxY))).Intersect(bounds)
+	if r.Empty() {
+		return mask
+	}
+
+	w := r.Dx()
+	left, right := float64(r.Min.X), float64(r.Max.X)
+	cover := make([]float64, w+1) // 部分覆盖的像素
+	run := make([]float64, w+2)   // 完全覆盖的像素区间，差分存储
+	var xs []float64
+	const step = 1.0 / maskSubSamples
+	for py := r.Min.Y; py < r.Max.Y; py++ {
+		clear(cover)
+		clear(run)
+		for s := 0; s < maskSubSamples; s++ {
+			sy := float64(py) + (float64(s)+0.5)*step
+			xs = xs[:0]
+			for _, e := range edges {
+				if sy >= e.y0 && sy < e.y1 {
+					xs = append(xs, e.x0+(sy-e.y0)*(e.x1-e.x0)/(e.y1-e.y0))
+				}
+			}
+			sort.Float64s(xs)
+			for i := 0; i+1 < len(xs); i += 2 {
+				a := math.Max(xs[i], left) - left
+				b := math.Min(xs[i+1], right) - left
+				if a >= b {
+					continue
+				}
+				ia, ib := int(a), int(b)
+				if ia == ib {
+					cover[ia] += (b - a) * step
+					continue
+				}
+				cover[ia] += (float64(ia+1) - a) * step
+				run[ia+1] += step
+				run[ib] -= step
+				cover[ib] += (b - float64(ib)) * step
+			}
+		}
+		row := mask.Pix[mask.PixOffset(r.Min.X, py):]
+		acc := 0.0
+		for x := 0; x < w; x++ {
+			acc += run[x]
+			row[x] = uint8(clamp(math.Round((cover[x]+acc)*255), 0, 255))
+		}
+	}
+	return mask
+}
+
+// circleRing 用多边形逼近圆，边长约1.5像素，与真实圆的误差远小于1像素
+func circleRing(cx, cy, r float64) [][2]float64 {
+	if r <= 0 {
+		return nil
+	}
+	n := clamp(int(math.Ceil(2*math.Pi*r/1.5)), 16, 4096)
+	ring := make([][2]float64, n)
+	for i := range ring {
+		sin, cos := math.Sincos(2 * math.Pi * float64(i) / float64(n))
+		ring[i] = [2]float64{cx + r*cos, cy + r*sin}
+	}
+	return ring
+}
+
+// featherMask 羽化：用高斯模糊柔化掩码的边缘，radius 为过渡带宽度的一半，只处理掩码非零区域附近
+func featherMask(mask *image.Gray, radius float64) *image.Gray {
+	if radius <= 0 {
+		return mask
+	}
+	sigma := radius / 2
+	r := maskBounds(mask).Inset(-int(math.Ceil(3*sigma)) - 1).Intersect(mask.Bounds())
+	if r.Empty() {
+		return mask
+	}
+	w, h := r.Dx(), r.Dy()
+	plane := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		row := mask.Pix[mask.PixOffset(r.Min.X, r.Min.Y+y):]
+		for x := 0; x < w; x++ {
+			plane[y*w+x] = float64(row[x])
+		}
+	}
+	blurred := planeToGray(blurPlane(plane, w, h, sigma), w, h, r)
+	dst := image.NewGray(mask.Bounds())
+	for y := 0; y < h; y++ {
+		copy(dst.Pix[dst.PixOffset(r.Min.X, r.Min.Y+y):dst.PixOffset(r.Max.X, r.Min.Y+y)], blurred.Pix[y*blurred.Stride:y*blurred.Stride+w])
+	}
+	return dst
+}
+
+// strokeMask 沿折线描边的抗锯齿掩码，width 为线宽，拐角和端点为圆形；closed 对应的环首尾相连
+func strokeMask(rings [][][2]float64, closed []bool, width float64, bounds image.Rectangle) *image.Gray {
+	mask := image.NewGray(bounds)
+	hw := width / 2
+	segment := func(a, b [2]float64) {
+		r := image.Rect(
+			int(math.Floor(math.Min(a[0], b[0])-hw-1)), int(math.Floor(math.Min(a[1], b[1])-hw-1)),
+			int(math.Ceil(math.Max(a[0], b[0])+hw+1))+1, int(math.Ceil(math.Max(a[1], b[1])+hw+1))+1,
+		).Intersect(bounds)
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				// 像素中心到线段的距离，在线的边缘处按距离线性过渡
+				d := pointToSegmentDist(float64(x), float64(y), a[0], a[1], b[0], b[1])
+				v := uint8(clamp(math.Round((hw-d+0.5)*255), 0, 255))
+				if o := mask.PixOffset(x, y); v > mask.Pix[o] {
+					mask.Pix[o] = v
+				}
+			}
+		}
+	}
+	for i, ring := range rings {
+		if len(ring) == 1 {
+			continue
+		}
+		for j := 0; j+1 < len(ring); j++ {
+			segment(ring[j], ring[j+1])
+		}
+		if i < len(closed) && closed[i] && len(ring) > 2 {
+			segment(ring[len(ring)-1], ring[0])
+		}
+	}
+	return mask
+}
+
+// fillMask 按掩码值作为不透明度，将颜色叠加到 dst 上
+func fillMask(dst *image.RGBA, mask *image.Gray, c color.Color) {
+	alpha := &image.Alpha{Pix: mask.Pix, Stride: mask.Stride, Rect: mask.Rect}
+	draw.DrawMask(dst, mask.Rect.Intersect(dst.Bounds()), image.NewUniform(c), image.Point{}, alpha, mask.Rect.Intersect(dst.Bounds()).Min, draw.Over)
+}
@@ -0,0 +1,63 @@
+package imgHelper
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+// maskArea 掩码覆盖的面积(像素)
+func maskArea(mask *image.Gray) float64 {
+	sum := 0
+	for _, v := range mask.Pix {
+		sum += int(v)
+	}
+	return float64(sum) / 255
+}
+
+// rectRing 以像素中心为整数的矩形环，覆盖像素 [x0, x1) × [y0, y1)
+func rectRing(x0, y0, x1, y1 float64) [][2]float64 {
+	return [][2]float64{{x0 - 0.5, y0 - 0.5}, {x1 - 0.5, y0 - 0.5}, {x1 - 0.5, y1 - 0.5}, {x0 - 0.5, y1 - 0.5}}
+}
+
+func TestCoverageMaskArea(t *testing.T) {
+	bounds := image.Rect(0, 0, 100, 100)
+	cases := []struct {
+		name  string
+		rings [][][2]float64
+		want  float64
+		tol   float64
+	}{
+		{"对齐像素的矩形", [][][2]float64{rectRing(10, 20, 30, 25)}, 100, 0},
+		{"半像素偏移的矩形", [][][2]float64{rectRing(10.5, 20, 30.5, 25)}, 100, 0.5},
+		{"三角形", [][][2]float64{{{10, 10}, {50, 10}, {10, 50}}}, 800, 2},
+		{"圆", [][][2]float64{circleRing(50, 50, 20)}, math.Pi * 400, 3},
+		{"带孔洞的矩形", [][][2]float64{rectRing(10, 10, 30, 30), rectRing(15, 15, 25, 25)}, 300, 0},
+		{"超出范围的部分被裁掉", [][][2]float64{rectRing(-10, -10, 10, 10)}, 100, 0},
+		{"不足3点的环被忽略", [][][2]float64{{{0, 0}, {50, 50}}}, 0, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := maskArea(coverageMask(c.rings, bounds))
+			if math.Abs(got-c.want) > c.tol+1e-9 {
+				t.Errorf("面积 %g, want %g±%g", got, c.want, c.tol)
+			}
+		})
+	}
+}
+
+func TestCoverageMaskPixels(t *testing.T) {
+	// 对齐像素的矩形内部完全覆盖、外部完全不覆盖
+	mask := coverageMask([][][2]float64{rectRing(2, 3, 6, 5)}, image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			want := uint8(0)
+			if x >= 2 && x < 6 && y >= 3 && y < 5 {
+				want = 255
+			}
+			if got := mask.GrayAt(x, y).Y; got != want {
+				t.Errorf("(%d, %d) = %d, want %d", x, y, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,410 @@
+package imgHelper
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// Transform 二维几何变换，用 3x3 齐次矩阵表示，将源图像坐标 (x, y) 映射到 (x', y')：
+// [x'w, y'w, w] = M * [x, y, 1]
+// 坐标系与图像一致：原点在左上角，x 向右，y 向下，角度为正时顺时针旋转
+// 各方法不修改原变换而是返回新的变换，可以链式调用，后调用的操作在先调用的操作之后生效，例如:
+// NewTransform().About(100, 100).Rotate(30).Scale(2, 2).Translate(10, 0)
+type Transform struct {
+	m      [3][3]float64
+	cx, cy float64 // Rotate、Scale、Shear 的中心点，默认为原点
+}
+
+// identityMatrix 单位矩阵
+var identityMatrix = [3][3]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+
+// NewTransform 创建单位变换(不做任何变换)
+func NewTransform() Transform {
+	return Transform{m: identityMatrix}
+}
+
+// TransformFromMatrix 由 3x3 矩阵创建变换，与 PerspectiveTransform33、FindHomography 的矩阵含义相同
+func TransformFromMatrix(m [3][3]float64) Transform {
+	return Transform{m: m}
+}
+
+// TransformFromAffine 由 2x3 仿射矩阵创建变换，与 AffineTransform23 的矩阵含义相同
+func TransformFromAffine(m [2][3]float64) Transform {
+	return Transform{m: [3][3]float64{m[0], m[1], {0, 0, 1}}}
+}
+
+// matrix 返回变换矩阵，零值的 Transform 视为单位变换
+func (t Transform) matrix() [3][3]float64 {
+	if t.m == ([3][3]float64{}) {
+		return identityMatrix
+	}
+	return t.m
+}
+
+// Matrix 返回 3x3 变换矩阵
+func (t Transform) Matrix() [3][3]float64 {
+	return t.matrix()
+}
+
+// IsAffine 是否为仿射变换(没有透视分量)，仿射变换保持平行线平行
+func (t Transform) IsAffine() bool {
+	m := t.matrix()
+	return m[2][0] == 0 && m[2][1] == 0 && m[2][2] == 1
+}
+
+// then 在当前变换之后叠加 op 变换
+func (t Transform) then(op [3][3]float64) Transform {
+	t.m = mulMatrix33(op, t.matrix())
+	return t
+}
+
+// aroundCenter 在当前中心点处叠加 op 变换：先将中心点移到原点，变换后再移回
+func (t Transform) aroundCenter(op [3][3]float64) Transform {
+	if t.cx == 0 && t.cy == 0 {
+		return t.then(op)
+	}
+	op = mulMatrix33(op, [3][3]float64{{1, 0, -t.cx}, {0, 1, -t.cy}, {0, 0, 1}})
+	op = mulMatrix33([3][3]float64{{1, 0, t.cx}, {0, 1, t.cy}, {0, 0, 1}}, op)
+	return t.then(op)
+}
+
+// About 设置之后的 Rotate、Scale、Shear 的中心点，坐标为源图像坐标经过之前的变换后的位置
+func (t Transform) About(cx, cy float64) Transform {
+	t.m = t.matrix()
+	t.cx, t.cy = cx, cy
+	return t
+}
+
+// Translate 平移
+func (t Transform) Translate(tx, ty float64) Transform {
+	return t.then([3][3]float64{{1, 0, tx}, {0, 1, ty}, {0, 0, 1}})
+}
+
+// Rotate 绕中心点顺时针旋转 angle 度
+func (t Transform) Rotate(angle float64) Transform {
+	sin, cos := math.Sincos(angle * math.Pi / 180)
+	return t.aroundCenter([3][3]float64{{cos, -sin, 0}, {sin, cos, 0}, {0, 0, 1}})
+}
+
+// Scale 以中心点为基准缩放，sx、sy 为负数时镜像翻转
+func (t Transform) Scale(sx, sy float64) Transform {
+	return t.aroundCenter([3][3]float64{{sx, 0, 0}, {0, sy, 0}, {0, 0, 1}})
+}
+
+// Shear 以中心点为基准错切：x' = x + shx*y，y' = y + shy*x
+func (t Transform) Shear(shx, shy float64) Transform {
+	return t.aroundCenter([3][3]float64{{1, shx, 0}, {shy, 1, 0}, {0, 0, 1}})
+}
+
+// Then 组合变换：先执行当前变换，再执行 next，中心点沿用当前变换的设置
+func (t Transform) Then(next Transform) Transform {
+	return t.then(next.matrix())
+}
+
+// Inverse 逆变换，将目标坐标映射回源图像坐标，矩阵不可逆(如缩放为0)时返回错误
+func (t Transform) Inverse() (Transform, error) {
+	inv, ok := invertMatrix33(t.matrix())
+	if !ok {
+		return Transform{}, fmt.Errorf("变换矩阵不可逆")
+	}
+	return Transform{m: inv}, nil
+}
+
+// Apply 映射一个点，透视变换中点位于无穷远处时返回 NaN
+func (t Transform) Apply(x, y float64) (float64, float64) {
+	m := t.matrix()
+	w := m[2][0]*x + m[2][1]*y + m[2][2]
+	if w == 0 {
+		return math.NaN(), math.NaN()
+	}
+	return (m[0][0]*x + m[0][1]*y + m[0][2]) / w, (m[1][0]*x + m[1][1]*y + m[1][2]) / w
+}
+
+// ApplyPoint 映射一个整数点，结果四舍五入
+func (t Transform) ApplyPoint(p Point) Point {
+	x, y := t.Apply(float64(p.X), float64(p.Y))
+	return Point{X: int(math.Round(x)), Y: int(math.Round(y))}
+}
+
+// Bounds 矩形区域变换后的外接矩形
+// 透视变换时若矩形的一部分被映射到无穷远(齐次坐标 w 小于等于0)，返回错误
+func (t Transform) Bounds(r image.Rectangle) (image.Rectangle, error) {
+	m := t.matrix()
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, c := range [4][2]float64{
+		{float64(r.Min.X), float64(r.Min.Y)}, {float64(r.Max.X), float64(r.Min.Y)},
+		{float64(r.Max.X), float64(r.Max.Y)}, {float64(r.Min.X), float64(r.Max.Y)},
+	} {
+		// 透视变换只有在四个角都位于投影平面同一侧时，外接矩形才由四个角决定
+		if m[2][0]*c[0]+m[2][1]*c[1]+m[2][2] <= 0 {
+			return image.Rectangle{}, fmt.Errorf("变换后的区域无界，无法计算输出范围")
+		}
+		x, y := t.Apply(c[0], c[1])
+		minX, minY = math.Min(minX, x), math.Min(minY, y)
+		maxX, maxY = math.Max(maxX, x), math.Max(maxY, y)
+	}
+	// 消除浮点误差，避免旋转90度等整数结果多出一行一列
+	snap := func(v float64, round func(float64) float64) int {
+		if r := math.Round(v); math.Abs(v-r) < 1e-6 {
+			return int(r)
+		}
+		return int(round(v))
+	}
+	return image.Rect(snap(minX, math.Floor), snap(minY, math.Floor), snap(maxX, math.Ceil), snap(maxY, math.Ceil)), nil
+}
+
+// Interpolation 插值方式，几何变换时在源图像的浮点坐标处取值的方法
+type Interpolation int
+
+const (
+	InterpBilinear Interpolation = iota // 双线性插值（默认）
+	InterpNearest                       // 最近邻，速度最快，保持像素的硬边缘
+	InterpBicubic                       // 双三次插值(Catmull-Rom)，比双线性更锐利
+	InterpLanczos                       // Lanczos-3，最锐利，可能产生轻微振铃
+)
+
+// BorderMode 几何变换的边界处理方式，采样坐标超出源图像时如何取值
+type BorderMode int
+
+const (
+	BorderTransparent BorderMode = iota // 透明（默认）
+	BorderFill                          // 填充 TransformOptions.Fill 指定的颜色
+	BorderClamp                         // 取最近的边缘像素
+	BorderReflect                       // 以边缘像素为轴镜像取值
+	BorderWrap                          // 从图像另一侧循环取值
+)
+
+// TransformOptions 图像几何变换参数，零值字段使用默认值
+type TransformOptions struct {
+	KeepSize      bool          // 保持源图像的尺寸和位置，超出部分被裁掉；默认扩展输出范围以容纳变换后的完整图像
+	Interpolation Interpolation // 插值方式，默认双线性
+	Border        BorderMode    // 边界处理方式，默认透明
+	Fill          color.Color   // Border 为 BorderFill 时填充的颜色，为 nil 时为黑色
+}
+
+func getTransformOptions(opts []TransformOptions) TransformOptions {
+	if len(opts) > 0 {
+		return opts[0]
+	}
+	return TransformOptions{}
+}
+
+// TransformImage 对图像进行几何变换，对每个输出像素用逆变换在源图像中按插值方式采样
+// 返回变换后的图像和其左上角在源图像坐标系中的位置，扩展输出范围时位置可能为负数
+// 参数:
+// - t 几何变换，坐标为源图像的坐标
+// - opts 变换参数，可选
+func TransformImage(src image.Image, t Transform, opts ...TransformOptions) (image.Image, image.Point, error) {
+	opt := getTransformOptions(opts)
+	bounds := src.Bounds()
+	out := bounds
+	if !opt.KeepSize {
+		var err error
+		if out, err = t.Bounds(bounds); err != nil {
+			return nil, image.Point{}, err
+		}
+	}
+	dst, err := transformInto(src, t, out, opt)
+	if err != nil {
+		return nil, image.Point{}, err
+	}
+	return dst, out.Min, nil
+}
+
+// transformInto 将变换结果中 out 范围(源图像坐标系)内的部分输出为从 (0,0) 开始的图像
+func transformInto(src image.Image, t Transform, out image.Rectangle, opt TransformOptions) (*image.RGBA, error) {
+	inv, err := t.Inverse()
+	if err != nil {
+		return nil, err
+	}
+	m := inv.matrix()
+	s := newSampler(imageToRGBA(src), opt)
+	dst := image.NewRGBA(image.Rect(0, 0, out.Dx(), out.Dy()))
+	for y := 0; y < out.Dy(); y++ {
+		for x := 0; x < out.Dx(); x++ {
+			// 以像素中心采样
+			fx, fy := float64(x+out.Min.X)+0.5, float64(y+out.Min.Y)+0.5
+			w := m[2][0]*fx + m[2][1]*fy + m[2][2]
+			if w <= 0 {
+				dst.SetRGBA(x, y, s.fillColor())
+				continue
+			}
+			sx := (m[0][0]*fx + m[0][1]*fy + m[0][2]) / w
+			sy := (m[1][0]*fx + m[1][1]*fy + m[1][2]) / w
+			dst.SetRGBA(x, y, s.at(sx-0.5, sy-0.5))
+		}
+	}
+	return dst, nil
+}
+
+// sampler 按插值方式和边界处理方式在源图像的浮点坐标处采样，坐标以像素中心为整数
+type sampler struct {
+	img    *image.RGBA
+	interp Interpolation
+	border BorderMode
+	fill   [4]float64 // 预乘 Alpha 的填充色
+}
+
+func newSampler(img *image.RGBA, opt TransformOptions) *sampler {
+	s := &sampler{img: img, interp: opt.Interpolation, border: opt.Border}
+	if s.border == BorderFill {
+		fill := opt.Fill
+		if fill == nil {
+			fill = color.Black
+		}
+		r, g, b, a := fill.RGBA()
+		s.fill = [4]float64{float64(r >> 8), float64(g >> 8), float64(b >> 8), float64(a >> 8)}
+	}
+	return s
+}
+
+func (s *sampler) fillColor() color.RGBA {
+	return color.RGBA{R: uint8(s.fill[0]), G: uint8(s.fill[1]), B: uint8(s.fill[2]), A: uint8(s.fill[3])}
+}
+
+// kernel 插值核函数和支撑半径
+func (s *sampler) kernel() (func(float64) float64, int) {
+	switch s.interp {
+	case InterpBicubic:
+		return catmullRomKernel, 2
+	case InterpLanczos:
+		return lanczos3Kernel, 3
+	default:
+		return func(x float64) float64 { return math.Max(0, 1-math.Abs(x)) }, 1
+	}
+}
+
+// index 将一个方向上的下标映射到图像范围内，ok 为 false 表示取边界颜色(透明或填充色)
+func (s *sampler) index(i, lo, n int) (int, bool) {
+	var mode EdgeMode
+	switch s.border {
+	case BorderClamp:
+		mode = EdgeClamp
+	case BorderReflect:
+		mode = EdgeMirror
+	case BorderWrap:
+		mode = EdgeWrap
+	default:
+		mode = EdgeTransparent
+	}
+	i, ok := edgeIndex(i-lo, n, mode)
+	return i + lo, ok
+}
+
+func (s *sampler) at(x, y float64) color.RGBA {
+	b := s.img.Bounds()
+	if b.Empty() {
+		return s.fillColor()
+	}
+	if s.interp == InterpNearest {
+		px, okX := s.index(int(math.Floor(x+0.5)), b.Min.X, b.Dx())
+		py, okY := s.index(int(math.Floor(y+0.5)), b.Min.Y, b.Dy())
+		if !okX || !okY {
+			return s.fillColor()
+		}
+		return s.img.RGBAAt(px, py)
+	}
+
+	kernel, radius := s.kernel()
+	x0, y0 := int(math.Floor(x)), int(math.Floor(y))
+	// 采样范围完全在图像以外时直接取边界颜色
+	if (s.border == BorderTransparent || s.border == BorderFill) &&
+		(x0+radius < b.Min.X || y0+radius < b.Min.Y || x0-radius+1 >= b.Max.X || y0-radius+1 >= b.Max.Y) {
+		return s.fillColor()
+	}
+	var wx, wy [6]float64
+	var ix, iy [6]int
+	var okx, oky [6]bool
+	n := 2 * radius
+	var sumX, sumY float64
+	for k := 0; k < n; k++ {
+		i := x0 - radius + 1 + k
+		wx[k] = kernel(x - float64(i))
+		ix[k], okx[k] = s.index(i, b.Min.X, b.Dx())
+		sumX += wx[k]
+		j := y0 - radius + 1 + k
+		wy[k] = kernel(y - float64(j))
+		iy[k], oky[k] = s.index(j, b.Min.Y, b.Dy())
+		sumY += wy[k]
+	}
+	var c [4]float64
+	for ky := 0; ky < n; ky++ {
+		if wy[ky] == 0 {
+			continue
+		}
+		for kx := 0; kx < n; kx++ {
+			w := wx[kx] * wy[ky] / (sumX * sumY)
+			if w == 0 {
+				continue
+			}
+			// *image.RGBA 为预乘 Alpha，可以直接加权
+			if !okx[kx] || !oky[ky] {
+				for i := range c {
+					c[i] += s.fill[i] * w
+				}
+				continue
+			}
+			o := s.img.PixOffset(ix[kx], iy[ky])
+			for i := range c {
+				c[i] += float64(s.img.Pix[o+i]) * w
+			}
+		}
+	}
+	// 负权重可能使结果超出范围，且颜色分量不能超过 Alpha
+	a := math.Min(math.Max(c[3], 0), 255)
+	ch := func(v float64) uint8 { return uint8(math.Min(math.Max(v, 0), a) + 0.5) }
+	return color.RGBA{R: ch(c[0]), G: ch(c[1]), B: ch(c[2]), A: uint8(a + 0.5)}
+}
+
+// catmullRomKernel Catmull-Rom 三次插值核(a=-0.5)
+func catmullRomKernel(x float64) float64 {
+	x = math.Abs(x)
+	switch {
+	case x < 1:
+		return (1.5*x-2.5)*x*x + 1
+	case x < 2:
+		return ((-0.5*x+2.5)*x-4)*x + 2
+	}
+	return 0
+}
+
+// OpsTransform 几何变换操作，扩展输出范围时画布大小随之改变
+func OpsTransform(t Transform, opts ...TransformOptions) func(ctx *CanvasContext) error {
+	return func(ctx *CanvasContext) error {
+		dst, _, err := TransformImage(ctx.Dst, t, opts...)
+		if err != nil {
+			return err
+		}
+		ctx.Dst = dst.(*image.RGBA)
+		return nil
+	}
+}
+
+// mulMatrix33 矩阵乘法 a*b
+func mulMatrix33(a, b [3][3]float64) [3][3]float64 {
+	var r [3][3]float64
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			r[i][j] = a[i][0]*b[0][j] + a[i][1]*b[1][j] + a[i][2]*b[2][j]
+		}
+	}
+	return r
+}
+
+// invertMatrix33 求逆矩阵，不可逆时返回 false
+func invertMatrix33(m [3][3]float64) ([3][3]float64, bool) {
+	det := m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+		m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+		m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+	if math.Abs(det) < 1e-12 {
+		return [3][3]float64{}, false
+	}
+	return [3][3]float64{
+		{(m[1][1]*m[2][2] - m[1][2]*m[2][1]) / det, (m[0][2]*m[2][1] - m[0][1]*m[2][2]) / det, (m[0][1]*m[1][2] - m[0][2]*m[1][1]) / det},
+		{(m[1][2]*m[2][0] - m[1][0]*m[2][2]) / det, (m[0][0]*m[2][2] - m[0][2]*m[2][0]) / det, (m[0][2]*m[1][0] - m[0][0]*m[1][2]) / det},
+		{(m[1][0]*m[2][1] - m[1][1]*m[2][0]) / det, (m[0][1]*m[2][0] - m[0][0]*m[2][1]) / det, (m[0][0]*m[1][1] - m[0][1]*m[1][0]) / det},
+	}, true
+}
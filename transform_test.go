@@ -0,0 +1,72 @@
+package imgHelper
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestTransformInverse(t *testing.T) {
+	cases := []struct {
+		name    string
+		tr      Transform
+		wantErr bool
+	}{
+		{"单位变换", NewTransform(), false},
+		{"零值", Transform{}, false},
+		{"平移", NewTransform().Translate(10, -5), false},
+		{"绕中心旋转缩放", NewTransform().About(50, 40).Rotate(30).Scale(2, 0.5), false},
+		{"错切", NewTransform().Shear(0.3, 0.1).Translate(3, 4), false},
+		{"透视", TransformFromMatrix([3][3]float64{{1, 0.2, 5}, {0.1, 1, 3}, {0.001, 0.002, 1}}), false},
+		{"缩放为0", NewTransform().Scale(0, 1), true},
+	}
+	points := [][2]float64{{0, 0}, {100, 0}, {37.5, 81.25}, {-20, 60}}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			inv, err := c.tr.Inverse()
+			if (err != nil) != c.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, c.wantErr)
+			}
+			if c.wantErr {
+				return
+			}
+			for _, p := range points {
+				x, y := c.tr.Apply(p[0], p[1])
+				bx, by := inv.Apply(x, y)
+				if math.Abs(bx-p[0]) > 1e-9 || math.Abs(by-p[1]) > 1e-9 {
+					t.Errorf("点 %v 变换后逆变换为 (%g, %g)", p, bx, by)
+				}
+			}
+		})
+	}
+}
+
+func TestTransformBounds(t *testing.T) {
+	r := image.Rect(0, 0, 100, 50)
+	cases := []struct {
+		name    string
+		tr      Transform
+		want    image.Rectangle
+		wantErr bool
+	}{
+		{"单位变换", NewTransform(), r, false},
+		{"平移", NewTransform().Translate(10.5, -5), image.Rect(10, -5, 111, 45), false},
+		{"缩放", NewTransform().Scale(2, 3), image.Rect(0, 0, 200, 150), false},
+		{"镜像", NewTransform().Scale(-1, 1), image.Rect(-100, 0, 0, 50), false},
+		{"旋转90度", NewTransform().Rotate(90), image.Rect(-50, 0, 0, 100), false},
+		{"绕中心旋转180度", NewTransform().About(50, 25).Rotate(180), r, false},
+		{"旋转45度", NewTransform().Rotate(45), image.Rect(-36, 0, 71, 107), false},
+		{"透视无界", TransformFromMatrix([3][3]float64{{1, 0, 0}, {0, 1, 0}, {-0.02, 0, 1}}), image.Rectangle{}, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := c.tr.Bounds(r)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, c.wantErr)
+			}
+			if got != c.want {
+				t.Errorf("Bounds = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
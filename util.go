@@ -3,6 +3,7 @@ package imgHelper
 import (
 	"image"
 	"image/color"
+	"image/draw"
 	"math"
 )
 
@@ -57,17 +58,6 @@ func minValue[T SignedNumeric](n ...T) T {
 	return minN
 }
 
-// 双线性插值计算颜色
-func interpolateColor(c1, c2 color.Color, t float64) (uint8, uint8, uint8, uint8) {
-	r1, g1, b1, a1 := c1.RGBA()
-	r2, g2, b2, a2 := c2.RGBA()
-	r := uint8((1-t)*float64(r1>>8) + t*float64(r2>>8))
-	g := uint8((1-t)*float64(g1>>8) + t*float64(g2>>8))
-	b := uint8((1-t)*float64(b1>>8) + t*float64(b2>>8))
-	a := uint8((1-t)*float64(a1>>8) + t*float64(a2>>8))
-	return r, g, b, a
-}
-
 // 辅助函数：判断点(pX,pY)是否在三角形(x1,y1)-(x2,y2)-(x3,y3)内部（含边界）
 // 原理：通过向量叉乘判断点与三条边的位置关系，若在同一侧则在内部
 func isPointInTriangle[T SignedNumeric](pX, pY, x1, y1, x2, y2, x3, y3 T) bool {
@@ -84,58 +74,6 @@ func isPointInTriangle[T SignedNumeric](pX, pY, x1, y1, x2, y2, x3, y3 T) bool {
 	return !(hasPositive && hasNegative)
 }
 
-// isPointInPolygon 用射线法判断点(pX,pY)是否在多边形内部（含边界）
-// 原理：从点向右发射水平射线，统计与多边形边的交点数量，奇数则在内部，偶数则在外部
-func isPointInPolygon(pX, pY int, vertices [][2]int) bool {
-	n := len(vertices)
-	if n < 3 {
-		return false // 非多边形
-	}
-	inside := false
-
-	for i := 0; i < n; i++ {
-		j := (i + 1) % n // 下一个顶点索引（闭合多边形）
-		vi := vertices[i]
-		vj := vertices[j]
-		xi, yi := vi[0], vi[1]
-		xj, yj := vj[0], vj[1]
-
-		// 检查点是否在当前边上（边界情况）
-		if isPointOnLine(pX, pY, xi, yi, xj, yj) {
-			return true
-		}
-
-		// 射线与边相交判断（仅处理边跨越射线y坐标的情况）
-		if (yi > pY) != (yj > pY) {
-			// 计算交点的x坐标（射线是水平向右的，y=pY）
-			xIntersect := ((pY-yi)*(xj-xi))/(yj-yi) + xi
-			// 若交点在点的右侧，则计数+1
-			if pX < xIntersect {
-				inside = !inside
-			}
-		}
-	}
-
-	return inside
-}
-
-// isPointOnLine 判断点(pX,pY)是否在直线段(x1,y1)-(x2,y2)上（边界处理）
-func isPointOnLine(pX, pY, x1, y1, x2, y2 int) bool {
-	// 点在直线的 bounding box 内
-	if (pX < minValue(x1, x2) || pX > minValue(x1, x2)) ||
-		(pY < minValue(y1, y2) || pY > minValue(y1, y2)) {
-		return false
-	}
-
-	// 向量叉积为0（点在直线上）
-	cross := (x2-x1)*(pY-y1) - (y2-y1)*(pX-x1)
-	if cross != 0 {
-		return false
-	}
-
-	return true
-}
-
 // RGBToHSV 将 RGB 颜色转换为 HSV 颜色
 func RGBToHSV(r, g, b uint8) (float64, float64, float64) {
 	rNorm := float64(r) / 255.0
@@ -220,20 +158,29 @@ func generateGaussianKernel(sigma float64) []float64 {
 }
 
 // 针对黑色文字+浅色背景，强制将文字转为前景（255），背景转为0
-func binaryImgForText(img image.Image) [][]uint8 {
-	bounds := img.Bounds()
-	width, height := bounds.Max.X, bounds.Max.Y
+// 二值化使用 BinaryImgWithOptions，不大于阈值的文字像素为前景；未指定参数时使用固定阈值50
+func binaryImgForText(img image.Image, opts ...BinaryOptions) [][]uint8 {
+	threshold := 50
+	fallback := BinaryOptions{Threshold: &threshold}
+	opt := fallback
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	binary, err := BinaryImgWithOptions(img, opt)
+	if err != nil {
+		// 参数无效时退回默认阈值
+		binary, _ = BinaryImgWithOptions(img, fallback)
+	}
+	gray := binary.(*image.Gray)
+	bounds := gray.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
 	bin := make([][]uint8, height)
 	for y := 0; y < height; y++ {
 		bin[y] = make([]uint8, width)
+		row := gray.Pix[y*gray.Stride:]
 		for x := 0; x < width; x++ {
-			// 转为灰度后，低于阈值的文字像素设为255，否则为0
-			r, g, b, _ := img.At(x, y).RGBA()
-			gray := uint8(0.299*float64(r>>8) + 0.587*float64(g>>8) + 0.114*float64(b>>8))
-			if gray <= 50 {
+			if row[x] == 0 {
 				bin[y][x] = 255 // 文字（前景）
-			} else {
-				bin[y][x] = 0 // 背景
 			}
 		}
 	}
@@ -252,6 +199,9 @@ var neighbors = []image.Point{
 	{-1, -1}, // p9: (x-1, y-1)
 }
 
+// neighbors4 4邻域
+var neighbors4 = []image.Point{{0, -1}, {1, 0}, {0, 1}, {-1, 0}}
+
 // countConnections 计算8邻域的连接数（衡量像素的"拐角"程度）
 func countConnections(bin [][]uint8, x, y int) int {
 	height, width := len(bin), len(bin[0])
@@ -311,6 +261,49 @@ func cloneImage(img image.Image) *image.RGBA {
 	return clone
 }
 
+// image.Image to *image.RGBA，保留原图的 Bounds
+func imageToRGBA(src image.Image) *image.RGBA {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(bounds)
+	draw.Draw(dst, bounds, src, bounds.Min, draw.Src)
+	return dst
+}
+
+// *image.NRGBA to *image.RGBA，并将结果放置到 bounds 范围
+func nrgbaToRGBA(src *image.NRGBA, bounds image.Rectangle) *image.RGBA {
+	dst := image.NewRGBA(bounds)
+	draw.Draw(dst, bounds, src, src.Bounds().Min, draw.Src)
+	return dst
+}
+
+// luminance 计算亮度值 (BT.601)
+func luminance(r, g, b uint8) uint8 {
+	return uint8(math.Round(float64(r)*0.299 + float64(g)*0.587 + float64(b)*0.114))
+}
+
+// grayPlane 计算图像的亮度平面，返回按行存储的亮度值和图像宽高
+func grayPlane(src image.Image) ([]float64, int, int) {
+	nrgba := imageToNRGBA(src)
+	w, h := nrgba.Bounds().Dx(), nrgba.Bounds().Dy()
+	plane := make([]float64, w*h)
+	for i := range plane {
+		o := i * 4
+		plane[i] = float64(nrgba.Pix[o])*0.299 + float64(nrgba.Pix[o+1])*0.587 + float64(nrgba.Pix[o+2])*0.114
+	}
+	return plane, w, h
+}
+
+// planeToGray 将亮度平面写入灰度图，超出 0~255 的值会被截断
+func planeToGray(plane []float64, w, h int, bounds image.Rectangle) *image.Gray {
+	dst := image.NewGray(image.Rect(bounds.Min.X, bounds.Min.Y, bounds.Min.X+w, bounds.Min.Y+h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dst.Pix[y*dst.Stride+x] = uint8(clamp(math.Round(plane[y*w+x]), 0, 255))
+		}
+	}
+	return dst
+}
+
 // 检查坐标是否在边界内
 func inBounds(bounds image.Rectangle, x, y int) bool {
 	return x >= bounds.Min.X && x < bounds.Max.X &&
@@ -496,3 +489,26 @@ func imageToNRGBA(src image.Image) *image.NRGBA {
 
 	return dst
 }
+
+// rgbToLab 将 sRGB 颜色转换为 CIE Lab 颜色(D65 白点)，Lab 空间中的欧氏距离更接近人眼感知的色差
+func rgbToLab(r, g, b uint8) (float64, float64, float64) {
+	linear := func(v uint8) float64 {
+		c := float64(v) / 255
+		if c <= 0.04045 {
+			return c / 12.92
+		}
+		return math.Pow((c+0.055)/1.055, 2.4)
+	}
+	rl, gl, bl := linear(r), linear(g), linear(b)
+	x := (rl*0.4124 + gl*0.3576 + bl*0.1805) / 0.95047
+	y := rl*0.2126 + gl*0.7152 + bl*0.0722
+	z := (rl*0.0193 + gl*0.1192 + bl*0.9505) / 1.08883
+	f := func(t float64) float64 {
+		if t > 0.008856 {
+			return math.Cbrt(t)
+		}
+		return 7.787*t + 16.0/116
+	}
+	fx, fy, fz := f(x), f(y), f(z)
+	return 116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)
+}